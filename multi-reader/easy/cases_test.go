@@ -0,0 +1,34 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"testing"
+	"time"
+)
+
+var parallelCases = flag.Bool("cases.parallel", false, "запускать тест-кейсы параллельно")
+
+// TestCases запускает публичные и приватные тест-кейсы как подтесты: их можно фильтровать через -run,
+// запускать с -race и параллельно (-cases.parallel), а провал одного кейса не останавливает остальные.
+// Кейс, не уложившийся в свой таймаут, проваливается со стеками всех горутин.
+func TestCases(t *testing.T) {
+	for _, tc := range append(testCases, privateTestCases...) {
+		t.Run(tc.name, func(t *testing.T) {
+			if *parallelCases {
+				t.Parallel()
+			}
+			done := make(chan bool, 1)
+			go func() { done <- tc.run() }()
+			select {
+			case ok := <-done:
+				if !ok {
+					t.Fatal("провал")
+				}
+			case <-time.After(tc.caseTimeout()):
+				dumpGoroutines(os.Stderr)
+				t.Fatalf("таймаут (%s)", tc.caseTimeout())
+			}
+		})
+	}
+}
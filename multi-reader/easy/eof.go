@@ -0,0 +1,32 @@
+package main
+
+import (
+	"errors"
+	"io"
+)
+
+// EOFStyle задаёт, как Read сообщает о конце потока. io.Reader допускает оба варианта,
+// но потребители, сравнивающие (n, err) с эталоном, должны знать, какой из них получат.
+type EOFStyle int
+
+const (
+	// EOFDefault - поведение реализации по умолчанию: easy отдаёт данные без ошибки и EOF следующим вызовом,
+	// hard возвращает EOF вместе с данными, если буфер не удалось заполнить до конца.
+	EOFDefault EOFStyle = iota
+	// EOFStrict - данные всегда без ошибки, (0, io.EOF) - только следующим вызовом.
+	EOFStrict
+	// EOFEager - io.EOF вместе с последними байтами потока, даже если буфер заполнен целиком.
+	EOFEager
+)
+
+// apply приводит результат Read к стилю s; atEnd - курсор после чтения стоит на конце потока.
+func (s EOFStyle) apply(n int, err error, atEnd bool) error {
+	switch {
+	case s == EOFStrict && n > 0 && errors.Is(err, io.EOF):
+		return nil
+	case s == EOFEager && n > 0 && err == nil && atEnd:
+		return io.EOF
+	default:
+		return err
+	}
+}
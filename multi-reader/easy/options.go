@@ -0,0 +1,30 @@
+package main
+
+// Option настраивает MultiReader при создании.
+type Option func(*MultiReader)
+
+// NewMultiReaderWithOptions создаёт мультиридер так же, как NewMultiReader, и применяет к нему опции.
+func NewMultiReaderWithOptions(readers []SizedReadSeekCloser, opts ...Option) *MultiReader {
+	m := NewMultiReader(readers...)
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// WithEOFStyle задаёт, как Read сообщает о конце потока (см. EOFStyle).
+func WithEOFStyle(style EOFStyle) Option {
+	return func(m *MultiReader) {
+		m.eofStyle = style
+	}
+}
+
+// WithMaxEmptyReads задаёт, сколько чтений (0, nil) подряд из источника допускается, прежде чем Read вернёт
+// io.ErrNoProgress. n <= 0 оставляет значение по умолчанию.
+func WithMaxEmptyReads(n int) Option {
+	return func(m *MultiReader) {
+		if n > 0 {
+			m.maxEmpty = n
+		}
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"io"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 var privateTestCases = []TestCase{
@@ -185,4 +187,127 @@ var privateTestCases = []TestCase{
 			return errors.Is(err, io.EOF)
 		},
 	},
+	{
+		name: "Переход к уже читанному ридеру без EOF выставляет его позицию",
+		run: func() bool {
+			m := NewMultiReader(newMockStringsReader("abc"), newMockStringsReader("def"))
+
+			// Дочитываем до середины второго ридера и возвращаемся в начало
+			buf := make([]byte, 5)
+			if _, err := io.ReadFull(m, buf); err != nil || string(buf) != "abcde" {
+				return false
+			}
+			if _, err := m.Seek(0, io.SeekStart); err != nil {
+				return false
+			}
+
+			// strings.Reader отдаёт остаток первого ридера без EOF, и чтение переходит во второй ридер
+			got := make([]byte, 6)
+			n, err := m.Read(got)
+			return err == nil && n == 6 && string(got) == "abcdef"
+		},
+	},
+	{
+		name: "Одновременные Read и Seek из нескольких горутин не теряют и не дублируют байты",
+		run: func() bool {
+			content := strings.Repeat("0123456789abcdef", 512)
+			m := NewMultiReader(newMockStringsReader(content[:3000]), newMockStringsReader(content[3000:]))
+
+			// Seek на текущую позицию не двигает курсор, но конкурирует с Read за состояние
+			var wg sync.WaitGroup
+			var total atomic.Int64
+			counts := make([][256]int, 4)
+			for g := range counts {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					buf := make([]byte, 1+g*37)
+					for {
+						if _, err := m.Seek(0, io.SeekCurrent); err != nil {
+							return
+						}
+						n, err := m.Read(buf)
+						total.Add(int64(n))
+						for _, c := range buf[:n] {
+							counts[g][c]++
+						}
+						if err != nil {
+							return
+						}
+					}
+				}()
+			}
+			wg.Wait()
+
+			var want [256]int
+			for i := 0; i < len(content); i++ {
+				want[content[i]]++
+			}
+			for c := range want {
+				if counts[0][c]+counts[1][c]+counts[2][c]+counts[3][c] != want[c] {
+					return false
+				}
+			}
+			return total.Load() == m.Size() && m.Close() == nil
+		},
+	},
+	{
+		name: "Стиль EOF: строгий и ранний на границе источников и в конце потока",
+		run: func() bool {
+			type result struct {
+				n   int
+				err error
+			}
+			// reads читает буферами указанных длин и возвращает (n, err) каждого вызова
+			reads := func(style EOFStyle, sizes ...int) []result {
+				m := NewMultiReaderWithOptions([]SizedReadSeekCloser{newMockStringsReader("ab"), newMockStringsReader("cd")}, WithEOFStyle(style))
+				var out []result
+				for _, size := range sizes {
+					n, err := m.Read(make([]byte, size))
+					out = append(out, result{n, err})
+				}
+				return out
+			}
+			equal := func(got []result, want ...result) bool {
+				if len(got) != len(want) {
+					return false
+				}
+				for i := range got {
+					if got[i].n != want[i].n || got[i].err != want[i].err {
+						return false
+					}
+				}
+				return true
+			}
+
+			return equal(reads(EOFDefault, 2, 2, 1), result{2, nil}, result{2, nil}, result{0, io.EOF}) &&
+				equal(reads(EOFStrict, 2, 10, 1), result{2, nil}, result{2, nil}, result{0, io.EOF}) &&
+				equal(reads(EOFEager, 2, 2, 1), result{2, nil}, result{2, io.EOF}, result{0, io.EOF}) &&
+				equal(reads(EOFEager, 10, 1), result{4, io.EOF}, result{0, io.EOF})
+		},
+	},
+	{
+		name: "Источник, отвечающий (0, nil), даёт io.ErrNoProgress после лимита пустых чтений",
+		run: func() bool {
+			// Несколько пустых чтений подряд в пределах лимита переживаются незаметно
+			m := NewMultiReader(&stallingReader{SizedReadSeekCloser: newMockStringsReader("data"), stalls: 5})
+			got, err := io.ReadAll(m)
+			if err != nil || string(got) != "data" {
+				return false
+			}
+
+			// Зависший источник не зацикливает Read; уже прочитанные байты отдаются без ошибки
+			stuck := NewMultiReaderWithOptions([]SizedReadSeekCloser{
+				newMockStringsReader("ab"),
+				&stallingReader{SizedReadSeekCloser: newMockStringsReader("cd"), stalls: -1},
+			}, WithMaxEmptyReads(3))
+			buf := make([]byte, 4)
+			n, err := stuck.Read(buf)
+			if n != 2 || err != nil {
+				return false
+			}
+			n, err = stuck.Read(buf)
+			return n == 0 && errors.Is(err, io.ErrNoProgress)
+		},
+	},
 }
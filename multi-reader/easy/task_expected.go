@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"sort"
+	"sync"
 )
 
 // SizedReadSeekCloser - интерфейс ридера с возможностью seek и знанием своего размера.
@@ -13,14 +14,21 @@ type SizedReadSeekCloser interface {
 	Size() int64
 }
 
+// defaultMaxEmptyReads - сколько чтений (0, nil) подряд допускается, прежде чем Read вернёт io.ErrNoProgress.
+const defaultMaxEmptyReads = 100
+
 // MultiReader объединяет несколько SizedReadSeekCloser в единый конкатенированный поток.
+// Методы безопасны для вызова из нескольких горутин: Read, Seek и Close сериализуются мьютексом.
 type MultiReader struct {
+	mu          sync.Mutex            // Защищает absPos, needSeek, closed и позиции исходных ридеров
 	readers     []SizedReadSeekCloser // Содержит исходные ридеры в порядке конкатенации
 	totalSize   int64                 // Суммарный размер всех ридеров, вычисляется один раз в NewMultiReader
 	prefixSizes []int64               // prefixSizes[i] - абсолютная стартовая позиция i-го ридера (длина = len(readers)+1)
 	absPos      int64                 // Абсолютная позиция в объединённом потоке
 	needSeek    bool                  // Флаг - нужно ли выставить позицию перед следующим чтением
 	closed      bool                  // Флаг - MultiReader закрыт и дальнейшие операции недоступны
+	eofStyle    EOFStyle              // Как Read сообщает о конце потока (WithEOFStyle)
+	maxEmpty    int                   // Сколько чтений (0, nil) подряд допускается до io.ErrNoProgress (WithMaxEmptyReads)
 }
 
 // NewMultiReader создаёт конкатенированный ридер поверх набора SizedReadSeekCloser.
@@ -40,6 +48,7 @@ func NewMultiReader(readers ...SizedReadSeekCloser) *MultiReader {
 		absPos:      0,
 		needSeek:    true,
 		closed:      false,
+		maxEmpty:    defaultMaxEmptyReads,
 	}
 }
 
@@ -48,6 +57,10 @@ var _ SizedReadSeekCloser = (*MultiReader)(nil)
 
 // Read читает данные последовательно из всех ридеров в порядке передачи в NewMultiReader.
 func (m *MultiReader) Read(p []byte) (n int, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	defer func() { err = m.eofStyle.apply(n, err, m.absPos == m.totalSize) }()
+
 	if m.closed {
 		return 0, io.ErrClosedPipe
 	}
@@ -58,6 +71,7 @@ func (m *MultiReader) Read(p []byte) (n int, err error) {
 		return 0, io.EOF
 	}
 
+	empty := 0 // Чтения (0, nil) подряд
 	for n < len(p) {
 		if m.absPos == m.totalSize { // Уже что-то прочитали в этом вызове (n > 0), а затем дошли до конца
 			return n, nil
@@ -85,11 +99,19 @@ func (m *MultiReader) Read(p []byte) (n int, err error) {
 		if k > 0 {
 			n += k
 			m.absPos += int64(k)
+			if m.absPos == m.prefixSizes[i+1] { // Ридер дочитан без EOF - позицию следующего выставим перед чтением
+				m.needSeek = true
+			}
 		}
 
 		switch {
-		case readErr == nil && k == 0: // Текущий ридер не продвинулся и не вернул ошибку. Выходим, чтобы не зациклиться
+		case readErr == nil && k == 0 && n > 0: // Текущий ридер не продвинулся, но данные уже есть - отдаём их
 			return n, nil
+		case readErr == nil && k == 0: // Ридер не продвинулся и не вернул ошибку. Повторяем, но не бесконечно
+			if empty++; empty >= m.maxEmpty {
+				return 0, io.ErrNoProgress
+			}
+			continue
 		case readErr == nil: // Прочитали k > 0 байт без ошибки. Пытаемся дочитать дальше
 			continue
 		case errors.Is(readErr, io.EOF): // Текущий ридер закончился. Не возвращаем EOF сразу, а переходим к след. ридеру.
@@ -106,6 +128,9 @@ func (m *MultiReader) Read(p []byte) (n int, err error) {
 
 // Seek перемещает курсор в объединённой последовательности ридеров.
 func (m *MultiReader) Seek(offset int64, whence int) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	if m.closed {
 		return 0, io.ErrClosedPipe
 	}
@@ -135,6 +160,9 @@ func (m *MultiReader) Seek(offset int64, whence int) (int64, error) {
 
 // Close закрывает все ридеры, объединяя все ошибки.
 func (m *MultiReader) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	if m.closed {
 		return nil
 	}
@@ -156,7 +184,7 @@ func (m *MultiReader) Close() error {
 	return nil
 }
 
-// Size возвращает суммарный размер всех ридеров.
+// Size возвращает суммарный размер всех ридеров. totalSize не меняется после создания, поэтому блокировка не нужна.
 func (m *MultiReader) Size() int64 {
 	return m.totalSize
 }
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"fmt"
+	"io"
+)
+
+// AESCTRSource расшифровывает источник, зашифрованный AES-CTR ключом key с начальным счётчиком iv, на лету
+// с сохранением произвольного доступа: при Seek счётчик вычисляется по смещению. Размер не меняется.
+// CTR симметричен, поэтому тот же источник поверх открытого текста отдаёт шифротекст.
+func AESCTRSource(raw SizedReadSeekCloser, key, iv []byte) (SizedReadSeekCloser, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != aes.BlockSize {
+		return nil, fmt.Errorf("iv length (%d) should be %d", len(iv), aes.BlockSize)
+	}
+	iv = append([]byte(nil), iv...)
+
+	return TransformSource(raw, raw.Size(), func(raw SizedReadSeekCloser) (io.ReadSeeker, error) {
+		return &ctrSeeker{raw: raw, block: block, iv: iv, pos: -1}, nil
+	}), nil
+}
+
+// ctrSeeker - расшифровывающий io.ReadSeeker поверх шифротекста AES-CTR.
+type ctrSeeker struct {
+	raw    io.ReadSeeker
+	block  cipher.Block
+	iv     []byte        // начальный счётчик
+	stream cipher.Stream // поток ключа, выставленный на позицию pos
+	pos    int64         // позиция в потоке (-1 - поток ключа ещё не выставлен)
+}
+
+func (c *ctrSeeker) Read(p []byte) (int, error) {
+	if c.pos < 0 {
+		if _, err := c.Seek(0, io.SeekStart); err != nil {
+			return 0, err
+		}
+	}
+	n, err := c.raw.Read(p)
+	c.stream.XORKeyStream(p[:n], p[:n])
+	c.pos += int64(n)
+
+	return n, err
+}
+
+// Seek перемещает сырой источник и пересчитывает счётчик: iv + off/BlockSize, с пропуском off%BlockSize байт ключа.
+func (c *ctrSeeker) Seek(offset int64, whence int) (int64, error) {
+	pos, err := c.raw.Seek(offset, whence)
+	if err != nil {
+		return 0, err
+	}
+
+	counter := append([]byte(nil), c.iv...)
+	carry := uint64(pos / aes.BlockSize)
+	for i := len(counter) - 1; i >= 0 && carry > 0; i-- { // 128-битное сложение big-endian
+		sum := uint64(counter[i]) + carry&0xff
+		counter[i] = byte(sum)
+		carry = carry>>8 + sum>>8
+	}
+	c.stream = cipher.NewCTR(c.block, counter)
+	if skip := pos % aes.BlockSize; skip > 0 {
+		discard := make([]byte, skip)
+		c.stream.XORKeyStream(discard, discard)
+	}
+	c.pos = pos
+
+	return pos, nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"io"
+)
+
+// sectionSource - участок io.ReaderAt как SizedReadSeekCloser. Close ничего не делает: ReaderAt принадлежит вызывающему.
+type sectionSource struct {
+	*io.SectionReader
+}
+
+// Проверка, что sectionSource удовлетворяет интерфейсу SizedReadSeekCloser
+var _ SizedReadSeekCloser = sectionSource{}
+
+func (sectionSource) Close() error {
+	return nil
+}
+
+// ZipEntrySource превращает член zip-архива в источник. Несжатый член читается напрямую из архива
+// по смещению данных (без проверки CRC); сжатый - распаковывается, Seek назад переоткрывает его.
+func ZipEntrySource(f *zip.File) (SizedReadSeekCloser, error) {
+	if f.Method == zip.Store {
+		raw, err := f.OpenRaw()
+		if err != nil {
+			return nil, err
+		}
+		if sr, ok := raw.(*io.SectionReader); ok {
+			return sectionSource{sr}, nil
+		}
+	}
+
+	return newReopenSource(int64(f.UncompressedSize64), func() (io.ReadCloser, error) { return f.Open() }), nil
+}
+
+// TarEntry - расположение данных члена tar-архива.
+type TarEntry struct {
+	Name   string `json:"name"`   // имя члена
+	Offset int64  `json:"offset"` // смещение данных от начала архива
+	Size   int64  `json:"size"`   // размер данных
+}
+
+// BuildTarIndex читает tar-архив целиком и возвращает расположение данных его обычных файлов.
+func BuildTarIndex(r io.Reader) ([]TarEntry, error) {
+	cr := &countingByteReader{r: bufio.NewReader(r)}
+	tr := tar.NewReader(cr)
+
+	var entries []TarEntry
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return entries, err
+		}
+		if hdr.Typeflag != tar.TypeReg { // Каталоги, ссылки и разреженные файлы не имеют непрерывных данных
+			continue
+		}
+		entries = append(entries, TarEntry{Name: hdr.Name, Offset: cr.n, Size: hdr.Size})
+	}
+}
+
+// TarEntrySource возвращает источник данных члена tar-архива по записи индекса. Архив читается позиционно и не закрывается.
+func TarEntrySource(archive io.ReaderAt, e TarEntry) SizedReadSeekCloser {
+	return sectionSource{io.NewSectionReader(archive, e.Offset, e.Size)}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"runtime/pprof"
 	"time"
 )
 
@@ -79,7 +80,13 @@ func AssertPrint(message string, expected string, cb func()) {
 }
 
 func ConcurrentCustomTestBody[T any](message string, prepare func() T, check func(T) bool) {
-	ctx, cancel := context.WithTimeout(context.Background(), concurrentTestTimeout)
+	ConcurrentCustomTestBodyWithTimeout(message, concurrentTestTimeout, prepare, check)
+}
+
+// ConcurrentCustomTestBodyWithTimeout - ConcurrentCustomTestBody с таймаутом timeout.
+// По таймауту перед выходом печатает стеки всех горутин, чтобы было видно, где завис тест.
+func ConcurrentCustomTestBodyWithTimeout[T any](message string, timeout time.Duration, prepare func() T, check func(T) bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
 	finished := make(chan struct{}, 1)
@@ -93,15 +100,30 @@ func ConcurrentCustomTestBody[T any](message string, prepare func() T, check fun
 	case <-ctx.Done():
 		_, _ = fmt.Fprintf(
 			os.Stderr,
-			"Тест кейс %q - таймаут\n",
+			"Тест кейс %q - таймаут (%s)\n",
 			message,
+			timeout,
 		)
+		dumpGoroutines(os.Stderr)
 
 		os.Exit(1)
 	case <-finished:
 	}
 }
 
+// caseTimeout возвращает таймаут кейса: заданный в TestCase или concurrentTestTimeout по умолчанию.
+func (tc TestCase) caseTimeout() time.Duration {
+	if tc.timeout > 0 {
+		return tc.timeout
+	}
+	return concurrentTestTimeout
+}
+
+// dumpGoroutines пишет стеки всех горутин в w.
+func dumpGoroutines(w io.Writer) {
+	_ = pprof.Lookup("goroutine").WriteTo(w, 2)
+}
+
 func compareSimpleTypes[T comparable](expected T, actual T) bool {
 	return expected == actual
 }
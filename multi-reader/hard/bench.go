@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// runBench - режим замеров: `go run . bench -manifest layout.json -windows 1,2,4,8`. Читает склейку,
+// описанную манифестом (источники - файлы по ManifestEntry.Name), при каждой глубине окна и печатает пропускную
+// способность и аллокации, чтобы подобрать buffersNum под своё хранилище. Размер блока - константа bufferSize.
+func runBench(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("bench", flag.ContinueOnError)
+	fs.SetOutput(out)
+	manifestPath := fs.String("manifest", "", "путь к JSON-манифесту склейки")
+	windows := fs.String("windows", "1,2,4,8", "глубины окна (buffersNum) через запятую")
+	runs := fs.Int("runs", 3, "прогонов на каждую глубину (берётся лучший)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *manifestPath == "" {
+		return errors.New("bench: -manifest is required")
+	}
+	depths, err := parseDepths(*windows)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(*manifestPath)
+	if err != nil {
+		return err
+	}
+	mf, err := ReadManifest(f)
+	_ = f.Close()
+	if err != nil {
+		return err
+	}
+	open := func(_ context.Context, e ManifestEntry) (SizedReadSeekCloser, error) { return OpenFileSource(e.Name) }
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "buffersNum\tblock\tMiB/s\tallocs\talloc MiB\t\n")
+	for _, depth := range depths {
+		best := benchResult{elapsed: time.Duration(1<<63 - 1)}
+		for range max(*runs, 1) {
+			res, err := benchOnce(depth, mf, open)
+			if err != nil {
+				return fmt.Errorf("buffersNum %d: %w", depth, err)
+			}
+			if res.elapsed < best.elapsed {
+				best = res
+			}
+		}
+		mibps := float64(mf.TotalSize) / (1 << 20) / max(best.elapsed.Seconds(), 1e-9)
+		fmt.Fprintf(tw, "%d\t%d\t%.1f\t%d\t%.1f\t\n", depth, bufferSize, mibps, best.allocs, float64(best.allocBytes)/(1<<20))
+	}
+
+	return tw.Flush()
+}
+
+// benchResult - итог одного прогона.
+type benchResult struct {
+	elapsed    time.Duration
+	allocs     uint64
+	allocBytes uint64
+}
+
+// benchOnce читает всю склейку один раз с окном глубины depth.
+func benchOnce(depth int, mf Manifest, open ManifestOpener) (benchResult, error) {
+	m, err := NewMultiReaderFromManifest(context.Background(), depth, mf, open)
+	if err != nil {
+		return benchResult{}, err
+	}
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	_, err = io.Copy(io.Discard, m)
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+	if closeErr := m.Close(); err == nil {
+		err = closeErr
+	}
+
+	return benchResult{
+		elapsed:    elapsed,
+		allocs:     after.Mallocs - before.Mallocs,
+		allocBytes: after.TotalAlloc - before.TotalAlloc,
+	}, err
+}
+
+// parseDepths разбирает список глубин окна "1,2,4".
+func parseDepths(s string) ([]int, error) {
+	var depths []int
+	for _, field := range strings.Split(s, ",") {
+		depth, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || depth <= 0 {
+			return nil, fmt.Errorf("bench: invalid window depth %q", field)
+		}
+		depths = append(depths, depth)
+	}
+	return depths, nil
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// benchImpl - реализация под замером: последовательный эталон referenceReader (алгоритм easy) или MultiReader из hard
+// с префетчем и без (WithPrefetch(false)).
+type benchImpl struct {
+	name string
+	open func(readers []SizedReadSeekCloser) io.ReadSeekCloser
+}
+
+var benchImpls = []benchImpl{
+	{"reference", func(readers []SizedReadSeekCloser) io.ReadSeekCloser { return newReferenceReader(readers...) }},
+	{"hard", func(readers []SizedReadSeekCloser) io.ReadSeekCloser { return NewMultiReader(4, readers...) }},
+	{"hard-sync", func(readers []SizedReadSeekCloser) io.ReadSeekCloser {
+		return NewMultiReaderWithOptions(4, readers, WithPrefetch(false))
+	}},
+}
+
+// benchReadSizes - размеры буфера Read; блок префетча - константа bufferSize.
+var benchReadSizes = []int{4 << 10, 64 << 10, bufferSize}
+
+// benchSources режет size байт синтетического содержимого на count источников.
+func benchSources(size int64, count int) []SizedReadSeekCloser {
+	readers := make([]SizedReadSeekCloser, count)
+	part := size / int64(count)
+	for i := range readers {
+		readers[i] = SyntheticSource(part, int64(i))
+	}
+	return readers
+}
+
+// BenchmarkSequentialRead - последовательное чтение 16 МиБ из 16 источников разными размерами буфера.
+func BenchmarkSequentialRead(b *testing.B) {
+	const size = 16 << 20
+	for _, impl := range benchImpls {
+		for _, readSize := range benchReadSizes {
+			b.Run(fmt.Sprintf("%s/read=%d", impl.name, readSize), func(b *testing.B) {
+				buf := make([]byte, readSize)
+				b.SetBytes(size)
+				b.ReportAllocs()
+				for range b.N {
+					r := impl.open(benchSources(size, 16))
+					if _, err := io.CopyBuffer(io.Discard, struct{ io.Reader }{r}, buf); err != nil {
+						b.Fatal(err)
+					}
+					_ = r.Close()
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkSeekHeavy - случайный Seek и короткое чтение после каждого.
+func BenchmarkSeekHeavy(b *testing.B) {
+	const size, readSize = 16 << 20, 4 << 10
+	for _, impl := range benchImpls {
+		b.Run(impl.name, func(b *testing.B) {
+			r := impl.open(benchSources(size, 16))
+			defer r.Close()
+			rnd := rand.New(rand.NewSource(1))
+			buf := make([]byte, readSize)
+			b.SetBytes(readSize)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for range b.N {
+				if _, err := r.Seek(rnd.Int63n(size-readSize), io.SeekStart); err != nil {
+					b.Fatal(err)
+				}
+				if _, err := io.ReadFull(r, buf); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkManyTinySources - последовательное чтение склейки из 4096 источников по 1 КиБ.
+func BenchmarkManyTinySources(b *testing.B) {
+	const count, part = 4096, 1 << 10
+	for _, impl := range benchImpls {
+		b.Run(impl.name, func(b *testing.B) {
+			buf := make([]byte, 64<<10)
+			b.SetBytes(count * part)
+			b.ReportAllocs()
+			for range b.N {
+				r := impl.open(benchSources(count*part, count))
+				if _, err := io.CopyBuffer(io.Discard, struct{ io.Reader }{r}, buf); err != nil {
+					b.Fatal(err)
+				}
+				_ = r.Close()
+			}
+		})
+	}
+}
+
+// BenchmarkRandomReadAt - случайные ReadAt разного размера. В easy ReadAt нет, поэтому замер только для hard.
+func BenchmarkRandomReadAt(b *testing.B) {
+	const size = 16 << 20
+	for _, readSize := range benchReadSizes {
+		b.Run(fmt.Sprintf("hard/read=%d", readSize), func(b *testing.B) {
+			m := NewMultiReader(4, benchSources(size, 16)...)
+			defer m.Close()
+			rnd := rand.New(rand.NewSource(1))
+			buf := make([]byte, readSize)
+			b.SetBytes(int64(readSize))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for range b.N {
+				if _, err := m.ReadAt(buf, rnd.Int63n(size-int64(readSize))); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkTinyReads - последовательное чтение 4 МиБ мелкими Read по 1-64 байта, как у побайтовых парсеров.
+func BenchmarkTinyReads(b *testing.B) {
+	const size = 4 << 20
+	for _, impl := range benchImpls {
+		b.Run(impl.name, func(b *testing.B) {
+			buf := make([]byte, 64)
+			b.SetBytes(size)
+			b.ReportAllocs()
+			for range b.N {
+				r := impl.open(benchSources(size, 4))
+				for i := 0; ; i++ {
+					if _, err := r.Read(buf[:1+i%len(buf)]); err == io.EOF {
+						break
+					} else if err != nil {
+						b.Fatal(err)
+					}
+				}
+				_ = r.Close()
+			}
+		})
+	}
+}
+
+// BenchmarkServeMultiReader - отдача 16 МиБ из 4 файлов в HTTP-ответ: ServeMultiReader копирует по сегментам
+// (sendfile для файлов), window - io.Copy через окно префетча, как было до ServeMultiReader.
+func BenchmarkServeMultiReader(b *testing.B) {
+	const size, count = 16 << 20, 4
+	dir := b.TempDir()
+	paths := make([]string, count)
+	for i := range paths {
+		paths[i] = filepath.Join(dir, fmt.Sprintf("part-%d", i))
+		if err := os.WriteFile(paths[i], bytes.Repeat([]byte{byte('a' + i)}, size/count), 0o600); err != nil {
+			b.Fatal(err)
+		}
+	}
+	modes := []struct {
+		name  string
+		serve func(w http.ResponseWriter, r *http.Request, m *MultiReader)
+	}{
+		{"segments", ServeMultiReader},
+		{"window", func(w http.ResponseWriter, _ *http.Request, m *MultiReader) {
+			_, _ = io.Copy(w, struct{ io.Reader }{m})
+		}},
+	}
+	for _, mode := range modes {
+		b.Run(mode.name, func(b *testing.B) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				readers := make([]SizedReadSeekCloser, count)
+				for i, path := range paths {
+					src, err := OpenFileSource(path)
+					if err != nil {
+						http.Error(w, err.Error(), http.StatusInternalServerError)
+						return
+					}
+					readers[i] = src
+				}
+				m := NewMultiReader(4, readers...)
+				defer m.Close()
+				mode.serve(w, r, m)
+			}))
+			defer srv.Close()
+
+			b.SetBytes(size)
+			b.ReportAllocs()
+			for range b.N {
+				resp, err := http.Get(srv.URL)
+				if err != nil {
+					b.Fatal(err)
+				}
+				n, err := io.Copy(io.Discard, resp.Body)
+				_ = resp.Body.Close()
+				if err != nil || n != size {
+					b.Fatalf("read %d bytes: %v", n, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"iter"
+	"math"
+	"time"
+)
+
+// Block - блок данных потока с абсолютным смещением его первого байта.
+type Block struct {
+	Offset int64
+	Data   []byte
+}
+
+// Blocks возвращает итератор по блокам потока с текущей позиции курсора, сдвигая курсор за каждым блоком.
+// Блоки префетчера отдаются как есть, без копирования в окно, - для поблочной обработки (хеширование, загрузка частей).
+// Data нельзя изменять и использовать после Close; с Hooks.OnRead блоки копируются, так как хук вправе менять данные.
+// Отмена ctx проверяется между блоками; ошибка потока отдаётся последним элементом, EOF завершает итерацию без ошибки.
+func (m *MultiReader) Blocks(ctx context.Context) iter.Seq2[Block, error] {
+	return func(yield func(Block, error) bool) {
+		for {
+			if err := ctx.Err(); err != nil {
+				yield(Block{}, err)
+				return
+			}
+			b, err := m.nextBlock()
+			if err == io.EOF {
+				return
+			}
+			if !yield(b, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// nextBlock отдаёт остаток окна, а если оно пусто - очередной блок префетчера целиком, минуя окно.
+func (m *MultiReader) nextBlock() (b Block, err error) {
+	startPos, err := m.beginRead()
+	if err != nil {
+		return Block{}, err
+	}
+	defer m.inflight.Done()
+	defer func() {
+		if m.hooks != nil && m.hooks.OnRead != nil && len(b.Data) > 0 {
+			b.Data = bytes.Clone(b.Data) // OnRead может изменить данные, а блок - память кэша или отображения файла
+		}
+		if derr := m.delivered(startPos, b.Data, err); derr != nil && err == nil {
+			err = derr
+		}
+	}()
+
+	if data := m.nextFromWindow(math.MaxInt); data != nil {
+		return Block{Offset: startPos, Data: data}, nil
+	}
+
+	if m.noPrefetch { // Префетчера нет - блок читается из источников в окно, как в Read
+		err := m.fillWindowDirect()
+		if data := m.nextFromWindow(math.MaxInt); data != nil {
+			if err == io.EOF { // Конец потока вернёт следующий вызов
+				err = nil
+			}
+			return Block{Offset: startPos, Data: data}, err
+		}
+		return Block{}, err
+	}
+
+	buf, okPf, err := m.waitBlock(time.Now())
+	if err != nil {
+		return Block{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !okPf {
+		return Block{}, m.prefetchResultLocked()
+	}
+	if m.closed {
+		return Block{}, io.ErrClosedPipe
+	}
+	m.windowStart += int64(len(buf))
+	m.absPos += int64(len(buf))
+	m.pfWarm = true
+
+	return Block{Offset: startPos, Data: buf}, nil
+}
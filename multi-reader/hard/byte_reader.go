@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"time"
+	"unicode/utf8"
+)
+
+// Проверка, что MultiReader удовлетворяет интерфейсам io.ByteScanner и io.RuneScanner
+var (
+	_ io.ByteScanner = (*MultiReader)(nil)
+	_ io.RuneScanner = (*MultiReader)(nil)
+)
+
+// ReadByte читает один байт. Если окно не пусто, обходится одной блокировкой - подходит для binary.ReadUvarint и сканеров.
+func (m *MultiReader) ReadByte() (byte, error) {
+	data, err := m.readUnit(false, func(window []byte, _ bool) int { return min(1, len(window)) })
+	if err != nil {
+		return 0, err
+	}
+	return data[0], nil
+}
+
+// ReadRune читает одну руну UTF-8. Некорректная последовательность возвращается как utf8.RuneError размера 1.
+func (m *MultiReader) ReadRune() (rune, int, error) {
+	data, err := m.readUnit(true, func(window []byte, atEOF bool) int {
+		if len(window) == 0 || (!utf8.FullRune(window) && !atEOF) {
+			return 0 // Руна разрезана границей блока - нужен следующий блок
+		}
+		_, size := utf8.DecodeRune(window)
+		return size
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	r, size := utf8.DecodeRune(data)
+	return r, size, nil
+}
+
+// UnreadByte возвращает в поток последний байт, прочитанный ReadByte или ReadRune.
+func (m *MultiReader) UnreadByte() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.unread) == 0 || m.absPos != m.unreadPos {
+		return bufio.ErrInvalidUnreadByte
+	}
+	return m.unreadLocked(1)
+}
+
+// UnreadRune возвращает в поток руну, прочитанную последним вызовом ReadRune.
+func (m *MultiReader) UnreadRune() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.unread) == 0 || m.absPos != m.unreadPos || !m.unreadRune {
+		return bufio.ErrInvalidUnreadRune
+	}
+	return m.unreadLocked(len(m.unread))
+}
+
+// readUnit отрезает от окна очередную единицу (байт или руну), размер которой по началу окна определяет unit
+// (0 - в окне пока недостаточно данных). Быстрый путь - одна блокировка, медленный - ожидание блоков префетчера.
+func (m *MultiReader) readUnit(isRune bool, unit func(window []byte, atEOF bool) int) ([]byte, error) {
+	m.mu.Lock()
+	if !m.closed && !m.draining {
+		if k := unit(m.windowBuf, false); k > 0 {
+			data, pos := m.takeUnitLocked(k, isRune)
+			m.inflight.Add(1) // Shutdown дожидается и хуков быстрого пути
+			m.mu.Unlock()
+			err := m.delivered(pos, data, nil)
+			m.inflight.Done()
+			return data, err
+		}
+	}
+	m.mu.Unlock()
+
+	start := time.Now()
+	if _, err := m.beginRead(); err != nil {
+		return nil, err
+	}
+	defer m.inflight.Done()
+
+	for {
+		err := m.fillWindow(start)
+		atEOF := err == io.EOF
+		if err != nil && !atEOF {
+			return nil, err
+		}
+
+		m.mu.Lock()
+		if k := unit(m.windowBuf, atEOF); k > 0 {
+			data, pos := m.takeUnitLocked(k, isRune)
+			m.mu.Unlock()
+			return data, m.delivered(pos, data, nil)
+		}
+		m.mu.Unlock()
+		if atEOF {
+			return nil, io.EOF
+		}
+	}
+}
+
+// takeUnitLocked отрезает k байт от начала окна и запоминает их для Unread*. Требует удержания m.mu.
+func (m *MultiReader) takeUnitLocked(k int, isRune bool) ([]byte, int64) {
+	pos := m.absPos
+	m.unread = m.windowBuf[:k] // Ёмкость не ограничиваем: по ней Unread* проверяет, что окно продолжает эти байты
+	m.unreadRune = isRune
+	m.windowBuf = m.windowBuf[k:]
+	m.windowStart += int64(k)
+	m.absPos += int64(k)
+	m.unreadPos = m.absPos
+
+	return m.unread[:k:k], pos
+}
+
+// unreadLocked возвращает в окно последние n байт единицы, прочитанной ReadByte/ReadRune. Если окно по-прежнему
+// продолжает эти байты в памяти (или пусто при работающем префетчере), это сдвиг среза, иначе - Seek назад.
+func (m *MultiReader) unreadLocked(n int) error {
+	u := m.unread[len(m.unread)-n:]
+	m.unread = nil
+
+	switch {
+	case len(m.windowBuf) == 0 && m.pfStarted:
+		m.windowBuf = u
+	case len(m.windowBuf) > 0 && cap(u) > n && &u[:n+1][n] == &m.windowBuf[0]:
+		m.windowBuf = u[:n+len(m.windowBuf)]
+	default:
+		_, err := m.seekLocked(-int64(n), io.SeekCurrent)
+		return err
+	}
+	m.windowStart -= int64(n)
+	m.absPos -= int64(n)
+
+	return nil
+}
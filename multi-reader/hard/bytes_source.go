@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+)
+
+// bytesSource - источник поверх среза байт в памяти.
+type bytesSource struct {
+	bytes.Reader
+}
+
+// stringSource - источник поверх строки.
+type stringSource struct {
+	strings.Reader
+}
+
+// Проверка, что bytesSource и stringSource удовлетворяют интерфейсу SizedReadSeekCloser
+var (
+	_ SizedReadSeekCloser = (*bytesSource)(nil)
+	_ SizedReadSeekCloser = (*stringSource)(nil)
+)
+
+// NewBytesReader возвращает источник поверх b без копирования. Close ничего не делает.
+func NewBytesReader(b []byte) SizedReadSeekCloser {
+	s := &bytesSource{}
+	s.Reset(b)
+	return s
+}
+
+// NewStringReader возвращает источник поверх строки s. Close ничего не делает.
+func NewStringReader(s string) SizedReadSeekCloser {
+	src := &stringSource{}
+	src.Reset(s)
+	return src
+}
+
+func (*bytesSource) Close() error {
+	return nil
+}
+
+func (*stringSource) Close() error {
+	return nil
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"container/list"
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// blockCache - LRU-кэш выровненных блоков потока размера bufferSize, ключ - номер блока (смещение / bufferSize).
+// Общий для префетчера, ReadAt и участков Split, поэтому повторное чтение (например, заголовка после полного
+// прохода) не обращается к источникам.
+type blockCache struct {
+	mu      sync.Mutex
+	budget  int64                   // лимит суммарного размера блоков в байтах
+	used    int64                   // текущий суммарный размер блоков
+	lru     *list.List              // блоки от самого свежего к самому старому
+	items   map[int64]*list.Element // номер блока -> элемент lru
+	hits    int64                   // попадания
+	misses  int64                   // промахи
+	evicted int64                   // блоков вытеснено из памяти сверх бюджета
+	disk    *diskTier               // второй уровень на диске для вытесненных блоков (nil - нет)
+	loading map[int64]*blockLoad    // блоки, читаемые из источников прямо сейчас
+	notify  bool                    // флаг - копить события вытеснения для Hooks.OnEvict и Hooks.OnDrop
+	pending []cacheEvent            // события вытеснения, ещё не переданные хукам
+	ttl     time.Duration           // время жизни блока с момента чтения из источников (0 - без ограничения)
+	expired int64                   // блоков выброшено по истечении ttl
+	now     func() time.Time        // текущее время (подменяется в тестах)
+}
+
+// EvictEvent описывает блок кэша блоков, вытесненный из-за нехватки бюджета.
+type EvictEvent struct {
+	Offset  int64 // абсолютное смещение блока в потоке
+	Size    int   // размер блока
+	Spilled bool  // блок сохранён на дисковый уровень (WithDiskCache) и остаётся в кэше
+}
+
+// cacheEvent - событие вытеснения для хуков: drop - блок покинул кэш совсем.
+type cacheEvent struct {
+	EvictEvent
+	drop bool
+}
+
+// blockLoad - чтение блока из источников, результат которого ждут все запросившие его одновременно.
+type blockLoad struct {
+	done chan struct{} // закрывается по завершении чтения
+	data []byte
+	err  error
+}
+
+// cacheEntry - блок в кэше.
+type cacheEntry struct {
+	idx    int64
+	data   []byte
+	loaded time.Time // когда блок прочитан из источников
+}
+
+func newBlockCache(budget int64) *blockCache {
+	return &blockCache{
+		budget:  budget,
+		lru:     list.New(),
+		items:   make(map[int64]*list.Element),
+		loading: make(map[int64]*blockLoad),
+		now:     time.Now,
+	}
+}
+
+// get возвращает блок idx и отмечает его как недавно использованный.
+func (c *blockCache) get(idx int64) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[idx]; ok && !c.expireLocked(el) {
+		c.hits++
+		c.lru.MoveToFront(el)
+		return el.Value.(*cacheEntry).data, true
+	}
+	if data, loaded, ok := c.disk.get(idx); ok { // Блок был вытеснен на диск - поднимаем его обратно в память
+		if c.expiredLocked(loaded) {
+			c.expired++
+			c.misses++
+			return nil, false
+		}
+		c.hits++
+		c.putLocked(idx, data, loaded)
+		return data, true
+	}
+	c.misses++
+	return nil, false
+}
+
+// put кладёт блок idx, вытесняя самые старые блоки сверх бюджета. Блок больше бюджета не кэшируется.
+func (c *blockCache) put(idx int64, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.putLocked(idx, data, c.now())
+}
+
+// putLocked - put под c.mu; loaded - когда блок прочитан из источников. Вытесняемые из памяти блоки уходят
+// на дисковый уровень, если он есть.
+func (c *blockCache) putLocked(idx int64, data []byte, loaded time.Time) {
+	if int64(len(data)) > c.budget {
+		c.spillLocked(idx, data, loaded, false)
+		return
+	}
+	if el, ok := c.items[idx]; ok {
+		entry := el.Value.(*cacheEntry)
+		c.used -= int64(len(entry.data))
+		entry.data, entry.loaded = data, loaded
+		c.used += int64(len(data))
+		c.lru.MoveToFront(el)
+	} else {
+		c.items[idx] = c.lru.PushFront(&cacheEntry{idx: idx, data: data, loaded: loaded})
+		c.used += int64(len(data))
+	}
+
+	for c.used > c.budget {
+		oldest := c.lru.Back()
+		entry := oldest.Value.(*cacheEntry)
+		c.lru.Remove(oldest)
+		delete(c.items, entry.idx)
+		c.used -= int64(len(entry.data))
+		c.evicted++
+		c.spillLocked(entry.idx, entry.data, entry.loaded, true)
+	}
+}
+
+// spillLocked отправляет блок, не поместившийся в память, на дисковый уровень и копит события для хуков.
+// evicted - блок был в памяти кэша и вытеснен из неё. Требует удержания c.mu
+func (c *blockCache) spillLocked(idx int64, data []byte, loaded time.Time, evicted bool) {
+	stored, old := c.disk.put(idx, data, loaded)
+	if !c.notify {
+		return
+	}
+	ev := EvictEvent{Offset: idx * bufferSize, Size: len(data), Spilled: stored}
+	if evicted {
+		c.pending = append(c.pending, cacheEvent{EvictEvent: ev})
+	}
+	if !stored {
+		c.pending = append(c.pending, cacheEvent{EvictEvent: ev, drop: true})
+	}
+	if old != nil { // Ради места на диске вытеснен самый старый блок дискового уровня
+		c.pending = append(c.pending, cacheEvent{EvictEvent: EvictEvent{Offset: old.idx * bufferSize, Size: old.size}, drop: true})
+	}
+}
+
+// drop выбрасывает блок idx из памяти и с диска: его содержимое устарело.
+func (c *blockCache) drop(idx int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[idx]; ok {
+		c.lru.Remove(el)
+		delete(c.items, idx)
+		c.used -= int64(len(el.Value.(*cacheEntry).data))
+	}
+	c.disk.drop(idx)
+}
+
+// close освобождает дисковый уровень. Безопасен для nil.
+func (c *blockCache) close() error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.disk.close()
+}
+
+// cachedBlock возвращает блок idx из кэша, а при промахе читает его из источников и кладёт в кэш.
+// Одновременные промахи по одному блоку читают его из источников один раз.
+// Блоки не изменяются после помещения в кэш - потребители обязаны только читать их.
+func (m *MultiReader) cachedBlock(idx int64) ([]byte, error) {
+	if m.cache.notify {
+		defer m.reportEvictions()
+	}
+	if data, ok := m.cache.get(idx); ok {
+		if int64(len(data)) >= min(bufferSize, m.size.Load()-idx*bufferSize) {
+			return data, nil
+		}
+		m.cache.drop(idx) // Неполный последний блок, прочитанный до роста потока в Refresh
+	}
+
+	c := m.cache
+	c.mu.Lock()
+	if el, ok := c.items[idx]; ok && !c.expireLocked(el) { // Блок положили, пока мы шли сюда после промаха
+		c.mu.Unlock()
+		return el.Value.(*cacheEntry).data, nil
+	}
+	if load, ok := c.loading[idx]; ok { // Блок уже читает другой потребитель - ждём его результата
+		c.mu.Unlock()
+		<-load.done
+		return load.data, load.err
+	}
+	load := &blockLoad{done: make(chan struct{})}
+	c.loading[idx] = load
+	c.mu.Unlock()
+
+	off := idx * bufferSize
+	data := make([]byte, min(bufferSize, m.size.Load()-off))
+	m.srcMu.Lock()
+	_, err := m.readAtSourcesLocked(data, off)
+	m.srcMu.Unlock()
+	if err == nil {
+		load.data = data
+	}
+	load.err = err
+
+	c.mu.Lock()
+	if err == nil {
+		c.putLocked(idx, data, c.now())
+	}
+	delete(c.loading, idx)
+	c.mu.Unlock()
+	close(load.done)
+
+	return load.data, load.err
+}
+
+// reportEvictions передаёт накопленные кэшем события вытеснения в Hooks.OnEvict и Hooks.OnDrop вне блокировок.
+func (m *MultiReader) reportEvictions() {
+	c := m.cache
+	c.mu.Lock()
+	events := c.pending
+	c.pending = nil
+	c.mu.Unlock()
+
+	for _, ev := range events {
+		switch {
+		case !ev.drop && m.hooks.OnEvict != nil:
+			m.hooks.OnEvict(ev.EvictEvent)
+		case ev.drop && m.hooks.OnDrop != nil:
+			m.hooks.OnDrop(ev.EvictEvent)
+		}
+	}
+}
+
+// readAtCached - ReadAt через кэш блоков.
+func (m *MultiReader) readAtCached(p []byte, off int64) (n int, err error) {
+	for n < len(p) && off < m.size.Load() {
+		block, err := m.cachedBlock(off / bufferSize)
+		if err != nil {
+			return n, err
+		}
+		k := copy(p[n:], block[off%bufferSize:])
+		n += k
+		off += int64(k)
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+
+	return n, nil
+}
+
+// prefetchCached публикует в out остаток блока кэша, содержащего позицию pos, и возвращает число опубликованных байт.
+func (m *MultiReader) prefetchCached(ctx context.Context, out chan<- []byte, pos int64) (int64, error) {
+	block, err := m.cachedBlock(pos / bufferSize)
+	if err != nil {
+		return 0, err
+	}
+	start := pos - pos%bufferSize
+	data := block[pos-start : m.pinLimit(pos, start+int64(len(block)))-start] // Закреплённые области отдаются из памяти
+	if err := m.publishBlock(ctx, out, data); err != nil {
+		return 0, err
+	}
+
+	return int64(len(data)), nil
+}
+
+// edgeBlocks возвращает номера блоков, покрывающих первые и последние n байт потока.
+func (m *MultiReader) edgeBlocks(n int64) []int64 {
+	size := m.size.Load()
+	n = min(max(n, 0), size)
+	if n == 0 {
+		return nil
+	}
+	head := (n + bufferSize - 1) / bufferSize
+	var blocks []int64
+	for idx := range head {
+		blocks = append(blocks, idx)
+	}
+	for idx := max((size-n)/bufferSize, head); idx*bufferSize < size; idx++ {
+		blocks = append(blocks, idx)
+	}
+	return blocks
+}
+
+// warmEdges читает в кэш блоки edgeBlocks. Ошибки не сохраняются: непрочитанный блок будет запрошен
+// у источников при обычном чтении, и ошибка вернётся оттуда.
+func (m *MultiReader) warmEdges(blocks []int64) {
+	for _, idx := range blocks {
+		_, _ = m.cachedBlock(idx)
+	}
+}
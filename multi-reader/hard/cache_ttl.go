@@ -0,0 +1,68 @@
+package main
+
+import (
+	"container/list"
+	"time"
+)
+
+// WithCacheTTL ограничивает время жизни блоков кэша блоков (WithBlockCache, WithDiskCache): блок, прочитанный
+// из источников больше ttl назад, считается промахом и перечитывается, а фоновая очистка раз в ttl/2 выбрасывает
+// истёкшие блоки из памяти и с диска, не дожидаясь обращения к ним. Нужна, когда содержимое источников может
+// законно смениться (например, presigned-чанки через сутки), - устаревшие попадания ограничены по времени.
+// Число выброшенных блоков - Stats.CacheExpired. Очистка останавливается в Close. Без кэша блоков опция
+// ни на что не влияет.
+func WithCacheTTL(ttl time.Duration) Option {
+	return func(m *MultiReader) {
+		m.cacheTTL = ttl
+	}
+}
+
+// startCacheJanitor включает ttl у кэша и запускает фоновую очистку истёкших блоков до Close.
+func (m *MultiReader) startCacheJanitor() {
+	if m.cache == nil || m.cacheTTL <= 0 {
+		return
+	}
+	m.cache.ttl = m.cacheTTL
+	ticker := time.NewTicker(max(m.cacheTTL/2, time.Millisecond))
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-m.closeCh:
+				return
+			case <-ticker.C:
+				m.cache.expire()
+			}
+		}
+	}()
+}
+
+// expiredLocked сообщает, истекло ли время жизни блока, прочитанного из источников в момент loaded. Требует удержания c.mu
+func (c *blockCache) expiredLocked(loaded time.Time) bool {
+	return c.ttl > 0 && c.now().Sub(loaded) >= c.ttl
+}
+
+// expireLocked выбрасывает блок el из памяти, если его время жизни истекло, и сообщает об этом. Требует удержания c.mu
+func (c *blockCache) expireLocked(el *list.Element) bool {
+	entry := el.Value.(*cacheEntry)
+	if !c.expiredLocked(entry.loaded) {
+		return false
+	}
+	c.lru.Remove(el)
+	delete(c.items, entry.idx)
+	c.used -= int64(len(entry.data))
+	c.expired++
+	return true
+}
+
+// expire выбрасывает из памяти и с диска все блоки с истёкшим временем жизни.
+func (c *blockCache) expire() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for el := c.lru.Back(); el != nil; {
+		prev := el.Prev()
+		c.expireLocked(el)
+		el = prev
+	}
+	c.expired += c.disk.expire(c.now().Add(-c.ttl))
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"testing"
+	"time"
+)
+
+var parallelCases = flag.Bool("cases.parallel", false, "запускать тест-кейсы параллельно")
+
+// TestCases запускает публичные и приватные тест-кейсы как подтесты: их можно фильтровать через -run,
+// запускать с -race и параллельно (-cases.parallel), а провал одного кейса не останавливает остальные.
+// Кейс, не уложившийся в свой таймаут, проваливается со стеками всех горутин.
+func TestCases(t *testing.T) {
+	for _, tc := range append(testCases, privateTestCases...) {
+		t.Run(tc.name, func(t *testing.T) {
+			if *parallelCases {
+				t.Parallel()
+			}
+			done := make(chan bool, 1)
+			go func() { done <- tc.run() }()
+			select {
+			case ok := <-done:
+				if !ok {
+					t.Fatal("провал")
+				}
+			case <-time.After(tc.caseTimeout()):
+				dumpGoroutines(os.Stderr)
+				t.Fatalf("таймаут (%s)", tc.caseTimeout())
+			}
+		})
+	}
+}
+
+// FuzzDifferential сверяет MultiReader с bytes.Reader на операциях, закодированных фаззером:
+// `go test -fuzz FuzzDifferential`. Находки сохраняются в testdata/fuzz и затем проверяются обычным go test.
+func FuzzDifferential(f *testing.F) {
+	f.Add([]byte("hello, multi reader"), []byte{3, 5, 0, 0, 0, 0, 8, 1, 0, 0, 3, 2, 0, 2, 0, 0, 0, 4})
+	f.Add([]byte{}, []byte{0, 1, 2, 255, 255})
+	f.Fuzz(func(t *testing.T, data, script []byte) {
+		parts, ops, err := decodeDiffCase(data, script)
+		if err != nil {
+			t.Skip()
+		}
+		if err := checkDifferential(1+int(script[0]%3), parts, ops); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// ChunkServer отдаёт диапазоны собранного потока последовательностью фрагментов - ядро стримингового сервиса
+// (например, server-streaming метода gRPC: send - это stream.Send). Чтение идёт через ReadAt, поэтому запросы
+// обслуживаются параллельно; с Multiplexer пересекающиеся запросы читают источники один раз.
+type ChunkServer struct {
+	src       SizedReaderAt
+	chunkSize int // размер фрагмента
+}
+
+// NewChunkServer создаёт сервер фрагментов над src. chunkSize <= 0 - bufferSize.
+func NewChunkServer(src SizedReaderAt, chunkSize int) *ChunkServer {
+	if chunkSize <= 0 {
+		chunkSize = bufferSize
+	}
+	return &ChunkServer{src: src, chunkSize: chunkSize}
+}
+
+// ServeRange передаёт в send диапазон [offset, offset+length) фрагментами не больше chunkSize. length < 0 -
+// до конца потока. Срез, переданный в send, переиспользуется: send должен скопировать или отправить его до возврата.
+// Отмена ctx и ошибка send прерывают передачу между фрагментами.
+func (s *ChunkServer) ServeRange(ctx context.Context, offset, length int64, send func(chunk []byte) error) error {
+	size := s.src.Size()
+	if length < 0 {
+		length = size - offset
+	}
+	if offset < 0 || length < 0 || offset+length > size {
+		return fmt.Errorf("range [%d, %d) should be within size (%d)", offset, offset+length, size)
+	}
+
+	buf := make([]byte, min(int64(s.chunkSize), length))
+	for end := offset + length; offset < end; {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		chunk := buf[:min(int64(len(buf)), end-offset)]
+		n, err := s.src.ReadAt(chunk, offset)
+		if n < len(chunk) {
+			if err == nil || err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return err
+		}
+		if err := send(chunk); err != nil {
+			return err
+		}
+		offset += int64(n)
+	}
+
+	return nil
+}
+
+// Size возвращает размер потока (для ответа на запрос метаданных).
+func (s *ChunkServer) Size() int64 {
+	return s.src.Size()
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrCloseTimeout возвращается из Close, если префетчер не остановился за период WithCloseGracePeriod.
+var ErrCloseTimeout = errors.New("prefetcher did not stop within close grace period")
+
+// ContextReader - источник, чтение которого можно прервать отменой контекста (например, сетевой запрос).
+// Префетчер передаёт в ReadContext свой контекст, который отменяется при Close и Seek за пределы окна.
+type ContextReader interface {
+	ReadContext(ctx context.Context, p []byte) (int, error)
+}
+
+// readContext читает из r через ReadContext, если r его реализует, иначе обычным Read.
+// Обёртки источников передают через него контекст префетчера вложенному источнику.
+func readContext(ctx context.Context, r io.Reader, p []byte) (int, error) {
+	if cr, ok := r.(ContextReader); ok {
+		return cr.ReadContext(ctx, p)
+	}
+	return r.Read(p)
+}
+
+// readCancelable - readContext для потока, который можно закрыть: Read, не знающий о контексте (тело HTTP-ответа,
+// сетевое соединение), прерывается закрытием потока при отмене ctx. Если чтение прервано, возвращает ctx.Err(),
+// а rc уже закрыт - вызывающий должен его выбросить.
+func readCancelable(ctx context.Context, rc io.ReadCloser, p []byte) (int, error) {
+	if _, ok := rc.(ContextReader); ok || ctx.Done() == nil {
+		return readContext(ctx, rc, p)
+	}
+	stop := context.AfterFunc(ctx, func() { _ = rc.Close() })
+	n, err := rc.Read(p)
+	if !stop() { // Поток закрыт по отмене - его ошибка чтения случайна
+		return n, ctx.Err()
+	}
+	return n, err
+}
+
+// CloseOrder задаёт порядок закрытия источников в Close.
+type CloseOrder int
+
+const (
+	CloseForward CloseOrder = iota // в порядке конкатенации (по умолчанию)
+	CloseReverse                   // в обратном порядке - сначала последний источник
+)
+
+// closeSourcesAndUnlock закрывает источники (кроме досрочно закрытых) не более чем closeWorkers одновременно
+// и отпускает m.srcMu, захваченный вызывающим. Ошибки объединяются в порядке источников. Если ctx истёк раньше,
+// оставшиеся закрытия доводятся в фоне (m.background), и m.srcMu отпускается только после них: источник
+// не закрывается одновременно с обращением к нему. Тогда возвращается ctx.Err() со списком незакрытых источников.
+func (m *MultiReader) closeSourcesAndUnlock(ctx context.Context) error {
+	workers := max(m.closeWorkers, 1)
+	errs := make([]error, len(m.readers))
+	closed := make([]atomic.Bool, len(m.readers)) // closed[i] - Close i-го источника вернулся, errs[i] заполнена
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		sem := make(chan struct{}, workers)
+		var wg sync.WaitGroup
+		for _, i := range m.closeOrderIndexes() {
+			r := m.readers[i]
+			if m.srcClosed != nil && m.srcClosed[i] { // Уже закрыт досрочно
+				closed[i].Store(true)
+				continue
+			}
+			sem <- struct{}{}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-sem }()
+				errs[i] = guardSource(i, r.Close) // Паника в Close источника не должна уронить процесс из фоновой горутины
+				closed[i].Store(true)
+				if m.onSourceClose != nil {
+					m.onSourceClose(i, errs[i])
+				}
+			}()
+		}
+		wg.Wait()
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+	select {
+	case <-done: // Успели закрыть всё, даже если ctx истёк одновременно
+		m.srcMu.Unlock()
+		return errors.Join(errs...)
+	default:
+	}
+
+	var open []int
+	var closeErrs []error
+	for i := range closed {
+		if closed[i].Load() {
+			closeErrs = append(closeErrs, errs[i])
+		} else {
+			open = append(open, i)
+		}
+	}
+	m.background.Add(1)
+	go func() {
+		defer m.background.Done()
+		<-done
+		m.srcMu.Unlock()
+	}()
+
+	return errors.Join(append(closeErrs, fmt.Errorf("sources %v still closing: %w", open, ctx.Err()))...)
+}
+
+// closeOrderIndexes возвращает индексы источников в порядке закрытия.
+func (m *MultiReader) closeOrderIndexes() []int {
+	order := make([]int, len(m.readers))
+	for i := range order {
+		if m.closeOrder == CloseReverse {
+			order[i] = len(order) - 1 - i
+		} else {
+			order[i] = i
+		}
+	}
+	return order
+}
+
+// closeAfterPrefetch дожидается выхода брошенного префетчера и закрывает источники в фоне, чтобы они не утекли.
+func (m *MultiReader) closeAfterPrefetch(pfDone <-chan struct{}) {
+	defer m.background.Done()
+	<-pfDone
+	_ = m.cache.close()
+	if m.borrowedSources {
+		return
+	}
+
+	m.srcMu.Lock()
+	_ = m.closeSourcesAndUnlock(context.Background())
+}
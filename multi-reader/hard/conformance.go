@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+)
+
+// ConformanceT - часть testing.TB, нужная набору проверок; *testing.T удовлетворяет ей.
+type ConformanceT interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// RunSourceConformance проверяет, что сторонний источник соблюдает контракт SizedReadSeekCloser, на который
+// опирается MultiReader: стабильный Size, содержимое, семантику Seek (в пределах [0, Size]), контракт EOF
+// и идемпотентность Close. newSource вызывается для каждой проверки и должен возвращать свежий источник
+// с содержимым want. Нарушения сообщаются через t.Errorf.
+func RunSourceConformance(t ConformanceT, newSource func() SizedReadSeekCloser, want []byte) {
+	t.Helper()
+	size := int64(len(want))
+
+	check := func(name string, fn func(src SizedReadSeekCloser) error) {
+		t.Helper()
+		src := newSource()
+		defer src.Close()
+		if err := fn(src); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+
+	check("size", func(src SizedReadSeekCloser) error {
+		if src.Size() != size {
+			return errors.New("Size() differs from content length")
+		}
+		if _, err := io.CopyN(io.Discard, src, size/2); err != nil {
+			return err
+		}
+		if src.Size() != size {
+			return errors.New("Size() changed after reading")
+		}
+		return nil
+	})
+
+	check("content", func(src SizedReadSeekCloser) error {
+		got, err := io.ReadAll(io.LimitReader(oneByteReader{src}, size+1))
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(got, want) {
+			return errors.New("content read with 1-byte buffers differs")
+		}
+		return nil
+	})
+
+	check("eof", func(src SizedReadSeekCloser) error {
+		if n, err := src.Read(nil); n != 0 || err != nil && (size > 0 || !errors.Is(err, io.EOF)) {
+			return errors.New("empty Read should return 0, nil")
+		}
+		if _, err := io.ReadFull(src, make([]byte, size)); err != nil {
+			return err
+		}
+		for range 2 { // Допускается (0, nil) перед EOF, но EOF обязан повторяться
+			if n, err := src.Read(make([]byte, 8)); n != 0 || err != nil && !errors.Is(err, io.EOF) {
+				return errors.New("Read at end should return 0 bytes and io.EOF")
+			}
+		}
+		if n, err := src.Read(make([]byte, 8)); n != 0 || !errors.Is(err, io.EOF) {
+			return errors.New("repeated Read at end should return io.EOF")
+		}
+		return nil
+	})
+
+	check("seek", func(src SizedReadSeekCloser) error {
+		steps := []struct {
+			offset int64
+			whence int
+			want   int64
+		}{
+			{size / 2, io.SeekStart, size / 2},
+			{-size / 4, io.SeekCurrent, size - size/4}, // Предыдущий шаг дочитал до конца
+			{-size / 3, io.SeekEnd, size - size/3},
+			{0, io.SeekEnd, size},
+			{0, io.SeekStart, 0},
+		}
+		for _, s := range steps {
+			pos, err := src.Seek(s.offset, s.whence)
+			if err != nil {
+				return err
+			}
+			if pos != s.want {
+				return errors.New("Seek returned wrong position")
+			}
+			rest, err := io.ReadAll(src)
+			if err != nil || !bytes.Equal(rest, want[pos:]) {
+				return errors.New("data after Seek differs")
+			}
+		}
+		if _, err := src.Seek(-1, io.SeekStart); err == nil {
+			return errors.New("Seek to a negative position should fail")
+		}
+		if _, err := src.Seek(0, 42); err == nil {
+			return errors.New("Seek with invalid whence should fail")
+		}
+		return nil
+	})
+
+	check("close", func(src SizedReadSeekCloser) error {
+		if err := src.Close(); err != nil {
+			return err
+		}
+		if err := src.Close(); err != nil {
+			return errors.New("second Close should return nil")
+		}
+		return nil
+	})
+}
+
+// oneByteReader читает по одному байту, проверяя обработку коротких буферов.
+type oneByteReader struct {
+	r io.Reader
+}
+
+func (o oneByteReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	return o.r.Read(p[:1])
+}
@@ -0,0 +1,36 @@
+package main
+
+import "context"
+
+// ConsistencyTokenSetter - источник, умеющий передавать токен согласованности (snapshot ID) в каждом запросе к бэкенду
+// (HTTP-заголовок, gRPC metadata и т.п.), чтобы все сегменты одного логического чтения пришли из одного снапшота.
+type ConsistencyTokenSetter interface {
+	SetConsistencyToken(token string)
+}
+
+// consistencyTokenKey - ключ токена согласованности в context.Context.
+type consistencyTokenKey struct{}
+
+// ContextWithConsistencyToken возвращает контекст, несущий токен согласованности.
+func ContextWithConsistencyToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, consistencyTokenKey{}, token)
+}
+
+// ConsistencyTokenFromContext извлекает токен согласованности из контекста (фабрики ленивых источников получают его так).
+func ConsistencyTokenFromContext(ctx context.Context) (string, bool) {
+	token, ok := ctx.Value(consistencyTokenKey{}).(string)
+	return token, ok
+}
+
+// ConsistencyToken возвращает токен согласованности, заданный через WithConsistencyToken.
+func (m *MultiReader) ConsistencyToken() string {
+	return m.consistencyToken
+}
+
+// SetConsistencyToken запоминает токен: фабрика получит его в контексте, открытый источник - через ConsistencyTokenSetter.
+func (l *lazySource) SetConsistencyToken(token string) {
+	l.ctx = ContextWithConsistencyToken(l.ctx, token)
+	if setter, ok := l.src.(ConsistencyTokenSetter); ok {
+		setter.SetConsistencyToken(token)
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"os"
+	"time"
+)
+
+// SetReadDeadline задаёт крайний срок для чтений, как net.Conn: Read, ждущий блок от префетчера дольше t,
+// возвращает уже скопированные данные и os.ErrDeadlineExceeded. Срок действует и на ReadByte, Peek, NextBlock
+// и уже заблокированные вызовы; нулевое t снимает ограничение. После продления срока чтение можно продолжить:
+// префетчер не останавливается. Синхронные чтения без префетча (WithPrefetch(false)) сроком не прерываются.
+func (m *MultiReader) SetReadDeadline(t time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return os.ErrClosed
+	}
+	m.readDeadline = t
+	if m.deadlineCh != nil {
+		close(m.deadlineCh) // Будим ожидающие чтения - пусть пересчитают срок
+	}
+	m.deadlineCh = make(chan struct{})
+
+	return nil
+}
+
+// readDeadlineLocked возвращает срок ожидания для чтения, начатого в start: ближайший из SetReadDeadline
+// и start+WithReadTimeout (нулевое время - без срока). Требует m.mu.
+func (m *MultiReader) readDeadlineLocked(start time.Time) time.Time {
+	deadline := m.readDeadline
+	if m.readTimeout > 0 {
+		if byTimeout := start.Add(m.readTimeout); deadline.IsZero() || byTimeout.Before(deadline) {
+			deadline = byTimeout
+		}
+	}
+	return deadline
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+)
+
+// diffOp - операция дифференциальной проверки.
+type diffOp struct {
+	kind   byte  // 'r' - Read, 's' - Seek, 'a' - ReadAt
+	n      int   // длина буфера для Read и ReadAt
+	offset int64 // смещение для Seek и ReadAt
+	whence int   // whence для Seek
+}
+
+func (op diffOp) String() string {
+	switch op.kind {
+	case 'r':
+		return fmt.Sprintf("Read(%d)", op.n)
+	case 's':
+		return fmt.Sprintf("Seek(%d, %d)", op.offset, op.whence)
+	default:
+		return fmt.Sprintf("ReadAt(%d, %d)", op.n, op.offset)
+	}
+}
+
+// checkDifferential выполняет ops над мультиридером из parts и над bytes.Reader по их склейке и сверяет
+// прочитанные байты, классы ошибок и позицию после каждой операции. bytes.Reader допускает Seek за конец,
+// а MultiReader - нет: там мультиридер обязан вернуть ошибку и не сдвинуть курсор.
+func checkDifferential(buffersNum int, parts [][]byte, ops []diffOp, opts ...Option) error {
+	readers := make([]SizedReadSeekCloser, len(parts))
+	for i, p := range parts {
+		readers[i] = NewBytesReader(p)
+	}
+	m := NewMultiReaderWithOptions(buffersNum, readers, opts...)
+	defer m.Close()
+	ref := bytes.NewReader(bytes.Join(parts, nil))
+	size := ref.Size()
+
+	for i, op := range ops {
+		fail := func(format string, args ...any) error {
+			return fmt.Errorf("op %d %v: %s", i, op, fmt.Sprintf(format, args...))
+		}
+
+		switch op.kind {
+		case 'r':
+			got, want := make([]byte, op.n), make([]byte, op.n)
+			n, err := m.Read(got)
+			refN, refErr := ref.Read(want)
+			if op.n == 0 && errors.Is(refErr, io.EOF) { // bytes.Reader на конце возвращает EOF и для пустого буфера
+				refErr = nil
+			}
+			if n != refN || readClass(n, err) != readClass(refN, refErr) || !bytes.Equal(got[:n], want[:refN]) {
+				return fail("got %d, %v; want %d, %v", n, err, refN, refErr)
+			}
+		case 's':
+			prev, _ := ref.Seek(0, io.SeekCurrent)
+			pos, err := m.Seek(op.offset, op.whence)
+			refPos, refErr := ref.Seek(op.offset, op.whence)
+			if refErr == nil && refPos > size {
+				if err == nil {
+					return fail("seek past end %d accepted", refPos)
+				}
+				_, _ = ref.Seek(prev, io.SeekStart)
+				refErr = err
+			}
+			if errorClass(err) != errorClass(refErr) || (err == nil && pos != refPos) {
+				return fail("got %d, %v; want %d, %v", pos, err, refPos, refErr)
+			}
+		default:
+			got, want := make([]byte, op.n), make([]byte, op.n)
+			n, err := m.ReadAt(got, op.offset)
+			refN, refErr := ref.ReadAt(want, op.offset)
+			if op.n == 0 && errors.Is(refErr, io.EOF) {
+				refErr = nil
+			}
+			if n != refN || errorClass(err) != errorClass(refErr) || !bytes.Equal(got[:n], want[:refN]) {
+				return fail("got %d, %v; want %d, %v", n, err, refN, refErr)
+			}
+		}
+
+		pos, err := m.Seek(0, io.SeekCurrent)
+		refPos, _ := ref.Seek(0, io.SeekCurrent)
+		if err != nil || pos != refPos {
+			return fail("position %d, %v; want %d", pos, err, refPos)
+		}
+	}
+
+	return nil
+}
+
+// randomDiffCase генерирует по rnd случайные части (в том числе пустые) общим размером до maxSize и n операций.
+// Смещения покрывают границы частей, отрицательные значения и позиции за концом склейки.
+func randomDiffCase(rnd *rand.Rand, maxSize, n int) ([][]byte, []diffOp) {
+	parts := make([][]byte, 1+rnd.Intn(6))
+	var size int
+	for i := range parts {
+		if rnd.Intn(3) == 0 {
+			parts[i] = []byte{}
+			continue
+		}
+		parts[i] = make([]byte, rnd.Intn(maxSize/len(parts)+1))
+		rnd.Read(parts[i])
+		size += len(parts[i])
+	}
+
+	offset := func() int64 { return int64(rnd.Intn(2*size+3) - size - 1) }
+	ops := make([]diffOp, n)
+	for i := range ops {
+		switch rnd.Intn(3) {
+		case 0:
+			ops[i] = diffOp{kind: 'r', n: rnd.Intn(size + 2)}
+		case 1:
+			ops[i] = diffOp{kind: 's', offset: offset(), whence: rnd.Intn(4)}
+		default:
+			ops[i] = diffOp{kind: 'a', n: rnd.Intn(size + 2), offset: max(offset(), -1)}
+		}
+	}
+	return parts, ops
+}
+
+// decodeDiffCase строит части и операции из произвольных байт фаззера: data режется на части по
+// первым байтам script, остальные байты script кодируют операции по 4 байта.
+func decodeDiffCase(data, script []byte) ([][]byte, []diffOp, error) {
+	if len(script) == 0 {
+		return nil, nil, errors.New("empty script")
+	}
+	partsNum := int(script[0]%6) + 1
+	script = script[1:]
+	parts := make([][]byte, 0, partsNum)
+	for i := 0; i < partsNum-1 && len(script) > 0; i++ {
+		cut := min(int(script[0]), len(data))
+		parts = append(parts, data[:cut])
+		data, script = data[cut:], script[1:]
+	}
+	parts = append(parts, data)
+
+	var ops []diffOp
+	for ; len(script) >= 4; script = script[4:] {
+		arg := int64(int16(uint16(script[2])<<8 | uint16(script[3])))
+		switch script[0] % 3 {
+		case 0:
+			ops = append(ops, diffOp{kind: 'r', n: int(uint16(arg))})
+		case 1:
+			ops = append(ops, diffOp{kind: 's', offset: arg, whence: int(script[1] % 4)})
+		default:
+			ops = append(ops, diffOp{kind: 'a', n: int(script[1]), offset: max(arg, -1)})
+		}
+	}
+	return parts, ops, nil
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"errors"
+	"hash"
+	"sync"
+)
+
+// streamDigest - хеш байт, отданных потребителю через Read.
+type streamDigest struct {
+	mu sync.Mutex
+	h  hash.Hash
+	n  int64 // сколько байт учтено
+}
+
+func (d *streamDigest) write(data []byte) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.h.Write(data)
+	d.n += int64(len(data))
+}
+
+// Digest возвращает хеш всех байт, отданных потребителю через Read, и их количество.
+// Хеш покрывает именно отданные байты: при Seek пропущенные данные не учитываются, а перечитанные учитываются повторно,
+// поэтому с контрольной суммой объекта он совпадает только при последовательном чтении от начала до EOF.
+func (m *MultiReader) Digest() ([]byte, int64, error) {
+	if m.digest == nil {
+		return nil, 0, errors.New("digest is not enabled")
+	}
+
+	m.digest.mu.Lock()
+	defer m.digest.mu.Unlock()
+	return m.digest.h.Sum(nil), m.digest.n, nil
+}
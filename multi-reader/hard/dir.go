@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// NewMultiReaderFromDir склеивает обычные файлы каталога dir в порядке имён. Файлы открываются лениво.
+func NewMultiReaderFromDir(buffersNum int, dir string) (*MultiReader, error) {
+	entries, err := os.ReadDir(dir) // Уже отсортированы по имени
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if e.Type().IsRegular() {
+			paths = append(paths, filepath.Join(dir, e.Name()))
+		}
+	}
+
+	return newMultiReaderFromPaths(buffersNum, paths)
+}
+
+// NewMultiReaderFromGlob склеивает файлы, подходящие под шаблон pattern, в лексикографическом порядке.
+// Сборка файла из частей - одна строка: NewMultiReaderFromGlob(0, "file.part*").
+func NewMultiReaderFromGlob(buffersNum int, pattern string) (*MultiReader, error) {
+	paths, err := filepath.Glob(pattern) // Уже отсортированы
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no files match %q", pattern)
+	}
+
+	return newMultiReaderFromPaths(buffersNum, paths)
+}
+
+// NewMultiReaderFromFS склеивает файлы names из fsys в заданном порядке. Файлы открываются лениво.
+func NewMultiReaderFromFS(buffersNum int, fsys fs.FS, names ...string) (*MultiReader, error) {
+	specs := make([]LazySpec, len(names))
+	for i, name := range names {
+		info, err := fs.Stat(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		specs[i] = LazySpec{
+			Size: info.Size(),
+			Open: func(context.Context) (SizedReadSeekCloser, error) { return NewFSReader(fsys, name) },
+			Name: name,
+		}
+	}
+
+	return NewMultiReaderLazy(context.Background(), buffersNum, specs...), nil
+}
+
+// newMultiReaderFromPaths создаёт ленивый мультиридер по путям файлов; размеры берутся из Stat заранее.
+func newMultiReaderFromPaths(buffersNum int, paths []string) (*MultiReader, error) {
+	specs := make([]LazySpec, len(paths))
+	for i, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.Mode().IsRegular() {
+			return nil, fmt.Errorf("%s is not a regular file", path)
+		}
+		specs[i] = LazySpec{
+			Size: info.Size(),
+			Open: func(context.Context) (SizedReadSeekCloser, error) { return OpenFileSource(path) },
+			Name: path,
+		}
+	}
+
+	return NewMultiReaderLazy(context.Background(), buffersNum, specs...), nil
+}
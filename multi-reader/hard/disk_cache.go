@@ -0,0 +1,181 @@
+package main
+
+import (
+	"container/list"
+	"errors"
+	"os"
+	"time"
+)
+
+// diskTier - второй уровень кэша блоков во временном файле. Файл разбит на слоты по bufferSize и создаётся
+// при первом вытеснении. Ошибки ввода-вывода не прерывают чтение: блок просто не сохраняется, и при следующем
+// обращении он будет снова прочитан из источников. Методы вызываются под blockCache.mu и безопасны для nil.
+type diskTier struct {
+	dir    string                  // каталог временного файла ("" - системный)
+	budget int64                   // лимит размера файла в байтах
+	f      *os.File                // файл слотов (nil - ещё не создан)
+	lru    *list.List              // блоки от самого свежего к самому старому
+	items  map[int64]*list.Element // номер блока -> элемент lru
+	free   []int64                 // смещения освободившихся слотов
+	next   int64                   // смещение следующего нового слота
+	failed bool                    // флаг - файл создать не удалось, уровень выключен
+	closed bool                    // флаг - уровень закрыт, файл удалён и больше не создаётся
+	packer *windowPacker           // сжатие сбрасываемых блоков (nil - без сжатия)
+	// Счётчики сброса для Stats (под blockCache.mu)
+	spilled int64 // байт блоков, записанных на диск, до сжатия
+	written int64 // байт, фактически записанных на диск
+}
+
+// diskEntry - блок на диске.
+type diskEntry struct {
+	idx    int64
+	slot   int64     // смещение слота в файле
+	size   int       // размер блока
+	packed int       // размер сжатого блока в слоте (0 - блок записан как есть)
+	loaded time.Time // когда блок прочитан из источников
+}
+
+func newDiskTier(dir string, budget int64) *diskTier {
+	return &diskTier{
+		dir:    dir,
+		budget: budget,
+		lru:    list.New(),
+		items:  make(map[int64]*list.Element),
+	}
+}
+
+// put сохраняет блок, прочитанный из источников в момент loaded, в слот, вытесняя самый старый блок, если файл
+// достиг бюджета. Возвращает, сохранён ли блок, и вытесненный ради него блок (nil - вытеснять не пришлось).
+func (d *diskTier) put(idx int64, data []byte, loaded time.Time) (bool, *diskEntry) {
+	if d == nil || d.failed || d.closed || len(data) > bufferSize {
+		return false, nil
+	}
+	if d.f == nil {
+		f, err := os.CreateTemp(d.dir, "multireader-spill-*")
+		if err != nil {
+			d.failed = true
+			return false, nil
+		}
+		d.f = f
+	}
+
+	if el, ok := d.items[idx]; ok {
+		d.lru.MoveToFront(el)
+		return true, nil // Блоки неизменяемы - перезаписывать нечего
+	}
+
+	var slot int64
+	var old *diskEntry
+	switch {
+	case len(d.free) > 0:
+		slot, d.free = d.free[len(d.free)-1], d.free[:len(d.free)-1]
+	case d.next+bufferSize <= d.budget:
+		slot, d.next = d.next, d.next+bufferSize
+	case d.lru.Len() > 0:
+		oldest := d.lru.Back()
+		old = oldest.Value.(*diskEntry)
+		d.lru.Remove(oldest)
+		delete(d.items, old.idx)
+		slot = old.slot
+	default: // Бюджет меньше одного слота
+		return false, nil
+	}
+
+	entry := &diskEntry{idx: idx, slot: slot, size: len(data), loaded: loaded}
+	raw := data
+	if d.packer != nil {
+		if packed := d.packer.pack(data); packed[0] == packedFlate { // Несжимаемый блок пишется как есть
+			raw, entry.packed = packed, len(packed)
+		}
+	}
+	if _, err := d.f.WriteAt(raw, slot); err != nil {
+		d.free = append(d.free, slot)
+		return false, old
+	}
+	d.spilled += int64(len(data))
+	d.written += int64(len(raw))
+	d.items[idx] = d.lru.PushFront(entry)
+	return true, old
+}
+
+// get читает блок с диска и освобождает его слот: блок возвращается в память. Возвращает и момент,
+// когда блок был прочитан из источников.
+func (d *diskTier) get(idx int64) ([]byte, time.Time, bool) {
+	if d == nil || d.closed {
+		return nil, time.Time{}, false
+	}
+	el, ok := d.items[idx]
+	if !ok {
+		return nil, time.Time{}, false
+	}
+	entry := el.Value.(*diskEntry)
+	d.lru.Remove(el)
+	delete(d.items, idx)
+	d.free = append(d.free, entry.slot)
+
+	if entry.packed > 0 {
+		packed := make([]byte, entry.packed)
+		if _, err := d.f.ReadAt(packed, entry.slot); err != nil {
+			return nil, time.Time{}, false
+		}
+		data, err := d.packer.unpack(packed)
+		if err != nil || len(data) != entry.size {
+			return nil, time.Time{}, false
+		}
+		return data, entry.loaded, true
+	}
+	data := make([]byte, entry.size)
+	if _, err := d.f.ReadAt(data, entry.slot); err != nil {
+		return nil, time.Time{}, false
+	}
+	return data, entry.loaded, true
+}
+
+// drop освобождает слот блока idx, не читая его.
+func (d *diskTier) drop(idx int64) {
+	if d == nil {
+		return
+	}
+	if el, ok := d.items[idx]; ok {
+		d.lru.Remove(el)
+		delete(d.items, idx)
+		d.free = append(d.free, el.Value.(*diskEntry).slot)
+	}
+}
+
+// expire освобождает слоты блоков, прочитанных из источников раньше before, и возвращает их число.
+func (d *diskTier) expire(before time.Time) int64 {
+	if d == nil || d.closed {
+		return 0
+	}
+	var n int64
+	for el := d.lru.Back(); el != nil; {
+		prev := el.Prev()
+		if entry := el.Value.(*diskEntry); entry.loaded.Before(before) {
+			d.lru.Remove(el)
+			delete(d.items, entry.idx)
+			d.free = append(d.free, entry.slot)
+			n++
+		}
+		el = prev
+	}
+	return n
+}
+
+// close закрывает и удаляет временный файл и забывает сохранённые блоки. После него уровень не принимает
+// блоки: префетчер или ReadAt, вытесняющие блок после Close, не создадут новый файл.
+func (d *diskTier) close() error {
+	if d == nil || d.closed {
+		return nil
+	}
+	d.closed = true
+	d.lru.Init()
+	d.items, d.free = nil, nil
+	if d.f == nil {
+		return nil
+	}
+	name := d.f.Name()
+	err := errors.Join(d.f.Close(), os.Remove(name))
+	d.f = nil
+	return err
+}
@@ -0,0 +1,366 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+const defaultPartSize = 8 * bufferSize // размер части по умолчанию
+
+// RangeOpener открывает диапазон [off, off+length) удалённого объекта - например, GET с заголовком Range к S3.
+type RangeOpener func(ctx context.Context, off, length int64) (io.ReadCloser, error)
+
+// Downloader скачивает объект по частям: параллельно, с повторами на уровне части и отчётом о прогрессе.
+type Downloader struct {
+	Open        RangeOpener                        // открытие диапазона объекта
+	Size        int64                              // размер объекта
+	PartSize    int64                              // размер части (0 - defaultPartSize)
+	Concurrency int                                // сколько частей качается одновременно (0 - 4)
+	Retries     int                                // сколько раз повторять часть после сбоя
+	OnProgress  func(done, total int64)            // вызывается после каждой порции данных
+	OnRetry     func(part, attempt int, err error) // вызывается перед повтором части
+	done        atomic.Int64                       // скачано байт
+	progressMu  sync.Mutex                         // сериализует вызовы OnProgress
+}
+
+// NewReader возвращает мультиридер поверх частей объекта: каждая часть - ленивый ranged-источник с повторами.
+// Позволяет читать объект как один поток с Seek и ReadAt без предварительного скачивания.
+// С WithReadConcurrency запросы внутри части идут конвейером.
+func (d *Downloader) NewReader(ctx context.Context, buffersNum int, opts ...Option) *MultiReader {
+	parts := d.parts()
+	readers := make([]SizedReadSeekCloser, len(parts))
+	for i, p := range parts {
+		readers[i] = d.partSource(ctx, i, p)
+	}
+
+	return NewMultiReaderWithOptions(buffersNum, readers, opts...)
+}
+
+// DownloadTo скачивает объект в w, записывая части параллельно по их смещениям. Сбой части отменяет остальные
+// и возвращается сам; ctx.Err() возвращается, только если скачивание прервал ctx вызывающего.
+func (d *Downloader) DownloadTo(parent context.Context, w io.WriterAt) error {
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+	d.done.Store(0)
+
+	parts := d.parts()
+	errs := make([]error, len(parts))
+	sem := make(chan struct{}, d.concurrency())
+	var wg sync.WaitGroup
+	dispatched := 0
+dispatch:
+	for i, p := range parts {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done(): // Часть упала или ctx вызывающего отменён
+			break dispatch
+		}
+		dispatched++
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			src := d.partSource(ctx, i, p)
+			defer src.Close()
+			if _, err := io.Copy(io.NewOffsetWriter(w, p.Off), src); err != nil {
+				errs[i] = fmt.Errorf("part %d: %w", i, err)
+				cancel() // Остальные части уже не нужны
+			}
+		}()
+	}
+	wg.Wait()
+
+	err := errors.Join(errs...)
+	if err != nil || dispatched < len(parts) {
+		if perr := parent.Err(); perr != nil { // Ошибки частей - следствие отмены извне
+			return perr
+		}
+	}
+	return err
+}
+
+// Download скачивает объект в последовательный w: части качаются параллельно в память
+// (не более Concurrency одновременно), а пишутся строго по порядку.
+func (d *Downloader) Download(ctx context.Context, w io.Writer) (int64, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait() // Части, которые ещё качаются, не должны пережить Download
+	}()
+	d.done.Store(0)
+
+	parts := d.parts()
+	type result struct {
+		data []byte
+		err  error
+	}
+	results := make([]chan result, len(parts))
+	for i := range results {
+		results[i] = make(chan result, 1)
+	}
+
+	sem := make(chan struct{}, d.concurrency())
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i, p := range parts {
+			select {
+			case sem <- struct{}{}: // Слот освобождает писатель, когда часть записана
+			case <-ctx.Done():
+				return
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				src := d.partSource(ctx, i, p)
+				defer src.Close()
+				var buf bytes.Buffer
+				buf.Grow(int(p.Len))
+				_, err := io.Copy(&buf, src)
+				results[i] <- result{buf.Bytes(), err}
+			}()
+		}
+	}()
+
+	var written int64
+	for i := range parts {
+		var res result
+		select {
+		case res = <-results[i]:
+		case <-ctx.Done():
+			return written, ctx.Err()
+		}
+		if res.err != nil {
+			return written, fmt.Errorf("part %d: %w", i, res.err)
+		}
+		k, err := w.Write(res.data)
+		written += int64(k)
+		if err != nil {
+			return written, err
+		}
+		<-sem
+	}
+
+	return written, nil
+}
+
+// parts разбивает объект на части размера PartSize.
+func (d *Downloader) parts() []Range {
+	partSize := d.PartSize
+	if partSize <= 0 {
+		partSize = defaultPartSize
+	}
+	parts := make([]Range, 0, d.Size/partSize+1)
+	for off := int64(0); off < d.Size; off += partSize {
+		parts = append(parts, Range{Off: off, Len: min(partSize, d.Size-off)})
+	}
+	return parts
+}
+
+func (d *Downloader) concurrency() int {
+	if d.Concurrency <= 0 {
+		return 4
+	}
+	return d.Concurrency
+}
+
+// progress учитывает n скачанных байт и сообщает прогресс.
+func (d *Downloader) progress(n int) {
+	done := d.done.Add(int64(n))
+	if d.OnProgress != nil {
+		d.progressMu.Lock()
+		d.OnProgress(done, d.Size)
+		d.progressMu.Unlock()
+	}
+}
+
+// partSource создаёт ranged-источник части.
+func (d *Downloader) partSource(ctx context.Context, idx int, part Range) *rangeSource {
+	return &rangeSource{ctx: ctx, d: d, idx: idx, part: part}
+}
+
+// rangeSource - часть объекта как SizedReadSeekCloser. Диапазон открывается лениво с текущей позиции,
+// а при сбое чтения переоткрывается с того же места (не более Retries раз подряд).
+type rangeSource struct {
+	ctx     context.Context
+	d       *Downloader
+	idx     int           // номер части
+	part    Range         // диапазон части в объекте
+	body    io.ReadCloser // открытый ответ (nil - не открыт)
+	pos     int64         // позиция внутри части
+	retries int           // повторы подряд без прогресса
+	closed  bool
+}
+
+// Проверка, что rangeSource удовлетворяет интерфейсам SizedReadSeekCloser, io.ReaderAt и ContextReader
+var (
+	_ SizedReadSeekCloser = (*rangeSource)(nil)
+	_ io.ReaderAt         = (*rangeSource)(nil)
+	_ ContextReader       = (*rangeSource)(nil)
+)
+
+func (s *rangeSource) Read(p []byte) (int, error) {
+	return s.ReadContext(s.ctx, p)
+}
+
+// ReadContext - Read, прерываемый отменой ctx (префетчер отменяет его при Close и Seek за окно):
+// зависшее чтение ответа обрывается закрытием тела, диапазон переоткроется при следующем чтении.
+func (s *rangeSource) ReadContext(ctx context.Context, p []byte) (int, error) {
+	if s.closed {
+		return 0, io.ErrClosedPipe
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if s.pos >= s.part.Len {
+		return 0, io.EOF
+	}
+	p = p[:min(int64(len(p)), s.part.Len-s.pos)]
+
+	for {
+		err := s.ensureOpen()
+		var n int
+		if err == nil {
+			n, err = readCancelable(ctx, s.body, p)
+			s.pos += int64(n)
+			if n > 0 {
+				s.retries = 0
+				s.d.progress(n)
+			}
+			if err != nil && ctx.Err() != nil { // Тело закрыто отменой
+				s.body = nil
+				return n, err
+			}
+		}
+		if errors.Is(err, io.EOF) && s.pos >= s.part.Len {
+			return n, nil // EOF вернём следующим вызовом
+		}
+		if err == nil || n > 0 {
+			if err != nil { // Данные отдаём сейчас, диапазон переоткроем при следующем чтении
+				s.reset()
+			}
+			return n, nil
+		}
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF // Ответ короче заявленного диапазона
+		}
+
+		s.reset()
+		if s.retries >= s.d.Retries || s.ctx.Err() != nil || ctx.Err() != nil {
+			return 0, err
+		}
+		s.retries++
+		if s.d.OnRetry != nil {
+			s.d.OnRetry(s.idx, s.retries, err)
+		}
+	}
+}
+
+// ReadAt открывает диапазон [off, off+len(p)) части отдельным запросом, не трогая открытый ответ Read.
+// Вызовы ReadAt можно выполнять параллельно - так WithReadConcurrency конвейеризует запросы внутри части.
+func (s *rangeSource) ReadAt(p []byte, off int64) (int, error) {
+	if s.closed {
+		return 0, io.ErrClosedPipe
+	}
+	if off < 0 {
+		return 0, fmt.Errorf("invalid offset: %d", off)
+	}
+	if off >= s.part.Len {
+		return 0, io.EOF
+	}
+	want := min(int64(len(p)), s.part.Len-off)
+
+	var err error
+	for attempt := 0; ; attempt++ {
+		var body io.ReadCloser
+		if body, err = s.d.Open(s.ctx, s.part.Off+off, want); err == nil {
+			var n int
+			n, err = io.ReadFull(body, p[:want])
+			_ = body.Close()
+			if err == nil {
+				s.d.progress(n)
+				break
+			}
+		}
+		if attempt >= s.d.Retries || s.ctx.Err() != nil {
+			return 0, err
+		}
+		if s.d.OnRetry != nil {
+			s.d.OnRetry(s.idx, attempt+1, err)
+		}
+	}
+	if want < int64(len(p)) {
+		return int(want), io.EOF
+	}
+
+	return int(want), nil
+}
+
+func (s *rangeSource) Seek(offset int64, whence int) (int64, error) {
+	if s.closed {
+		return 0, io.ErrClosedPipe
+	}
+
+	var base int64
+	switch whence {
+	case io.SeekStart:
+		base = 0
+	case io.SeekCurrent:
+		base = s.pos
+	case io.SeekEnd:
+		base = s.part.Len
+	default:
+		return 0, fmt.Errorf("invalid whence: %d", whence)
+	}
+
+	seekPos := base + offset
+	if seekPos < 0 || seekPos > s.part.Len {
+		return 0, fmt.Errorf("seek position (%d) should be >= 0 and <= size (%d)", seekPos, s.part.Len)
+	}
+	if seekPos != s.pos { // Открытый ответ отдаёт данные с другой позиции
+		s.reset()
+	}
+	s.pos = seekPos
+
+	return seekPos, nil
+}
+
+func (s *rangeSource) Close() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	s.reset()
+	return nil
+}
+
+func (s *rangeSource) Size() int64 {
+	return s.part.Len
+}
+
+// ensureOpen открывает диапазон с текущей позиции.
+func (s *rangeSource) ensureOpen() error {
+	if s.body != nil {
+		return nil
+	}
+	body, err := s.d.Open(s.ctx, s.part.Off+s.pos, s.part.Len-s.pos)
+	if err != nil {
+		return err
+	}
+	s.body = body
+	return nil
+}
+
+// reset закрывает открытый ответ.
+func (s *rangeSource) reset() {
+	if s.body != nil {
+		_ = s.body.Close()
+		s.body = nil
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Dump пишет в w внутреннее состояние мультиридера в стабильном текстовом формате для отчётов об ошибках:
+// позицию курсора, диапазон окна, очередь префетчера, его статус и таблицу границ источников.
+// Не берёт мьютекс источников, поэтому работает и тогда, когда префетчер или ReadAt зависли в чтении источника.
+func (m *MultiReader) Dump(w io.Writer) error {
+	m.mu.Lock()
+	absPos, size, closed := m.absPos, m.size.Load(), m.closed
+	windowStart, windowLen := m.windowStart, int64(len(m.windowBuf))
+	prefetch := m.prefetchStatusLocked()
+	queued := len(m.pfBufCh)
+	prefixSizes := append([]int64(nil), m.prefixes()...)
+	m.mu.Unlock()
+	st := m.Stats()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "MultiReader size=%d pos=%d closed=%t\n", size, absPos, closed)
+	fmt.Fprintf(&b, "window: [%d, %d) len=%d\n", windowStart, windowStart+windowLen, windowLen)
+	fmt.Fprintf(&b, "prefetch: %s queued_blocks=%d buffered_bytes=%d buffers=%d\n", prefetch, queued, st.BytesBuffered, m.buffersNum)
+	fmt.Fprintf(&b, "stalls: producer=%d consumer=%d restarts=%d\n", st.ProducerStalls, st.ConsumerStalls, st.PrefetchRestarts)
+	fmt.Fprintf(&b, "sources: %d\n", len(m.readers))
+
+	tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "\t#\tstart\tend\tsize\tread\tname")
+	for i, r := range m.readers {
+		cursor := "" // Источник, в котором стоит курсор
+		if prefixSizes[i] <= absPos && absPos < prefixSizes[i+1] {
+			cursor = ">"
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%d\t%s\n",
+			cursor, i, prefixSizes[i], prefixSizes[i+1], prefixSizes[i+1]-prefixSizes[i], st.Sources[i].BytesRead, sourceName(r))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// prefetchStatusLocked описывает состояние префетчера одним словом и, если он завершился, его итоговой ошибкой. Требует m.mu.
+func (m *MultiReader) prefetchStatusLocked() string {
+	switch {
+	case m.noPrefetch:
+		return "disabled"
+	case m.pfErr != nil:
+		return fmt.Sprintf("finished (%v)", m.pfErr)
+	case m.pfStarted:
+		select {
+		case <-m.pfDone:
+			return "finished"
+		default:
+			return "running"
+		}
+	default:
+		return "idle"
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrSourceClosed - sentinel для errors.Is: источник закрыт досрочно и не может быть открыт заново.
+var ErrSourceClosed = errors.New("source is closed")
+
+// Reopener - источник, который можно открыть заново после Close (например, файл по пути).
+type Reopener interface {
+	Reopen() error
+}
+
+// closeSourceLocked досрочно закрывает i-й источник. Ошибка закрытия откладывается до Close. Требует удержания m.srcMu
+func (m *MultiReader) closeSourceLocked(i int) {
+	if m.srcClosed == nil {
+		m.srcClosed = make([]bool, len(m.readers))
+	}
+	if m.srcClosed[i] {
+		return
+	}
+	m.srcClosed[i] = true
+	err := guardSource(i, m.readers[i].Close)
+	if m.onSourceClose != nil {
+		m.onSourceClose(i, err)
+	}
+	if err != nil {
+		m.eagerCloseErr = errors.Join(m.eagerCloseErr, fmt.Errorf("source %d: %w", i, err))
+	}
+}
+
+// ensureSourceOpenLocked переоткрывает досрочно закрытый i-й источник, если он это поддерживает. Требует удержания m.srcMu
+func (m *MultiReader) ensureSourceOpenLocked(i int) error {
+	if m.srcClosed == nil || !m.srcClosed[i] {
+		return nil
+	}
+	reopener, ok := m.readers[i].(Reopener)
+	if !ok {
+		return fmt.Errorf("source %d: %w", i, ErrSourceClosed)
+	}
+	if err := reopener.Reopen(); err != nil {
+		return fmt.Errorf("source %d: reopen: %w", i, err)
+	}
+	m.srcClosed[i] = false
+
+	return nil
+}
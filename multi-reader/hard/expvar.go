@@ -0,0 +1,28 @@
+package main
+
+import (
+	"expvar"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	openReaders   atomic.Int64 // открытые (не закрытые) мультиридеры процесса
+	bytesStreamed atomic.Int64 // байт, отданных всеми мультиридерами через Read и ReadAt
+	registerOnce  sync.Once
+)
+
+// Register публикует в expvar переменную "multireader" с агрегатными счётчиками всех мультиридеров процесса:
+// open_readers, live_prefetchers и bytes_streamed. После этого они видны на /debug/vars без дополнительного кода.
+// Повторные вызовы ничего не делают.
+func Register() {
+	registerOnce.Do(func() {
+		expvar.Publish("multireader", expvar.Func(func() any {
+			return map[string]int64{
+				"open_readers":     openReaders.Load(),
+				"live_prefetchers": livePrefetchers.Load(),
+				"bytes_streamed":   bytesStreamed.Load(),
+			}
+		}))
+	})
+}
@@ -0,0 +1,45 @@
+package main
+
+// Подсказки posix_fadvise (значения Linux).
+const (
+	fadvSequential = 2 // POSIX_FADV_SEQUENTIAL: чтение будет последовательным, можно увеличить readahead
+	fadvWillNeed   = 3 // POSIX_FADV_WILLNEED: диапазон скоро понадобится, начать подгрузку
+	fadvDontNeed   = 4 // POSIX_FADV_DONTNEED: диапазон больше не нужен, можно вытеснить из page cache
+)
+
+// adviseSequential сообщает ядру, что файловый источник src будет читаться последовательно.
+func (m *MultiReader) adviseSequential(src SizedReadSeekCloser) {
+	if !m.fadvise {
+		return
+	}
+	if fp, ok := src.(osFileProvider); ok {
+		_ = fadvise(fp.OSFile(), 0, 0, fadvSequential) // Подсказки необязательны - ошибки игнорируем
+	}
+}
+
+// adviseWillNeed просит ядро подгрузить окно префетча файлового источника src начиная с локального смещения off.
+func (m *MultiReader) adviseWillNeed(src SizedReadSeekCloser, off int64) {
+	if !m.fadvise {
+		return
+	}
+	if fp, ok := src.(osFileProvider); ok {
+		_ = fadvise(fp.OSFile(), off, int64(m.buffersNum)*bufferSize, fadvWillNeed)
+	}
+}
+
+// adviseConsumed разрешает ядру вытеснить из page cache участки файловых источников, уже отданные потребителю: [pos, pos+n).
+func (m *MultiReader) adviseConsumed(pos, n int64) {
+	if !m.fadvise || n <= 0 {
+		return
+	}
+	prefixSizes := m.prefixes()
+	for i := m.readerIndex(pos); i < len(m.readers) && prefixSizes[i] < pos+n; i++ {
+		fp, ok := m.readers[i].(osFileProvider)
+		if !ok {
+			continue
+		}
+		from := max(pos, prefixSizes[i]) - prefixSizes[i]
+		to := min(pos+n, prefixSizes[i+1]) - prefixSizes[i]
+		_ = fadvise(fp.OSFile(), from, to-from, fadvDontNeed)
+	}
+}
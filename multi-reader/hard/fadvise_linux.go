@@ -0,0 +1,17 @@
+//go:build linux && (amd64 || arm64)
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// fadvise вызывает posix_fadvise для диапазона [off, off+length) файла f (length = 0 - до конца файла).
+func fadvise(f *os.File, off, length int64, advice int) error {
+	_, _, errno := syscall.Syscall6(syscall.SYS_FADVISE64, f.Fd(), uintptr(off), uintptr(length), uintptr(advice), 0, 0)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,10 @@
+//go:build !(linux && (amd64 || arm64))
+
+package main
+
+import "os"
+
+// fadvise на остальных платформах ничего не делает: подсказки необязательны.
+func fadvise(*os.File, int64, int64, int) error {
+	return nil
+}
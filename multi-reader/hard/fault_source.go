@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Faults описывает сбои, которые FaultSource внедряет в работу источника. Нулевое значение - сбоев нет.
+type Faults struct {
+	ReadErr      error // ошибка Read (nil - Read не сбоит)
+	ReadErrAfter int64 // сколько байт отдать до ReadErr
+	SeekErr      error // ошибка Seek (nil - Seek не сбоит)
+	SeekErrOn    int   // номер вызова Seek (с 1), который вернёт SeekErr; 0 - каждый вызов
+	CloseErr     error // ошибка, возвращаемая Close
+	MaxRead      int   // короткие чтения: не больше MaxRead байт за вызов Read (0 - без ограничения)
+
+	Latency   time.Duration // задержка каждого Read
+	Jitter    time.Duration // случайная добавка к задержке из [0, Jitter)
+	Seed      int64         // зерно генератора jitter - задержки воспроизводимы
+	Bandwidth int64         // ограничение пропускной способности, байт/с (0 - без ограничения)
+}
+
+// FaultSource - источник в памяти с настраиваемыми сбоями для проверки путей обработки ошибок:
+// ошибка после N байт, ошибка на K-м Seek, ошибка Close, короткие чтения, задержки и ограничение пропускной
+// способности. Потокобезопасен; задержка выдерживается без блокировки, так что Close не ждёт медленный Read.
+type FaultSource struct {
+	mu     sync.Mutex
+	r      bytes.Reader
+	size   int64
+	faults Faults
+	read   int64 // отдано байт за всё время
+	seeks  int   // вызовов Seek
+	closes int   // вызовов Close
+	rnd    *rand.Rand
+}
+
+// Проверка, что FaultSource удовлетворяет интерфейсам SizedReadSeekCloser и ContextReader
+var (
+	_ SizedReadSeekCloser = (*FaultSource)(nil)
+	_ ContextReader       = (*FaultSource)(nil)
+)
+
+// NewFaultSource создаёт источник с содержимым data и сбоями faults.
+func NewFaultSource(data []byte, faults Faults) *FaultSource {
+	s := &FaultSource{size: int64(len(data)), faults: faults, rnd: rand.New(rand.NewSource(faults.Seed))}
+	s.r.Reset(data)
+	return s
+}
+
+// Read отдаёт данные до порога ReadErrAfter, после него - ReadErr. Отданное до порога в том же вызове
+// возвращается без ошибки, ошибка - следующим вызовом.
+func (s *FaultSource) Read(p []byte) (int, error) {
+	return s.ReadContext(context.Background(), p)
+}
+
+// ReadContext - Read, ожидание задержки в котором прерывается отменой ctx (префетчер отменяет его при Close и Seek).
+func (s *FaultSource) ReadContext(ctx context.Context, p []byte) (int, error) {
+	if delay := s.delay(len(p)); delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return 0, ctx.Err()
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.faults.MaxRead > 0 && len(p) > s.faults.MaxRead {
+		p = p[:s.faults.MaxRead]
+	}
+	if s.faults.ReadErr != nil {
+		left := s.faults.ReadErrAfter - s.read
+		if left <= 0 {
+			return 0, s.faults.ReadErr
+		}
+		if int64(len(p)) > left {
+			p = p[:left]
+		}
+	}
+	n, err := s.r.Read(p)
+	s.read += int64(n)
+
+	return n, err
+}
+
+// delay возвращает задержку чтения до n байт: Latency, jitter и время передачи при ограничении Bandwidth.
+func (s *FaultSource) delay(n int) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.faults.MaxRead > 0 {
+		n = min(n, s.faults.MaxRead)
+	}
+	d := s.faults.Latency
+	if s.faults.Jitter > 0 {
+		d += time.Duration(s.rnd.Int63n(int64(s.faults.Jitter)))
+	}
+	if s.faults.Bandwidth > 0 {
+		n = min(n, s.r.Len())
+		d += time.Duration(int64(n) * int64(time.Second) / s.faults.Bandwidth)
+	}
+	return d
+}
+
+// Seek перемещает позицию; вызов с номером SeekErrOn возвращает SeekErr.
+func (s *FaultSource) Seek(offset int64, whence int) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.seeks++
+	if s.faults.SeekErr != nil && (s.faults.SeekErrOn == 0 || s.faults.SeekErrOn == s.seeks) {
+		return 0, s.faults.SeekErr
+	}
+	return s.r.Seek(offset, whence)
+}
+
+// Close возвращает CloseErr при каждом вызове.
+func (s *FaultSource) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closes++
+	return s.faults.CloseErr
+}
+
+func (s *FaultSource) Size() int64 {
+	return s.size
+}
+
+// Seeks возвращает число вызовов Seek.
+func (s *FaultSource) Seeks() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.seeks
+}
+
+// Closes возвращает число вызовов Close.
+func (s *FaultSource) Closes() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closes
+}
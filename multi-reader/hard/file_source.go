@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// fileSource - источник поверх локального файла. Размер берётся из Stat при создании.
+type fileSource struct {
+	*os.File
+	size int64
+}
+
+// Проверка, что fileSource удовлетворяет интерфейсу SizedReadSeekCloser
+var _ SizedReadSeekCloser = (*fileSource)(nil)
+
+// NewFileSource оборачивает открытый файл в SizedReadSeekCloser.
+func NewFileSource(f *os.File) (SizedReadSeekCloser, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("stat %s: %w", f.Name(), err)
+	}
+
+	return &fileSource{File: f, size: info.Size()}, nil
+}
+
+// OpenFileSource открывает файл по пути и оборачивает его в SizedReadSeekCloser.
+func OpenFileSource(path string) (SizedReadSeekCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	src, err := NewFileSource(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	return src, nil
+}
+
+// Size возвращает размер файла на момент открытия.
+func (s *fileSource) Size() int64 {
+	return s.size
+}
+
+// OSFile возвращает нижележащий *os.File (нужен для sendfile и позиционного чтения).
+func (s *fileSource) OSFile() *os.File {
+	return s.File
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+)
+
+// ErrWouldBlock - в режиме слежения (WithFollow без интервала опроса) за текущим концом потока данных пока нет.
+// Ошибка временная: Read можно повторить, когда последний источник подрастёт.
+var ErrWouldBlock = errors.New("no data past the current end yet, retry later")
+
+// SizeRefresher - источник, который умеет заново узнать свой размер (например, перечитать stat растущего файла).
+// Refresh опрашивает его вместо Size.
+type SizeRefresher interface {
+	RefreshSize() (int64, error)
+}
+
+// RefreshSize перечитывает размер файла: в файл могут дописывать, пока его читают.
+func (s *fileSource) RefreshSize() (int64, error) {
+	info, err := s.Stat()
+	if err != nil {
+		return 0, fmt.Errorf("stat %s: %w", s.Name(), err)
+	}
+	s.size = info.Size()
+	return s.size, nil
+}
+
+// WithFollow включает режим слежения за растущим последним источником (активно дописываемый сегмент лога).
+// Read на конце потока вызывает Refresh и продолжает чтение, если источник вырос. Если нет - при poll <= 0
+// сразу возвращает ErrWouldBlock, иначе опрашивает размер каждые poll, пока не появятся данные или не будет Close.
+func WithFollow(poll time.Duration) Option {
+	return func(m *MultiReader) {
+		m.follow = true
+		m.followPoll = poll
+	}
+}
+
+// Refresh заново запрашивает размеры источников (SizeRefresher или Size) и возвращает, на сколько вырос поток.
+// Расти может только последний источник; изменение размера любого другого или уменьшение последнего
+// возвращает ошибку с ErrSourceChanged. При росте префетчер останавливается и перезапускается со следующего Read.
+// Refresh сериализуется с Read и Seek; ReadAt и Size, выполняющиеся одновременно с ним, видят размер до или
+// после роста. Представления (Limit, Split и т.п.) видят размер на момент своего создания.
+func (m *MultiReader) Refresh() (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return 0, io.ErrClosedPipe
+	}
+	if len(m.readers) == 0 {
+		return 0, nil
+	}
+
+	last := len(m.readers) - 1
+	m.srcMu.Lock() // Источники читает префетчер - опрашиваем их размер между его чтениями
+	sizes := make([]int64, len(m.readers))
+	var err error
+	for i, r := range m.readers {
+		if sizes[i], err = currentSize(r); err != nil {
+			m.srcMu.Unlock()
+			return 0, fmt.Errorf("source %d: %w", i, err)
+		}
+	}
+	m.srcMu.Unlock()
+
+	prefixSizes := m.prefixes()
+	for i, size := range sizes {
+		old := prefixSizes[i+1] - prefixSizes[i]
+		if size != old && (i != last || size < old) {
+			return 0, fmt.Errorf("source %d: size %d differs from %d: %w", i, size, old, ErrSourceChanged)
+		}
+	}
+	grown := sizes[last] - (prefixSizes[last+1] - prefixSizes[last])
+	if grown == 0 {
+		return 0, nil
+	}
+
+	// Префетчер и кэш видели старый конец потока: останавливаем префетчер и выбрасываем неполный последний блок
+	if m.pfStarted {
+		m.resetPrefetchLocked()
+	}
+	m.windowBuf, m.windowMem = nil, 0
+	m.windowStart = m.absPos
+	if total := m.size.Load(); m.cache != nil && total%bufferSize != 0 {
+		m.cache.drop(total / bufferSize)
+	}
+	grownSizes := slices.Clone(prefixSizes) // Срез могут читать без блокировок - публикуем копию
+	grownSizes[last+1] += grown
+	m.prefix.Store(&grownSizes)
+	m.size.Add(grown)
+
+	return grown, nil
+}
+
+// currentSize возвращает актуальный размер источника.
+func currentSize(r SizedReadSeekCloser) (int64, error) {
+	if sr, ok := r.(SizeRefresher); ok {
+		return sr.RefreshSize()
+	}
+	return r.Size(), nil
+}
+
+// readFollow - Read в режиме слежения: конец потока не окончателен, пока последний источник может вырасти.
+func (m *MultiReader) readFollow(p []byte) (int, error) {
+	for {
+		n, err := m.read(p)
+		switch {
+		case n > 0 && errors.Is(err, io.EOF): // Отдаём данные, а конец потока перепроверим следующим вызовом
+			return n, nil
+		case !errors.Is(err, io.EOF):
+			return n, err
+		}
+
+		grown, err := m.Refresh()
+		if err != nil {
+			return 0, err
+		}
+		if grown > 0 {
+			continue
+		}
+		if m.followPoll <= 0 {
+			return 0, ErrWouldBlock
+		}
+
+		timer := time.NewTimer(m.followPoll)
+		select {
+		case <-timer.C:
+		case <-m.closeCh:
+			timer.Stop()
+			return 0, io.ErrClosedPipe
+		}
+	}
+}
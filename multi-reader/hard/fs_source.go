@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+)
+
+// fsFileSource - файл из fs.FS, поддерживающий Seek (os.DirFS, embed.FS и т.п.).
+type fsFileSource struct {
+	fs.File
+	io.Seeker
+	size int64
+}
+
+// Size возвращает размер файла по Stat при открытии.
+func (s *fsFileSource) Size() int64 {
+	return s.size
+}
+
+// NewFSReader открывает файл name из fsys как SizedReadSeekCloser: размер берётся из Stat.
+// Если файл не поддерживает Seek, перемещение назад переоткрывает его, вперёд - пропускает байты.
+// Так встроенные через embed.FS ресурсы склеиваются с файлами на диске.
+func NewFSReader(fsys fs.FS, name string) (SizedReadSeekCloser, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("stat %s: %w", name, err)
+	}
+	if info.IsDir() {
+		_ = f.Close()
+		return nil, fmt.Errorf("%s is a directory", name)
+	}
+
+	if seeker, ok := f.(io.Seeker); ok {
+		return &fsFileSource{File: f, Seeker: seeker, size: info.Size()}, nil
+	}
+	src := newReopenSource(info.Size(), func() (io.ReadCloser, error) { return fsys.Open(name) })
+	src.rc = f // Уже открытый файл используем для первого прохода
+
+	return src, nil
+}
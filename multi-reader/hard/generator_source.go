@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// GeneratorFunc заполняет p содержимым, начиная с абсолютной позиции off внутри источника.
+// Для одной и той же позиции функция обязана возвращать одни и те же байты (детерминированность).
+type GeneratorFunc func(off int64, p []byte) (int, error)
+
+// generatorSource - источник, синтезирующий содержимое по запросу вместо хранения его в памяти.
+type generatorSource struct {
+	size   int64         // объявленный размер источника
+	gen    GeneratorFunc // функция генерации содержимого
+	pos    int64         // текущая позиция чтения
+	closed bool          // флаг закрытия источника
+}
+
+// Проверка, что generatorSource удовлетворяет интерфейсу SizedReadSeekCloser
+var _ SizedReadSeekCloser = (*generatorSource)(nil)
+
+// GeneratorSource создаёт источник размера size, содержимое которого вычисляется функцией gen.
+// Так как содержимое определяется только позицией, источник поддерживает Seek и повторное чтение.
+func GeneratorSource(size int64, gen GeneratorFunc) SizedReadSeekCloser {
+	return &generatorSource{
+		size: size,
+		gen:  gen,
+	}
+}
+
+// Read генерирует данные с текущей позиции.
+func (g *generatorSource) Read(p []byte) (int, error) {
+	if g.closed {
+		return 0, io.ErrClosedPipe
+	}
+	n, err := g.ReadAt(p, g.pos)
+	g.pos += int64(n)
+	if err == io.EOF && n > 0 { // Отдаём прочитанные байты сейчас, EOF - следующим вызовом
+		err = nil
+	}
+	return n, err
+}
+
+// ReadAt генерирует данные с позиции off, не сдвигая текущую позицию.
+func (g *generatorSource) ReadAt(p []byte, off int64) (int, error) {
+	if g.closed {
+		return 0, io.ErrClosedPipe
+	}
+	if off < 0 {
+		return 0, fmt.Errorf("invalid offset: %d", off)
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if off >= g.size {
+		return 0, io.EOF
+	}
+
+	want := len(p)
+	p = p[:min(int64(len(p)), g.size-off)]
+	n := 0
+	for n < len(p) {
+		k, err := g.gen(off+int64(n), p[n:])
+		n += k
+		if err != nil {
+			return n, err
+		}
+		if k == 0 { // Генератор не продвинулся и не вернул ошибку. Выходим, чтобы не зациклиться
+			return n, io.ErrNoProgress
+		}
+	}
+	if n < want {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// Seek перемещает позицию чтения внутри [0, size].
+func (g *generatorSource) Seek(offset int64, whence int) (int64, error) {
+	if g.closed {
+		return 0, io.ErrClosedPipe
+	}
+
+	var base int64
+	switch whence {
+	case io.SeekStart:
+		base = 0
+	case io.SeekCurrent:
+		base = g.pos
+	case io.SeekEnd:
+		base = g.size
+	default:
+		return 0, fmt.Errorf("invalid whence: %d", whence)
+	}
+
+	seekPos := base + offset
+	if seekPos < 0 || seekPos > g.size {
+		return 0, fmt.Errorf("seek position (%d) should be >= 0 and <= size (%d)", seekPos, g.size)
+	}
+	g.pos = seekPos
+
+	return seekPos, nil
+}
+
+// Close помечает источник закрытым. Повторный вызов возвращает nil.
+func (g *generatorSource) Close() error {
+	g.closed = true
+	return nil
+}
+
+// Size возвращает объявленный размер источника.
+func (g *generatorSource) Size() int64 {
+	return g.size
+}
+
+// ZeroSource создаёт источник размера size, заполненный нулевыми байтами.
+func ZeroSource(size int64) SizedReadSeekCloser {
+	return GeneratorSource(size, func(_ int64, p []byte) (int, error) {
+		clear(p)
+		return len(p), nil
+	})
+}
+
+// SyntheticSource создаёт источник размера size с детерминированным псевдослучайным содержимым, заданным seed.
+// Содержимое не хранится в памяти, а вычисляется по позиции (см. FillSynthetic), поэтому склейки
+// в гигабайты можно проверять без гигабайт ОЗУ.
+func SyntheticSource(size, seed int64) SizedReadSeekCloser {
+	return GeneratorSource(size, func(off int64, p []byte) (int, error) {
+		FillSynthetic(seed, off, p)
+		return len(p), nil
+	})
+}
+
+// FillSynthetic заполняет p содержимым SyntheticSource с зерном seed, начиная с позиции off.
+// Позволяет сверить прочитанное с ожидаемым без хранения эталона.
+func FillSynthetic(seed, off int64, p []byte) {
+	for i := 0; i < len(p); {
+		pos := off + int64(i)
+		word := splitmix64(uint64(seed) ^ uint64(pos/8)*0x9e3779b97f4a7c15)
+		for shift := pos % 8; shift < 8 && i < len(p); shift++ {
+			p[i] = byte(word >> (8 * shift))
+			i++
+		}
+	}
+}
+
+// splitmix64 - финализатор генератора SplitMix64: хорошо перемешивает соседние значения.
+func splitmix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ x>>30) * 0xbf58476d1ce4e5b9
+	x = (x ^ x>>27) * 0x94d049bb133111eb
+	return x ^ x>>31
+}
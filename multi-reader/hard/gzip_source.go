@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// GzipBlock - независимый gzip-член внутри сжатого источника.
+type GzipBlock struct {
+	CompressedOffset int64 `json:"compressed_offset"` // смещение начала члена в сжатом потоке
+	Offset           int64 `json:"offset"`            // смещение его первого байта в распакованном потоке
+}
+
+// GzipIndex - индекс блоков многочленного gzip (как в BGZF): позволяет начинать распаковку с ближайшего блока, а не с начала.
+type GzipIndex struct {
+	Blocks []GzipBlock `json:"blocks"` // блоки в порядке следования
+	Size   int64       `json:"size"`   // размер распакованного потока
+}
+
+// BuildGzipIndex один раз распаковывает поток r целиком и строит индекс его gzip-членов.
+// Чем мельче члены, тем дешевле Seek: распаковка начинается с начала блока, содержащего позицию.
+func BuildGzipIndex(r io.Reader) (GzipIndex, error) {
+	cr := &countingByteReader{r: bufio.NewReader(r)}
+	var idx GzipIndex
+
+	zr, err := gzip.NewReader(cr)
+	if errors.Is(err, io.EOF) { // Пустой поток - пустой индекс
+		return idx, nil
+	}
+	if err != nil {
+		return idx, err
+	}
+	start := int64(0)
+	for {
+		zr.Multistream(false)
+		n, err := io.Copy(io.Discard, zr)
+		if err != nil {
+			return idx, fmt.Errorf("gzip member at %d: %w", start, err)
+		}
+		idx.Blocks = append(idx.Blocks, GzipBlock{CompressedOffset: start, Offset: idx.Size})
+		idx.Size += n
+
+		start = cr.n
+		if err := zr.Reset(cr); errors.Is(err, io.EOF) {
+			return idx, nil
+		} else if err != nil {
+			return idx, fmt.Errorf("gzip member at %d: %w", start, err)
+		}
+	}
+}
+
+// GzipSource отдаёт распакованное содержимое сжатого источника raw с произвольным доступом по индексу idx.
+// Размер источника - распакованный (idx.Size), поэтому сжатые сегменты логов можно конкатенировать мультиридером.
+func GzipSource(raw SizedReadSeekCloser, idx GzipIndex) SizedReadSeekCloser {
+	return TransformSource(raw, idx.Size, GzipTransform(idx))
+}
+
+// GzipTransform возвращает преобразование для TransformSource, распаковывающее gzip с произвольным доступом по индексу idx.
+func GzipTransform(idx GzipIndex) Transform {
+	return func(raw SizedReadSeekCloser) (io.ReadSeeker, error) {
+		if len(idx.Blocks) == 0 && idx.Size > 0 {
+			return nil, errors.New("gzip index has no blocks")
+		}
+		return &gzipSeeker{raw: raw, idx: idx}, nil
+	}
+}
+
+// gzipSeeker - распаковывающий io.ReadSeekCloser поверх сжатого источника с индексом блоков.
+type gzipSeeker struct {
+	raw   io.ReadSeeker // сжатый поток
+	idx   GzipIndex     // индекс блоков
+	br    *bufio.Reader // буфер чтения сжатых данных
+	zr    *gzip.Reader  // открытый декодер (nil - не открыт)
+	zrPos int64         // распакованная позиция декодера
+	pos   int64         // логическая позиция чтения
+}
+
+func (g *gzipSeeker) Read(p []byte) (int, error) {
+	if g.pos >= g.idx.Size {
+		return 0, io.EOF
+	}
+	if err := g.position(); err != nil {
+		return 0, err
+	}
+
+	n, err := g.zr.Read(p[:min(int64(len(p)), g.idx.Size-g.pos)])
+	g.pos += int64(n)
+	g.zrPos += int64(n)
+
+	return n, err
+}
+
+func (g *gzipSeeker) Seek(offset int64, whence int) (int64, error) {
+	var base int64
+	switch whence {
+	case io.SeekStart:
+		base = 0
+	case io.SeekCurrent:
+		base = g.pos
+	case io.SeekEnd:
+		base = g.idx.Size
+	default:
+		return 0, fmt.Errorf("invalid whence: %d", whence)
+	}
+
+	seekPos := base + offset
+	if seekPos < 0 || seekPos > g.idx.Size {
+		return 0, fmt.Errorf("seek position (%d) should be >= 0 and <= size (%d)", seekPos, g.idx.Size)
+	}
+	g.pos = seekPos
+
+	return seekPos, nil
+}
+
+// Close освобождает декодер; сжатый источник закрывает владелец.
+func (g *gzipSeeker) Close() error {
+	if g.zr == nil {
+		return nil
+	}
+	err := g.zr.Close()
+	g.zr = nil
+	return err
+}
+
+// position подводит декодер к логической позиции: вперёд внутри того же блока - пропуском,
+// иначе - переоткрытием с начала блока, содержащего позицию.
+func (g *gzipSeeker) position() error {
+	block := g.blockIndex(g.pos)
+	if g.zr == nil || g.pos < g.zrPos || (g.pos > g.zrPos && g.blockIndex(g.zrPos) != block) {
+		b := g.idx.Blocks[block]
+		if _, err := g.raw.Seek(b.CompressedOffset, io.SeekStart); err != nil {
+			return err
+		}
+		if g.br == nil {
+			g.br = bufio.NewReader(g.raw)
+		} else {
+			g.br.Reset(g.raw)
+		}
+		if g.zr == nil {
+			zr, err := gzip.NewReader(g.br)
+			if err != nil {
+				return fmt.Errorf("gzip block %d: %w", block, err)
+			}
+			g.zr = zr
+		} else if err := g.zr.Reset(g.br); err != nil {
+			g.zr = nil
+			return fmt.Errorf("gzip block %d: %w", block, err)
+		}
+		g.zrPos = b.Offset
+	}
+
+	if skip := g.pos - g.zrPos; skip > 0 {
+		n, err := io.CopyN(io.Discard, g.zr, skip)
+		g.zrPos += n
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// blockIndex возвращает индекс блока, содержащего распакованную позицию pos.
+func (g *gzipSeeker) blockIndex(pos int64) int {
+	return sort.Search(len(g.idx.Blocks), func(i int) bool { return g.idx.Blocks[i].Offset > pos }) - 1
+}
+
+// countingByteReader считает потреблённые байты; io.ByteReader не даёт gzip читать сжатые данные наперёд.
+type countingByteReader struct {
+	r *bufio.Reader
+	n int64
+}
+
+func (c *countingByteReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func (c *countingByteReader) ReadByte() (byte, error) {
+	b, err := c.r.ReadByte()
+	if err == nil {
+		c.n++
+	}
+	return b, err
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"sync"
+)
+
+// ComputeHashes параллельно хеширует поток, разделённый Split на parts участков, и возвращает дайджесты участков
+// по порядку - как для ETag составной загрузки. Курсор m не сдвигается. Отмена ctx прерывает хеширование между блоками.
+func (m *MultiReader) ComputeHashes(ctx context.Context, parts int, newHash func() hash.Hash) ([][]byte, error) {
+	if parts <= 0 {
+		return nil, fmt.Errorf("invalid parts count: %d", parts)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	sections := m.Split(parts)
+	digests := make([][]byte, parts)
+	errs := make([]error, parts)
+	var wg sync.WaitGroup
+	for i, section := range sections {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer section.Close()
+
+			h := newHash()
+			if err := copyContext(ctx, h, section); err != nil {
+				errs[i] = fmt.Errorf("part %d: %w", i, err)
+				cancel() // Остальные дайджесты уже не нужны
+				return
+			}
+			digests[i] = h.Sum(nil)
+		}()
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return digests, nil
+}
+
+// copyContext копирует src в dst блоками, проверяя отмену ctx между ними.
+func copyContext(ctx context.Context, dst io.Writer, src io.Reader) error {
+	buf := make([]byte, bufferSize)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		n, err := src.Read(buf)
+		if n > 0 {
+			if _, werr := dst.Write(buf[:n]); werr != nil {
+				return werr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
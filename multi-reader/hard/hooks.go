@@ -0,0 +1,101 @@
+package main
+
+import "sync"
+
+// Hooks - перехватчики событий мультиридера для логирования, учёта или модификации отдаваемых данных.
+// Вызываются вне внутренних блокировок, поэтому из них можно обращаться к мультиридеру.
+type Hooks struct {
+	// OnRead вызывается после каждого Read, отдавшего данные или ошибку потока: pos - позиция первого байта data.
+	// data можно изменить на месте - потребитель получит изменённые байты.
+	OnRead func(pos int64, data []byte, err error)
+	// OnSeek вызывается после успешного Seek.
+	OnSeek func(from, to int64)
+	// OnSourceSwitch вызывается, когда отданные потребителю байты переходят из источника from в источник to -
+	// на границе источников или после Seek в другой источник. При первом чтении from = -1. Пустые источники пропускаются.
+	OnSourceSwitch func(from, to int)
+	// OnSourceEnter вызывается, когда потребителю отдан первый байт источника: при переходе из другого источника
+	// или после повторного входа в уже покинутый. Offset - абсолютная позиция этого байта.
+	OnSourceEnter func(ev SourceEvent)
+	// OnSourceExit вызывается, когда потребитель покинул источник: дочитал его до конца (Offset - конец источника)
+	// или перешёл через Seek в другой источник (Offset - позиция сразу за последним отданным байтом).
+	OnSourceExit func(ev SourceEvent)
+	// OnEvict вызывается, когда блок кэша блоков (WithBlockCache) вытеснен из памяти сверх бюджета.
+	// При ev.Spilled он сохранён на дисковый уровень (WithDiskCache), иначе следом вызывается OnDrop.
+	OnEvict func(ev EvictEvent)
+	// OnDrop вызывается, когда блок покинул кэш блоков совсем из-за нехватки бюджета: вытеснен из памяти без
+	// дискового уровня, не поместился на диск или вытеснен с диска. Следующее обращение прочитает его из источников.
+	// OnEvict и OnDrop вызываются из префетчера и из ReadAt, поэтому возможны одновременные вызовы.
+	OnDrop func(ev EvictEvent)
+}
+
+// hookCursor - что потребитель получил последним: по нему afterRead решает, какие события границ источников вызвать.
+// Принадлежит мультиридеру, а не Hooks: один набор перехватчиков можно передать нескольким мультиридерам.
+type hookCursor struct {
+	mu     sync.Mutex // защищает src, end и exited
+	src    int        // источник, из которого отданы последние байты (-1 - ещё ничего не отдано)
+	end    int64      // позиция сразу за последним отданным байтом
+	exited bool       // флаг - для src уже вызван OnSourceExit
+}
+
+// SourceEvent описывает пересечение границы источника потребителем.
+type SourceEvent struct {
+	Index  int    // индекс источника
+	Name   string // имя источника (метод Name() string), "" - источник без имени
+	Offset int64  // абсолютная позиция в потоке
+}
+
+// afterRead вызывает OnRead и OnSourceSwitch для байт data, отданных с позиции pos.
+func (h *Hooks) afterRead(m *MultiReader, pos int64, data []byte, err error) {
+	if h.OnRead != nil && (len(data) > 0 || err != nil) {
+		h.OnRead(pos, data, err)
+	}
+	if (h.OnSourceSwitch == nil && h.OnSourceEnter == nil && h.OnSourceExit == nil) || len(data) == 0 {
+		return
+	}
+
+	first, last := m.readerIndex(pos), m.readerIndex(pos+int64(len(data))-1)
+	prefixSizes := m.prefixes()
+	for i := first; i <= last; i++ {
+		srcStart, srcEnd := prefixSizes[i], prefixSizes[i+1]
+		if srcStart == srcEnd {
+			continue
+		}
+		start, end := max(pos, srcStart), min(pos+int64(len(data)), srcEnd)
+
+		c := m.hookPos
+		c.mu.Lock()
+		from, fromEnd, fromExited := c.src, c.end, c.exited
+		c.src, c.end, c.exited = i, end, end == srcEnd
+		c.mu.Unlock()
+
+		if from != i || fromExited {
+			if from >= 0 && !fromExited {
+				h.sourceExit(m, from, fromEnd)
+			}
+			if from != i && h.OnSourceSwitch != nil {
+				h.OnSourceSwitch(from, i)
+			}
+			if h.OnSourceEnter != nil {
+				h.OnSourceEnter(SourceEvent{Index: i, Name: sourceName(m.readers[i]), Offset: start})
+			}
+		}
+		if end == srcEnd {
+			h.sourceExit(m, i, end)
+		}
+	}
+}
+
+// sourceExit вызывает OnSourceExit для i-го источника.
+func (h *Hooks) sourceExit(m *MultiReader, i int, offset int64) {
+	if h.OnSourceExit != nil {
+		h.OnSourceExit(SourceEvent{Index: i, Name: sourceName(m.readers[i]), Offset: offset})
+	}
+}
+
+// sourceName возвращает имя источника из его метода Name() string или "".
+func sourceName(r SizedReadSeekCloser) string {
+	if named, ok := r.(interface{ Name() string }); ok {
+		return named.Name()
+	}
+	return ""
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SizedReaderAt - поток с позиционным чтением и известным размером (MultiReader, Multiplexer).
+type SizedReaderAt interface {
+	io.ReaderAt
+	Size() int64
+}
+
+// HTTPHandler отдаёт собранный поток по HTTP с поддержкой Range (в том числе multipart), HEAD, ETag
+// и условных запросов (If-Match, If-None-Match, If-Range, If-Modified-Since). Каждый запрос читает поток
+// через ReadAt со своей позицией, поэтому запросы обслуживаются параллельно; с Multiplexer пересекающиеся
+// диапазоны разных запросов читаются из источников один раз.
+type HTTPHandler struct {
+	src         SizedReaderAt
+	ETag        string    // сильный ETag в кавычках ("" - не отдаётся)
+	ModTime     time.Time // время изменения для Last-Modified (нулевое - не отдаётся)
+	ContentType string    // тип содержимого ("" - application/octet-stream)
+}
+
+// Проверка, что HTTPHandler удовлетворяет интерфейсу http.Handler
+var _ http.Handler = (*HTTPHandler)(nil)
+
+// NewHTTPHandler создаёт обработчик, отдающий src. etag - значение ETag без кавычек ("" - не отдаётся),
+// например из ManifestETag.
+func NewHTTPHandler(src SizedReaderAt, etag string) *HTTPHandler {
+	h := &HTTPHandler{src: src}
+	if etag != "" {
+		h.ETag = `"` + etag + `"`
+	}
+	return h
+}
+
+func (h *HTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	contentType := h.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType) // Иначе ServeContent станет угадывать тип, читая начало потока
+	if h.ETag != "" {
+		w.Header().Set("ETag", h.ETag)
+	}
+
+	// ServeContent разбирает Range и условные заголовки; HEAD отвечает без тела
+	http.ServeContent(w, r, "", h.ModTime, io.NewSectionReader(h.src, 0, h.src.Size()))
+}
+
+// ManifestETag строит ETag по контрольным суммам манифеста: для одного источника - его сумма, для нескольких -
+// дайджест SHA-256 от сумм источников с суффиксом "-N" (по аналогии с ETag составной загрузки S3).
+// Если хоть у одного источника нет суммы, возвращает false.
+func ManifestETag(mf Manifest) (string, bool) {
+	if len(mf.Sources) == 0 {
+		return "", false
+	}
+	sums := make([]string, len(mf.Sources))
+	for i, e := range mf.Sources {
+		if e.Checksum == "" {
+			return "", false
+		}
+		sums[i] = e.Checksum
+	}
+	if len(sums) == 1 {
+		return sums[0], true
+	}
+
+	digest := sha256.Sum256([]byte(strings.Join(sums, "\n")))
+	return fmt.Sprintf("%s-%d", hex.EncodeToString(digest[:]), len(sums)), true
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Entry описывает источник, размещённый с абсолютного смещения Offset в итоговом потоке.
+type Entry struct {
+	Offset int64               // абсолютная позиция начала источника
+	Source SizedReadSeekCloser // источник данных
+}
+
+// NewMultiReaderWithLayout создаёт мультиридер по разреженной раскладке: источники размещаются по своим смещениям,
+// а промежутки между ними отдаются нулевыми байтами. Пересекающиеся источники и отрицательные смещения - ошибка.
+func NewMultiReaderWithLayout(buffersNum int, entries []Entry) (*MultiReader, error) {
+	sorted := make([]Entry, len(entries))
+	copy(sorted, entries)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Offset < sorted[j].Offset })
+
+	readers := make([]SizedReadSeekCloser, 0, 2*len(sorted))
+	var pos int64
+	for i, e := range sorted {
+		if e.Offset < 0 {
+			return nil, fmt.Errorf("layout entry %d: negative offset %d", i, e.Offset)
+		}
+		if e.Source == nil {
+			return nil, fmt.Errorf("layout entry %d: nil source", i)
+		}
+		if e.Offset < pos {
+			return nil, fmt.Errorf("layout entry at offset %d overlaps previous data ending at %d", e.Offset, pos)
+		}
+		if gap := e.Offset - pos; gap > 0 { // Промежуток без данных заполняется нулями
+			readers = append(readers, ZeroSource(gap))
+		}
+		readers = append(readers, e.Source)
+		pos = e.Offset + e.Source.Size()
+	}
+
+	return NewMultiReader(buffersNum, readers...), nil
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// SourceFactory открывает источник по требованию.
+type SourceFactory func(ctx context.Context) (SizedReadSeekCloser, error)
+
+// LazySpec описывает источник, открываемый лениво: объявленный размер и фабрику.
+type LazySpec struct {
+	Size int64         // объявленный размер источника
+	Open SourceFactory // фабрика, вызываемая при первом входе курсора в источник
+	Name string        // необязательное имя источника (путь, ключ объекта) для SaveState
+}
+
+// lazySource - источник, открывающий настоящий ридер только при первом чтении и закрывающий его после полного прочтения.
+type lazySource struct {
+	ctx    context.Context     // контекст, передаваемый в фабрику
+	size   int64               // объявленный размер
+	open   SourceFactory       // фабрика источника
+	name   string              // имя источника из LazySpec
+	src    SizedReadSeekCloser // открытый источник (nil - не открыт или уже освобождён)
+	srcPos int64               // позиция внутри открытого источника
+	pos    int64               // логическая позиция чтения
+	closed bool                // флаг закрытия
+}
+
+// Проверка, что lazySource удовлетворяет интерфейсам SizedReadSeekCloser и ContextReader
+var (
+	_ SizedReadSeekCloser = (*lazySource)(nil)
+	_ ContextReader       = (*lazySource)(nil)
+)
+
+// LazySource создаёт источник объявленного размера size, который будет открыт фабрикой open при первом чтении.
+func LazySource(ctx context.Context, size int64, open SourceFactory) SizedReadSeekCloser {
+	return &lazySource{
+		ctx:  ctx,
+		size: size,
+		open: open,
+	}
+}
+
+// newLazySource создаёт ленивый источник по описанию spec.
+func newLazySource(ctx context.Context, spec LazySpec) *lazySource {
+	return &lazySource{ctx: ctx, size: spec.Size, open: spec.Open, name: spec.Name}
+}
+
+// NewMultiReaderLazy создаёт мультиридер, источники которого открываются только при первом входе в них курсора
+// и закрываются сразу после полного прочтения. Позволяет объединять тысячи файлов/соединений без их одновременного открытия.
+func NewMultiReaderLazy(ctx context.Context, buffersNum int, specs ...LazySpec) *MultiReader {
+	readers := make([]SizedReadSeekCloser, len(specs))
+	for i, spec := range specs {
+		readers[i] = newLazySource(ctx, spec)
+	}
+
+	return NewMultiReader(buffersNum, readers...)
+}
+
+// Read открывает источник при необходимости и читает с текущей позиции.
+func (l *lazySource) Read(p []byte) (int, error) {
+	return l.ReadContext(context.Background(), p)
+}
+
+// ReadContext - Read, передающий ctx открытому источнику, если тот реализует ContextReader.
+func (l *lazySource) ReadContext(ctx context.Context, p []byte) (int, error) {
+	if l.closed {
+		return 0, io.ErrClosedPipe
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if l.pos >= l.size {
+		return 0, io.EOF
+	}
+
+	if err := l.ensureOpen(); err != nil {
+		return 0, err
+	}
+
+	n, err := readContext(ctx, l.src, p[:min(int64(len(p)), l.size-l.pos)])
+	l.pos += int64(n)
+	l.srcPos += int64(n)
+	if l.pos >= l.size { // Источник прочитан полностью - освобождаем его, не дожидаясь Close
+		if closeErr := l.release(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+
+	return n, err
+}
+
+// Seek перемещает логическую позицию. Фактический Seek в источнике выполняется лениво при чтении.
+func (l *lazySource) Seek(offset int64, whence int) (int64, error) {
+	if l.closed {
+		return 0, io.ErrClosedPipe
+	}
+
+	var base int64
+	switch whence {
+	case io.SeekStart:
+		base = 0
+	case io.SeekCurrent:
+		base = l.pos
+	case io.SeekEnd:
+		base = l.size
+	default:
+		return 0, fmt.Errorf("invalid whence: %d", whence)
+	}
+
+	seekPos := base + offset
+	if seekPos < 0 || seekPos > l.size {
+		return 0, fmt.Errorf("seek position (%d) should be >= 0 and <= size (%d)", seekPos, l.size)
+	}
+	l.pos = seekPos
+
+	return seekPos, nil
+}
+
+// Close закрывает открытый источник. Повторный вызов возвращает nil.
+func (l *lazySource) Close() error {
+	if l.closed {
+		return nil
+	}
+	l.closed = true
+
+	return l.release()
+}
+
+// Reopen снимает признак закрытия: источник будет открыт фабрикой заново при следующем чтении.
+func (l *lazySource) Reopen() error {
+	l.closed = false
+	return nil
+}
+
+// Name возвращает имя источника из LazySpec.
+func (l *lazySource) Name() string {
+	return l.name
+}
+
+// Size возвращает объявленный размер, не открывая источник.
+func (l *lazySource) Size() int64 {
+	return l.size
+}
+
+// ensureOpen открывает источник (повторно - после освобождения) и выставляет в нём логическую позицию.
+func (l *lazySource) ensureOpen() error {
+	if l.src == nil {
+		src, err := l.open(l.ctx)
+		if err != nil {
+			return fmt.Errorf("open lazy source: %w", err)
+		}
+		if src.Size() != l.size {
+			return errors.Join(
+				fmt.Errorf("lazy source size mismatch: declared %d, actual %d", l.size, src.Size()),
+				src.Close(),
+			)
+		}
+		if token, ok := ConsistencyTokenFromContext(l.ctx); ok {
+			if setter, ok := src.(ConsistencyTokenSetter); ok {
+				setter.SetConsistencyToken(token)
+			}
+		}
+		l.src, l.srcPos = src, 0
+	}
+
+	if l.srcPos != l.pos {
+		if _, err := l.src.Seek(l.pos, io.SeekStart); err != nil {
+			return err
+		}
+		l.srcPos = l.pos
+	}
+
+	return nil
+}
+
+// release закрывает открытый источник, если он есть.
+func (l *lazySource) release() error {
+	if l.src == nil {
+		return nil
+	}
+	err := l.src.Close()
+	l.src = nil
+
+	return err
+}
@@ -0,0 +1,12 @@
+package main
+
+import "sync/atomic"
+
+// livePrefetchers - число работающих горутин префетча во всех мультиридерах процесса.
+var livePrefetchers atomic.Int64
+
+// LivePrefetchers возвращает число работающих горутин префетча во всех мультиридерах. Отладочный счётчик:
+// значение, растущее со временем, указывает на мультиридеры, брошенные посреди потока без Close.
+func LivePrefetchers() int64 {
+	return livePrefetchers.Load()
+}
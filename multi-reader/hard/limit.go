@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// LimitedMultiReader - представление не более n байт мультиридера с позиции его курсора на момент Limit,
+// аналог io.LimitedReader с поддержкой Seek, ReadAt и Size.
+type LimitedMultiReader struct {
+	m     *MultiReader
+	start int64 // абсолютная позиция начала представления в m
+	size  int64 // размер представления
+	pos   int64 // позиция внутри представления
+}
+
+// Проверка, что LimitedMultiReader удовлетворяет интерфейсам SizedReadSeekCloser и io.ReaderAt
+var (
+	_ SizedReadSeekCloser = (*LimitedMultiReader)(nil)
+	_ io.ReaderAt         = (*LimitedMultiReader)(nil)
+)
+
+// Limit возвращает представление, отдающее не более n байт с текущей позиции: Size() = min(n, остаток потока),
+// после него - io.EOF. Представление двигает курсор m и рассчитано на монопольное использование m;
+// его Close закрывает m.
+func (m *MultiReader) Limit(n int64) *LimitedMultiReader {
+	m.mu.Lock()
+	start := m.absPos
+	m.mu.Unlock()
+
+	return &LimitedMultiReader{
+		m:     m,
+		start: start,
+		size:  max(0, min(n, m.size.Load()-start)),
+	}
+}
+
+// Read читает из m, не выходя за границу представления.
+func (l *LimitedMultiReader) Read(p []byte) (int, error) {
+	if l.pos >= l.size {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > l.size-l.pos {
+		p = p[:l.size-l.pos]
+	}
+
+	n, err := l.m.Read(p)
+	l.pos += int64(n)
+
+	return n, err
+}
+
+// ReadAt читает с позиции off внутри представления, не сдвигая курсор.
+func (l *LimitedMultiReader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, fmt.Errorf("invalid offset: %d", off)
+	}
+	if off >= l.size {
+		return 0, io.EOF
+	}
+
+	limited := p
+	if int64(len(p)) > l.size-off {
+		limited = p[:l.size-off]
+	}
+	n, err := l.m.ReadAt(limited, l.start+off)
+	if err == nil && n < len(p) {
+		err = io.EOF
+	}
+
+	return n, err
+}
+
+// Seek перемещает позицию внутри представления и курсор m.
+func (l *LimitedMultiReader) Seek(offset int64, whence int) (int64, error) {
+	var base int64
+	switch whence {
+	case io.SeekStart:
+		base = 0
+	case io.SeekCurrent:
+		base = l.pos
+	case io.SeekEnd:
+		base = l.size
+	default:
+		return 0, fmt.Errorf("invalid whence: %d", whence)
+	}
+
+	seekPos := base + offset
+	if seekPos < 0 || seekPos > l.size {
+		return 0, fmt.Errorf("seek position (%d) should be >= 0 and <= size (%d)", seekPos, l.size)
+	}
+	if _, err := l.m.Seek(l.start+seekPos, io.SeekStart); err != nil {
+		return 0, err
+	}
+	l.pos = seekPos
+
+	return seekPos, nil
+}
+
+// Close закрывает нижележащий мультиридер.
+func (l *LimitedMultiReader) Close() error {
+	return l.m.Close()
+}
+
+// Size возвращает размер представления.
+func (l *LimitedMultiReader) Size() int64 {
+	return l.size
+}
@@ -1,14 +1,56 @@
 package main
 
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "bench" { // Режим замеров вместо прогона тестов
+		if err := runBench(os.Args[2:], os.Stdout); err != nil {
+			_, _ = fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "stress" { // Стресс-прогоны против эталонной реализации
+		if err := runStress(os.Args[2:], os.Stdout); err != nil {
+			_, _ = fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	tests := append(testCases, privateTestCases...)
 
+	// С -format или -keep-going прогоняются все кейсы, а отчёт пишется в stdout в выбранном формате
+	flags := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	format := flags.String("format", "", "формат отчёта: text, json (JSON Lines) или junit")
+	keepGoing := flags.Bool("keep-going", false, "не останавливаться на первом провале")
+	_ = flags.Parse(os.Args[1:])
+	if *format != "" || *keepGoing {
+		if *format == "" {
+			*format = "text"
+		}
+		failed, err := runReport(tests, *format, os.Stdout)
+		if err != nil {
+			_, _ = fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		if failed > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
 	for _, tc := range tests {
 		name := tc.name
 		run := tc.run
 
-		CustomTestBody(
+		ConcurrentCustomTestBodyWithTimeout(
 			name,
+			tc.caseTimeout(),
 			func() struct{} {
 				return struct{}{}
 			},
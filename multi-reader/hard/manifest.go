@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+)
+
+// ManifestEntry описывает источник в манифесте.
+type ManifestEntry struct {
+	Name     string `json:"name,omitempty"`     // имя источника (путь, ключ объекта)
+	Size     int64  `json:"size"`               // размер источника
+	Checksum string `json:"checksum,omitempty"` // hex-дайджест содержимого (алгоритм - Manifest.Algorithm)
+}
+
+// Manifest описывает склейку источников так, чтобы её можно было воспроизвести на другой машине.
+type Manifest struct {
+	TotalSize int64           `json:"total_size"`          // суммарный размер
+	Algorithm string          `json:"algorithm,omitempty"` // алгоритм контрольных сумм ("" - без них)
+	Sources   []ManifestEntry `json:"sources"`             // источники в порядке склейки
+}
+
+// ManifestOpener открывает источник, описанный записью манифеста.
+type ManifestOpener func(ctx context.Context, e ManifestEntry) (SizedReadSeekCloser, error)
+
+// Manifest возвращает манифест склейки: имена и размеры источников. Имя берётся из метода источника Name() string.
+// Если newHash не nil, для каждого источника считается контрольная сумма: источники читаются через ReadAt,
+// курсор m не сдвигается. algorithm записывается в манифест как есть (например, "sha256").
+func (m *MultiReader) Manifest(ctx context.Context, algorithm string, newHash func() hash.Hash) (Manifest, error) {
+	st := m.SaveState()
+	mf := Manifest{TotalSize: st.TotalSize, Sources: make([]ManifestEntry, len(st.Sources))}
+	for i, src := range st.Sources {
+		mf.Sources[i] = ManifestEntry{Name: src.Name, Size: src.Size}
+	}
+	if newHash == nil {
+		return mf, nil
+	}
+
+	mf.Algorithm = algorithm
+	for i := range mf.Sources {
+		sum, err := m.sourceChecksum(ctx, i, newHash)
+		if err != nil {
+			return Manifest{}, fmt.Errorf("source %d: %w", i, err)
+		}
+		mf.Sources[i].Checksum = hex.EncodeToString(sum)
+	}
+
+	return mf, nil
+}
+
+// VerifyManifest сверяет размеры и контрольные суммы источников m с манифестом. Записи без контрольной суммы
+// сверяются только по размеру. Расхождение возвращает ошибку, для которой errors.Is(err, ErrSourceChanged).
+func (m *MultiReader) VerifyManifest(ctx context.Context, mf Manifest, newHash func() hash.Hash) error {
+	if len(mf.Sources) != len(m.readers) {
+		return fmt.Errorf("manifest has %d sources, reader has %d: %w", len(mf.Sources), len(m.readers), ErrSourceChanged)
+	}
+	for i, e := range mf.Sources {
+		if size := m.readers[i].Size(); size != e.Size {
+			return fmt.Errorf("source %d: size %d differs from manifest %d: %w", i, size, e.Size, ErrSourceChanged)
+		}
+		if e.Checksum == "" {
+			continue
+		}
+		want, err := hex.DecodeString(e.Checksum)
+		if err != nil {
+			return fmt.Errorf("source %d: malformed checksum: %w", i, err)
+		}
+		sum, err := m.sourceChecksum(ctx, i, newHash)
+		if err != nil {
+			return fmt.Errorf("source %d: %w", i, err)
+		}
+		if !bytes.Equal(sum, want) {
+			return fmt.Errorf("source %d: checksum mismatch: %w", i, ErrSourceChanged)
+		}
+	}
+
+	return nil
+}
+
+// NewMultiReaderFromManifest воссоздаёт склейку по манифесту: источники открываются через open лениво,
+// при первом входе в них курсора, и сверяются с манифестом по размеру. Контрольные суммы проверяет VerifyManifest.
+func NewMultiReaderFromManifest(ctx context.Context, buffersNum int, mf Manifest, open ManifestOpener, opts ...Option) (*MultiReader, error) {
+	readers := make([]SizedReadSeekCloser, len(mf.Sources))
+	var total int64
+	for i, e := range mf.Sources {
+		readers[i] = newLazySource(ctx, LazySpec{
+			Size: e.Size,
+			Open: func(ctx context.Context) (SizedReadSeekCloser, error) { return open(ctx, e) },
+			Name: e.Name,
+		})
+		total += e.Size
+	}
+	if total != mf.TotalSize {
+		return nil, fmt.Errorf("malformed manifest: sources sum to %d, total size %d", total, mf.TotalSize)
+	}
+
+	return NewMultiReaderWithOptions(buffersNum, readers, opts...), nil
+}
+
+// ReadManifest читает манифест в JSON.
+func ReadManifest(r io.Reader) (Manifest, error) {
+	var mf Manifest
+	if err := json.NewDecoder(r).Decode(&mf); err != nil {
+		return Manifest{}, fmt.Errorf("decode manifest: %w", err)
+	}
+	return mf, nil
+}
+
+// WriteManifest записывает манифест в JSON.
+func WriteManifest(w io.Writer, mf Manifest) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(mf)
+}
+
+// sourceChecksum хеширует i-й источник через ReadAt мультиридера.
+func (m *MultiReader) sourceChecksum(ctx context.Context, i int, newHash func() hash.Hash) ([]byte, error) {
+	h := newHash()
+	prefixSizes := m.prefixes()
+	section := io.NewSectionReader(m, prefixSizes[i], prefixSizes[i+1]-prefixSizes[i])
+	if err := copyContext(ctx, h, section); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"math"
+	"runtime/debug"
+	"runtime/metrics"
+	"time"
+)
+
+// memoryPressurePoll - как часто префетчер, придержавший блок, перепроверяет давление памяти, если потребитель
+// не забирает блоки.
+const memoryPressurePoll = 10 * time.Millisecond
+
+// memoryPressure - режим WithMemoryLimitAware.
+type memoryPressure struct {
+	threshold float64        // доля GOMEMLIMIT, начиная с которой окно сжимается до одного блока
+	probe     func() float64 // текущая доля занятой памяти от лимита (подменяется в тестах)
+}
+
+// WithMemoryLimitAware сжимает окно префетча, когда память процесса подходит к GOMEMLIMIT: начиная с доли
+// threshold лимита (например, 0.9) префетчер читает следующий блок, только когда потребитель забрал предыдущий,
+// то есть вперёд читается не больше одного блока. Когда давление спадает, окно снова растёт до buffersNum блоков.
+// Без заданного GOMEMLIMIT (debug.SetMemoryLimit) опция ни на что не влияет. Полезно сервисам, где одновременно
+// читаются сотни потоков: вместе они не выталкивают процесс за лимит ради чтения наперёд.
+func WithMemoryLimitAware(threshold float64) Option {
+	return func(m *MultiReader) {
+		m.memPressure = &memoryPressure{threshold: threshold, probe: runtimeMemoryPressure}
+		if m.pfSpace == nil {
+			m.pfSpace = make(chan struct{}, 1)
+		}
+	}
+}
+
+// runtimeMemoryPressure возвращает долю памяти, занятой рантаймом, от GOMEMLIMIT - по той же метрике, по которой
+// рантайм сверяется с лимитом. Без лимита возвращает 0.
+func runtimeMemoryPressure() float64 {
+	limit := debug.SetMemoryLimit(-1)
+	if limit <= 0 || limit == math.MaxInt64 {
+		return 0
+	}
+	samples := []metrics.Sample{
+		{Name: "/memory/classes/total:bytes"},
+		{Name: "/memory/classes/heap/released:bytes"},
+	}
+	metrics.Read(samples)
+	used := samples[0].Value.Uint64() - samples[1].Value.Uint64()
+
+	return float64(used) / float64(limit)
+}
+
+// waitMemoryPressure придерживает очередной блок префетчера, пока в очереди есть непрочитанные блоки,
+// а память близка к GOMEMLIMIT. Возвращается, когда потребитель забрал блоки или давление спало.
+func (m *MultiReader) waitMemoryPressure(ctx context.Context) error {
+	var poll *time.Timer
+	defer func() {
+		if poll != nil {
+			poll.Stop()
+		}
+	}()
+	for {
+		if m.pfQueued.Load() <= 0 || m.memPressure.probe() < m.memPressure.threshold {
+			return nil
+		}
+		if poll == nil {
+			m.stats.pressureStalls.Add(1)
+			poll = time.NewTimer(memoryPressurePoll)
+		} else {
+			poll.Reset(memoryPressurePoll)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-m.pfSpace:
+		case <-poll.C:
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// errMmapUnsupported - платформа не поддерживает отображение файлов в память.
+var errMmapUnsupported = errors.New("mmap is not supported on this platform")
+
+// blockViewer - источник, отдающий блоки префетчеру срезом собственной памяти, без выделения и копирования.
+type blockViewer interface {
+	viewAt(off int64, n int) ([]byte, error)
+}
+
+// mmapSource - локальный файл, отображённый в память: префетчер берёт блоки прямо из отображения.
+type mmapSource struct {
+	data   []byte // отображение файла
+	pos    int64  // текущая позиция чтения
+	closed bool   // флаг закрытия; после него отображение снято и обращаться к data нельзя
+}
+
+// Проверка, что mmapSource удовлетворяет интерфейсам SizedReadSeekCloser и blockViewer
+var (
+	_ SizedReadSeekCloser = (*mmapSource)(nil)
+	_ blockViewer         = (*mmapSource)(nil)
+)
+
+// OpenMmapSource отображает файл path в память и возвращает источник поверх отображения.
+// На платформах без mmap возвращается обычный файловый источник (OpenFileSource).
+func OpenMmapSource(path string) (SizedReadSeekCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("stat %s: %w", path, err)
+	}
+
+	data, err := mmapFile(f, info.Size())
+	if errors.Is(err, errMmapUnsupported) {
+		return NewFileSource(f)
+	}
+	if closeErr := f.Close(); err == nil { // Отображение живёт независимо от дескриптора
+		err = closeErr
+	}
+	if err != nil {
+		_ = munmap(data)
+		return nil, fmt.Errorf("mmap %s: %w", path, err)
+	}
+
+	return &mmapSource{data: data}, nil
+}
+
+func (s *mmapSource) Read(p []byte) (int, error) {
+	n, err := s.ReadAt(p, s.pos)
+	s.pos += int64(n)
+	if err == io.EOF && n > 0 { // Отдаём прочитанные байты сейчас, EOF - следующим вызовом
+		err = nil
+	}
+	return n, err
+}
+
+func (s *mmapSource) ReadAt(p []byte, off int64) (int, error) {
+	if s.closed {
+		return 0, io.ErrClosedPipe
+	}
+	if off >= int64(len(s.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, s.data[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (s *mmapSource) viewAt(off int64, n int) ([]byte, error) {
+	if s.closed {
+		return nil, io.ErrClosedPipe
+	}
+	if off >= int64(len(s.data)) {
+		return nil, io.EOF
+	}
+	return s.data[off:min(off+int64(n), int64(len(s.data)))], nil
+}
+
+func (s *mmapSource) Seek(offset int64, whence int) (int64, error) {
+	if s.closed {
+		return 0, io.ErrClosedPipe
+	}
+
+	var base int64
+	switch whence {
+	case io.SeekStart:
+		base = 0
+	case io.SeekCurrent:
+		base = s.pos
+	case io.SeekEnd:
+		base = int64(len(s.data))
+	default:
+		return 0, fmt.Errorf("invalid whence: %d", whence)
+	}
+
+	seekPos := base + offset
+	if seekPos < 0 || seekPos > int64(len(s.data)) {
+		return 0, fmt.Errorf("seek position (%d) should be >= 0 and <= size (%d)", seekPos, len(s.data))
+	}
+	s.pos = seekPos
+
+	return seekPos, nil
+}
+
+// Close снимает отображение. Повторный вызов возвращает nil.
+func (s *mmapSource) Close() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	err := munmap(s.data)
+	s.data = nil
+
+	return err
+}
+
+func (s *mmapSource) Size() int64 {
+	return int64(len(s.data))
+}
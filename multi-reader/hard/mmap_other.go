@@ -0,0 +1,14 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// mmapFile на платформах без mmap всегда возвращает errMmapUnsupported - используется обычный файловый источник.
+func mmapFile(*os.File, int64) ([]byte, error) {
+	return nil, errMmapUnsupported
+}
+
+func munmap([]byte) error {
+	return nil
+}
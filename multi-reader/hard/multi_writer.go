@@ -0,0 +1,151 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+// SizedWriteSeekCloser - интерфейс писателя с возможностью seek и заранее известным размером.
+type SizedWriteSeekCloser interface {
+	io.WriteSeeker
+	io.Closer
+	Size() int64
+}
+
+// MultiWriter - парный к MultiReader писатель: раскладывает единый поток записи по нескольким
+// SizedWriteSeekCloser согласно их объявленным размерам и поддерживает Seek.
+type MultiWriter struct {
+	writers     []SizedWriteSeekCloser // целевые писатели
+	prefixSizes []int64                // абсолютные стартовые позиции писателей (префиксные суммы)
+	totalSize   int64                  // суммарный размер
+	absPos      int64                  // абсолютная позиция записи
+	curIdx      int                    // писатель, позиция которого совпадает с absPos (-1 - нужен Seek)
+	closed      bool                   // флаг закрытия
+	mu          sync.Mutex             // мьютекс для блокировок
+}
+
+// Проверка, что MultiWriter удовлетворяет интерфейсу SizedWriteSeekCloser
+var _ SizedWriteSeekCloser = (*MultiWriter)(nil)
+
+// NewMultiWriter создаёт писатель, конкатенирующий writers.
+func NewMultiWriter(writers ...SizedWriteSeekCloser) *MultiWriter {
+	prefixSizes := make([]int64, len(writers)+1)
+	var total int64
+	for i, w := range writers {
+		prefixSizes[i] = total
+		total += w.Size()
+	}
+	prefixSizes[len(writers)] = total
+
+	return &MultiWriter{
+		writers:     writers,
+		prefixSizes: prefixSizes,
+		totalSize:   total,
+		curIdx:      -1,
+	}
+}
+
+// Write пишет p с текущей позиции, переходя между писателями на их границах.
+// Запись за пределы суммарного размера не выполняется и возвращает ошибку с io.ErrShortWrite.
+func (w *MultiWriter) Write(p []byte) (n int, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, io.ErrClosedPipe
+	}
+
+	for n < len(p) {
+		if w.absPos >= w.totalSize {
+			return n, fmt.Errorf("write of %d bytes at %d exceeds totalSize (%d): %w", len(p)-n, w.absPos, w.totalSize, io.ErrShortWrite)
+		}
+
+		i := sort.Search(len(w.writers), func(i int) bool { return w.prefixSizes[i+1] > w.absPos })
+		if i != w.curIdx {
+			if _, err := w.writers[i].Seek(w.absPos-w.prefixSizes[i], io.SeekStart); err != nil {
+				w.curIdx = -1
+				return n, err
+			}
+			w.curIdx = i
+		}
+
+		chunk := p[n:min(int64(len(p)), int64(n)+w.prefixSizes[i+1]-w.absPos)]
+		k, err := w.writers[i].Write(chunk)
+		n += k
+		w.absPos += int64(k)
+		if err != nil {
+			w.curIdx = -1
+			return n, err
+		}
+		if k < len(chunk) {
+			w.curIdx = -1
+			return n, io.ErrShortWrite
+		}
+	}
+
+	return n, nil
+}
+
+// Seek перемещает позицию записи. Позиция в писателе выставляется при следующей записи.
+func (w *MultiWriter) Seek(offset int64, whence int) (int64, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, io.ErrClosedPipe
+	}
+
+	var base int64
+	switch whence {
+	case io.SeekStart:
+		base = 0
+	case io.SeekCurrent:
+		base = w.absPos
+	case io.SeekEnd:
+		base = w.totalSize
+	default:
+		return 0, fmt.Errorf("invalid whence: %d", whence)
+	}
+
+	seekPos := base + offset
+	if seekPos < 0 || seekPos > w.totalSize {
+		return 0, fmt.Errorf("seek position (%d) should be >= 0 and <= totalSize (%d)", seekPos, w.totalSize)
+	}
+	if seekPos != w.absPos {
+		w.curIdx = -1
+	}
+	w.absPos = seekPos
+
+	return seekPos, nil
+}
+
+// Close закрывает все писатели, агрегируя ошибки.
+func (w *MultiWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	var multiErr error
+	for _, wr := range w.writers {
+		if err := wr.Close(); err != nil {
+			multiErr = errors.Join(multiErr, err)
+		}
+	}
+	if multiErr != nil {
+		return fmt.Errorf("error when closing: %w", multiErr)
+	}
+
+	return nil
+}
+
+// Size возвращает суммарный размер всех писателей.
+func (w *MultiWriter) Size() int64 {
+	return w.totalSize
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sync/atomic"
+)
+
+// Multiplexer владеет источниками и кэшем блоков собранного объекта и раздаёт независимые читающие дескрипторы.
+// Дескрипторы читают через общий кэш: пересекающиеся чтения разных потребителей (например, HTTP range-запросы
+// к одному объекту) обращаются к источникам один раз.
+type Multiplexer struct {
+	m *MultiReader
+}
+
+// NewMultiplexer создаёт мультиплексор над источниками readers с кэшем блоков бюджетом cacheBudget байт.
+// Источники закрываются в Close мультиплексора.
+func NewMultiplexer(cacheBudget int64, readers ...SizedReadSeekCloser) *Multiplexer {
+	return &Multiplexer{
+		m: NewMultiReaderWithOptions(1, readers, WithBlockCache(cacheBudget)),
+	}
+}
+
+// NewReader возвращает дескриптор для чтения всего объекта со своей позицией.
+func (x *Multiplexer) NewReader() SizedReadSeekCloser {
+	return &muxReader{sr: io.NewSectionReader(x.m, 0, x.m.size.Load())}
+}
+
+// NewRangeReader возвращает дескриптор для чтения диапазона [offset, offset+length) объекта.
+func (x *Multiplexer) NewRangeReader(offset, length int64) (SizedReadSeekCloser, error) {
+	if size := x.m.size.Load(); offset < 0 || length < 0 || offset+length > size {
+		return nil, fmt.Errorf("range [%d, %d) should be within size (%d)", offset, offset+length, size)
+	}
+
+	return &muxReader{sr: io.NewSectionReader(x.m, offset, length)}, nil
+}
+
+// ReadAt читает объект с позиции off через общий кэш.
+func (x *Multiplexer) ReadAt(p []byte, off int64) (int, error) {
+	return x.m.ReadAt(p, off)
+}
+
+// Size возвращает размер объекта.
+func (x *Multiplexer) Size() int64 {
+	return x.m.size.Load()
+}
+
+// Close закрывает источники и освобождает кэш. Чтение из дескрипторов после этого возвращает io.ErrClosedPipe.
+func (x *Multiplexer) Close() error {
+	return x.m.Close()
+}
+
+// muxReader - дескриптор мультиплексора. Потокобезопасен только Close; Read и Seek одного дескриптора
+// нельзя вызывать конкурентно, разные дескрипторы независимы.
+type muxReader struct {
+	sr     *io.SectionReader
+	closed atomic.Bool
+}
+
+// Проверка, что muxReader удовлетворяет интерфейсам SizedReadSeekCloser и io.ReaderAt
+var (
+	_ SizedReadSeekCloser = (*muxReader)(nil)
+	_ io.ReaderAt         = (*muxReader)(nil)
+)
+
+func (r *muxReader) Read(p []byte) (int, error) {
+	if r.closed.Load() {
+		return 0, io.ErrClosedPipe
+	}
+	return r.sr.Read(p)
+}
+
+func (r *muxReader) ReadAt(p []byte, off int64) (int, error) {
+	if r.closed.Load() {
+		return 0, io.ErrClosedPipe
+	}
+	return r.sr.ReadAt(p, off)
+}
+
+func (r *muxReader) Seek(offset int64, whence int) (int64, error) {
+	if r.closed.Load() {
+		return 0, io.ErrClosedPipe
+	}
+	return r.sr.Seek(offset, whence)
+}
+
+// Close закрывает только дескриптор: источники принадлежат мультиплексору.
+func (r *muxReader) Close() error {
+	r.closed.Store(true)
+	return nil
+}
+
+func (r *muxReader) Size() int64 {
+	return r.sr.Size()
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// SeekToSource ставит курсор на начало i-го источника. i == числу источников ставит курсор на конец потока.
+// Пустые источники занимают нулевой отрезок: их начало совпадает с началом следующего источника.
+func (m *MultiReader) SeekToSource(i int) (int64, error) {
+	return m.seekTo(func() (int64, error) {
+		if i < 0 || i > len(m.readers) {
+			return 0, fmt.Errorf("source index %d out of range [0, %d]", i, len(m.readers))
+		}
+		return m.prefixes()[i], nil
+	})
+}
+
+// NextSource переводит курсор на начало источника, следующего за текущим. На конце потока возвращает io.EOF.
+func (m *MultiReader) NextSource() (int64, error) {
+	return m.seekTo(func() (int64, error) {
+		if m.absPos >= m.size.Load() {
+			return 0, io.EOF
+		}
+		return m.prefixes()[m.readerIndex(m.absPos)+1], nil
+	})
+}
+
+// CurrentSource возвращает индекс источника, из которого будет прочитан следующий байт
+// (пустые источники пропускаются), или число источников, если курсор на конце потока.
+func (m *MultiReader) CurrentSource() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.absPos >= m.size.Load() {
+		return len(m.readers)
+	}
+	return m.readerIndex(m.absPos)
+}
+
+// seekTo перемещает курсор на абсолютную позицию, вычисленную target под m.mu, и вызывает хук OnSeek, как Seek.
+func (m *MultiReader) seekTo(target func() (int64, error)) (int64, error) {
+	m.mu.Lock()
+	from := m.absPos
+	pos, err := target()
+	if err == nil {
+		pos, err = m.seekLocked(pos, io.SeekStart)
+	}
+	m.mu.Unlock()
+
+	if err == nil && m.hooks != nil && m.hooks.OnSeek != nil {
+		m.hooks.OnSeek(from, pos)
+	}
+
+	return pos, err
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"errors"
+	"io"
+)
+
+// defaultMaxEmptyReads - сколько чтений (0, nil) подряд допускается, прежде чем источник считается зависшим
+// (столько же терпит bufio).
+const defaultMaxEmptyReads = 100
+
+// readFullProgress работает как io.ReadFull, но после maxEmpty пустых чтений подряд возвращает io.ErrNoProgress
+// вместо бесконечного цикла на источнике, который отвечает (0, nil).
+func readFullProgress(r io.Reader, p []byte, maxEmpty int) (n int, err error) {
+	empty := 0
+	for n < len(p) && err == nil {
+		var k int
+		k, err = r.Read(p[n:])
+		n += k
+		if k > 0 || err != nil {
+			empty = 0
+			continue
+		}
+		if empty++; empty >= maxEmpty {
+			return n, io.ErrNoProgress
+		}
+	}
+	switch {
+	case n == len(p):
+		err = nil
+	case n > 0 && errors.Is(err, io.EOF):
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"runtime"
+	"time"
+)
+
+// Option настраивает MultiReader при создании.
+type Option func(*MultiReader)
+
+// NewMultiReaderWithOptions создаёт мультиридер так же, как NewMultiReader, и применяет к нему опции.
+func NewMultiReaderWithOptions(buffersNum int, readers []SizedReadSeekCloser, opts ...Option) *MultiReader {
+	m := NewMultiReader(buffersNum, readers...)
+	for _, opt := range opts {
+		opt(m)
+	}
+	if m.cache != nil && m.hooks != nil && (m.hooks.OnEvict != nil || m.hooks.OnDrop != nil) {
+		m.cache.notify = true
+	}
+	if m.cache != nil && m.cache.disk != nil && m.diskCompress {
+		m.cache.disk.packer = newWindowPacker()
+	}
+	m.startCacheJanitor()
+
+	return m
+}
+
+// ErrColdStartTimeout - sentinel для errors.Is: первый блок префетча не пришёл вовремя.
+var ErrColdStartTimeout = errors.New("cold start timeout")
+
+// ColdStartTimeoutError возвращается из Read, если первый блок после создания или Seek не пришёл за отведённое время.
+type ColdStartTimeoutError struct {
+	Segment int           // индекс источника, с которого префетчер начал чтение
+	Timeout time.Duration // сработавший лимит
+}
+
+func (e *ColdStartTimeoutError) Error() string {
+	return fmt.Sprintf("first block from source %d did not arrive within %s", e.Segment, e.Timeout)
+}
+
+func (e *ColdStartTimeoutError) Is(target error) bool {
+	return target == ErrColdStartTimeout
+}
+
+// WithColdStartTimeout ограничивает ожидание первого блока после создания или Seek за окно.
+// Позволяет отличить «медленный» источник от «зависшего» на старте. Последующие блоки ждутся без лимита.
+func WithColdStartTimeout(d time.Duration) Option {
+	return func(m *MultiReader) {
+		m.coldStartTimeout = d
+	}
+}
+
+// WithReadTimeout ограничивает время, которое один вызов Read (а также ReadByte, Peek и др.) ждёт блоки
+// от префетчера: по истечении d он возвращает уже скопированные данные и os.ErrDeadlineExceeded.
+// Действует вместе с SetReadDeadline - срабатывает ближайший из сроков.
+func WithReadTimeout(d time.Duration) Option {
+	return func(m *MultiReader) {
+		m.readTimeout = d
+	}
+}
+
+// WithEagerClose закрывает каждый источник, как только префетчер прочитал его целиком, а не при Close мультиридера.
+// Если последующий Seek назад потребует закрытый источник, он переоткрывается через Reopener,
+// а при отсутствии такой возможности чтение завершается ошибкой ErrSourceClosed.
+// Источники из OpenMmapSource при этом читаются с копированием: отображение снимается сразу при закрытии.
+func WithEagerClose() Option {
+	return func(m *MultiReader) {
+		m.eagerClose = true
+	}
+}
+
+// WithConsistencyToken передаёт токен согласованности (snapshot ID) всем источникам, реализующим ConsistencyTokenSetter.
+// Ленивые источники получают токен и в контексте фабрики (см. ConsistencyTokenFromContext).
+func WithConsistencyToken(token string) Option {
+	return func(m *MultiReader) {
+		m.consistencyToken = token
+		for _, r := range m.readers {
+			if setter, ok := r.(ConsistencyTokenSetter); ok {
+				setter.SetConsistencyToken(token)
+			}
+		}
+	}
+}
+
+// WithoutClosingSources оставляет источники открытыми: Close останавливает префетчер, но не закрывает ридеры.
+// Нужна, когда одни и те же источники используются несколькими мультиридерами. Отключает WithEagerClose.
+func WithoutClosingSources() Option {
+	return func(m *MultiReader) {
+		m.borrowedSources = true
+	}
+}
+
+// WithCloseConcurrency закрывает источники в Close параллельно, не более n одновременно.
+// Полезно для сотен сетевых источников, последовательное закрытие которых занимает минуты.
+func WithCloseConcurrency(n int) Option {
+	return func(m *MultiReader) {
+		m.closeWorkers = n
+	}
+}
+
+// WithCloseOrder задаёт порядок закрытия источников. Порядок строго соблюдается только при последовательном
+// закрытии; с WithCloseConcurrency он определяет лишь порядок запуска закрытий.
+func WithCloseOrder(order CloseOrder) Option {
+	return func(m *MultiReader) {
+		m.closeOrder = order
+	}
+}
+
+// OnSourceClose регистрирует хук, вызываемый после закрытия каждого источника (в том числе досрочного)
+// с его индексом и ошибкой закрытия. При WithCloseConcurrency хук вызывается из нескольких горутин.
+func OnSourceClose(hook func(index int, err error)) Option {
+	return func(m *MultiReader) {
+		m.onSourceClose = hook
+	}
+}
+
+// WithCloseGracePeriod ограничивает ожидание остановки префетчера в Close. Если префетчер завис в чтении источника,
+// не поддерживающего ContextReader, Close по истечении d возвращает ErrCloseTimeout, а брошенная горутина
+// закроет источники сама после выхода из чтения.
+func WithCloseGracePeriod(d time.Duration) Option {
+	return func(m *MultiReader) {
+		m.closeGrace = d
+	}
+}
+
+// WithReplayProtection включает запись SHA-256 дайджестов по диапазонам размера rangeSize для всех байт,
+// отданных через Read. Контрольная точка (Checkpoint) позволяет возобновлённой передаче убедиться,
+// что уже отправленный префикс по-прежнему совпадает с источниками (см. ResumeFromCheckpoint).
+func WithReplayProtection(rangeSize int64) Option {
+	return func(m *MultiReader) {
+		if rangeSize > 0 {
+			m.replay = newReplayRecorder(rangeSize)
+		}
+	}
+}
+
+// WithTrace записывает в w трассу всех Read, Seek и ReadAt мультиридера: аргументы, число байт, их crc32
+// и класс ошибки. Трасса компактна (строка на операцию, без самих данных) и воспроизводится ReplayTrace
+// над любой реализацией - так сценарии порчи данных или зависаний из отчёта клиента повторяются офлайн.
+// Ошибка записи в w прекращает запись трассы и возвращается из Close.
+func WithTrace(w io.Writer) Option {
+	return func(m *MultiReader) {
+		m.trace = &traceRecorder{w: w}
+	}
+}
+
+// WithDigest подаёт все байты, отданные потребителю через Read, в хеш h. Результат доступен через Digest.
+func WithDigest(h hash.Hash) Option {
+	return func(m *MultiReader) {
+		m.digest = &streamDigest{h: h}
+	}
+}
+
+// WithHooks устанавливает перехватчики событий чтения, перемещения курсора и перехода между источниками.
+// nil - перехватчики не заданы.
+func WithHooks(h *Hooks) Option {
+	return func(m *MultiReader) {
+		if h == nil {
+			return
+		}
+		m.hooks = h
+		m.hookPos = &hookCursor{src: -1}
+	}
+}
+
+// WithParallelReads разрешает префетчеру держать в полёте до n чтений ReadAt по позиционным источникам
+// (файлы, ReaderAt, mmap), чтобы загрузить очередь NVMe-устройства. Блоки по-прежнему публикуются по порядку;
+// в памяти дополнительно находится до n блоков. Экспериментально.
+func WithParallelReads(n int) Option {
+	return func(m *MultiReader) {
+		m.parallelReads = n
+	}
+}
+
+// WithReadConcurrency держит в полёте до n чтений ReadAt внутри одного источника, реализующего io.ReaderAt
+// (удалённые объекты, ranged-источники Downloader), конвейеризуя запросы к источникам с большой задержкой.
+// В отличие от WithParallelReads распространяется на любой io.ReaderAt, а не только на файлы и mmap,
+// поэтому ReadAt источника обязан быть потокобезопасным, как того требует контракт io.ReaderAt.
+func WithReadConcurrency(n int) Option {
+	return func(m *MultiReader) {
+		m.parallelReads = n
+		m.readAtAny = true
+	}
+}
+
+// WithFadvise включает подсказки posix_fadvise для файловых источников: SEQUENTIAL при входе в файл,
+// WILLNEED на окно префетча перед каждым блоком и DONTNEED для уже отданных потребителю участков.
+// Ускоряет последовательное чтение с холодным кэшем; на платформах без posix_fadvise ничего не делает.
+func WithFadvise() Option {
+	return func(m *MultiReader) {
+		m.fadvise = true
+	}
+}
+
+// WithTee пишет в w все байты, отданные потребителю (см. NewTeeMultiReader).
+func WithTee(w io.Writer) Option {
+	return func(m *MultiReader) {
+		m.tee = &teeWriter{w: w}
+	}
+}
+
+// WithBlockCache включает LRU-кэш выровненных блоков потока с бюджетом budget байт. Кэш общий для префетчера,
+// ReadAt и участков Split: повторно прочитанные области не запрашиваются у источников. Блоки читаются в кэш
+// целиком через позиционное чтение, поэтому досрочное закрытие источников (WithEagerClose) с кэшем не работает.
+func WithBlockCache(budget int64) Option {
+	return func(m *MultiReader) {
+		if m.cache == nil {
+			m.cache = newBlockCache(budget)
+			return
+		}
+		m.cache.budget = budget
+	}
+}
+
+// WithEdgePrefetch сразу при создании читает в кэш блоков первые и последние n байт потока. Многие форматы
+// (zip, parquet, mp4) сначала читают футер, а затем переходят к началу - оба чтения попадут в кэш без обращения
+// к источникам. Без WithBlockCache кэш создаётся с бюджетом под эти области; заданный бюджет при необходимости
+// увеличивается, чтобы области поместились. Опцию нужно указывать после WithBlockCache и WithDiskCache.
+func WithEdgePrefetch(n int64) Option {
+	return func(m *MultiReader) {
+		blocks := m.edgeBlocks(n)
+		need := int64(len(blocks)) * bufferSize
+		if m.cache == nil {
+			m.cache = newBlockCache(need)
+		}
+		m.cache.budget = max(m.cache.budget, need)
+		m.warmEdges(blocks)
+	}
+}
+
+// WithDiskCache добавляет кэшу блоков второй уровень: вытесненные из памяти блоки сохраняются во временном файле
+// в каталоге dir ("" - системный) размером до budget байт, так что медленные удалённые источники читаются один раз,
+// даже если повторно посещаемые области не помещаются в память. Без WithBlockCache кэш в памяти не держит блоков
+// и все они сразу уходят на диск. Файл удаляется в Close.
+func WithDiskCache(dir string, budget int64) Option {
+	return func(m *MultiReader) {
+		if m.cache == nil {
+			m.cache = newBlockCache(0)
+		}
+		m.cache.disk = newDiskTier(dir, budget)
+	}
+}
+
+// WithDiskCacheCompression сжимает блоки, сбрасываемые на дисковый уровень кэша (WithDiskCache), тем же быстрым
+// deflate, что и WithWindowCompression: медленный или сетевой диск пишет и читает меньше байт ценой CPU на сжатие
+// при сбросе и разжатие при подъёме блока. Место в файле по-прежнему выделяется слотами по bufferSize, поэтому
+// бюджет диска вмещает столько же блоков. Объём сброса и экономия - Stats.SpilledBytes и Stats.SpillSaved.
+// Несжимаемые блоки пишутся как есть. Без WithDiskCache опция ни на что не влияет.
+func WithDiskCacheCompression() Option {
+	return func(m *MultiReader) {
+		m.diskCompress = true
+	}
+}
+
+// WithCoalescedReads склеивает чтения подряд идущих источников меньше блока в один блок окна: вместо блока
+// на каждый мелкий источник префетчер публикует полные блоки, что снижает накладные расходы для потоков из
+// тысяч маленьких частей. Источник, оказавшийся короче объявленного размера, даёт io.ErrUnexpectedEOF.
+// Досрочное закрытие (WithEagerClose) источников, пройденных таким чтением целиком, не выполняется.
+func WithCoalescedReads() Option {
+	return func(m *MultiReader) {
+		m.coalesceReads = true
+	}
+}
+
+// WithFinalizer закрывает мультиридер (и его источники), если он стал недостижим без вызова Close.
+// Работающий префетчер сам ссылается на мультиридер, поэтому страховка срабатывает только после его остановки:
+// мультиридер не читали, дочитали до конца или чтение завершилось ошибкой. Брошенный посреди потока мультиридер
+// по-прежнему нужно закрывать явно; найти такие поможет LivePrefetchers.
+func WithFinalizer() Option {
+	return func(m *MultiReader) {
+		runtime.SetFinalizer(m, func(m *MultiReader) { _ = m.Close() })
+	}
+}
+
+// WithPrefetch включает или выключает фоновый префетч. С WithPrefetch(false) мультиридер работает синхронно,
+// как easy-вариант: Read читает из источников прямо в буфер вызывающего ровно столько, сколько запрошено,
+// без горутины и окна блоков. ReadAt, Seek, Close и кэш блоков работают как обычно.
+func WithPrefetch(enabled bool) Option {
+	return func(m *MultiReader) {
+		m.noPrefetch = !enabled
+	}
+}
+
+// WithEOFStyle задаёт, как Read сообщает о конце потока (см. EOFStyle). Остальные методы чтения не меняются.
+func WithEOFStyle(style EOFStyle) Option {
+	return func(m *MultiReader) {
+		m.eofStyle = style
+	}
+}
+
+// WithMaxEmptyReads задаёт, сколько чтений (0, nil) подряд из источника допускается, прежде чем Read вернёт
+// io.ErrNoProgress. Без опции - defaultMaxEmptyReads; n <= 0 оставляет значение по умолчанию.
+func WithMaxEmptyReads(n int) Option {
+	return func(m *MultiReader) {
+		if n > 0 {
+			m.maxEmptyReads = n
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// Patch - заплатка: байты Data, подменяющие содержимое базового потока начиная с Offset.
+type Patch struct {
+	Offset int64  // абсолютная позиция начала заплатки
+	Data   []byte // подменяющие данные
+}
+
+// Overlay накладывает набор заплаток поверх базового потока, не изменяя сами источники (copy-on-write представление).
+type Overlay struct {
+	base    SizedReadSeekCloser // базовый поток
+	patches []Patch             // заплатки; при пересечении побеждает более поздняя
+	pos     int64               // текущая позиция чтения
+}
+
+// Проверка, что Overlay удовлетворяет интерфейсу SizedReadSeekCloser
+var _ SizedReadSeekCloser = (*Overlay)(nil)
+
+// NewOverlay создаёт представление base с наложенными заплатками. Заплатка не может выходить за пределы base.
+func NewOverlay(base SizedReadSeekCloser, patches ...Patch) (*Overlay, error) {
+	size := base.Size()
+	for _, p := range patches {
+		if p.Offset < 0 || p.Offset+int64(len(p.Data)) > size {
+			return nil, fmt.Errorf("patch [%d, %d) is out of range [0, %d)", p.Offset, p.Offset+int64(len(p.Data)), size)
+		}
+	}
+
+	return &Overlay{
+		base:    base,
+		patches: patches,
+	}, nil
+}
+
+// Read читает данные базового потока и подменяет байты, попавшие в заплатки.
+func (o *Overlay) Read(p []byte) (int, error) {
+	n, err := o.base.Read(p)
+	o.apply(p[:n], o.pos)
+	o.pos += int64(n)
+	return n, err
+}
+
+// ReadAt читает данные с позиции off, если базовый поток поддерживает io.ReaderAt.
+func (o *Overlay) ReadAt(p []byte, off int64) (int, error) {
+	ra, ok := o.base.(io.ReaderAt)
+	if !ok {
+		return 0, fmt.Errorf("base reader %T does not support ReadAt", o.base)
+	}
+	n, err := ra.ReadAt(p, off)
+	o.apply(p[:n], off)
+	return n, err
+}
+
+// Seek перемещает курсор базового потока.
+func (o *Overlay) Seek(offset int64, whence int) (int64, error) {
+	pos, err := o.base.Seek(offset, whence)
+	if err != nil {
+		return pos, err
+	}
+	o.pos = pos
+	return pos, nil
+}
+
+// Close закрывает базовый поток.
+func (o *Overlay) Close() error {
+	return o.base.Close()
+}
+
+// Size возвращает размер базового потока - заплатки его не меняют.
+func (o *Overlay) Size() int64 {
+	return o.base.Size()
+}
+
+// apply копирует в p (данные с абсолютной позиции off) пересекающиеся с ним части заплаток.
+func (o *Overlay) apply(p []byte, off int64) {
+	end := off + int64(len(p))
+	for _, patch := range o.patches {
+		patchEnd := patch.Offset + int64(len(patch.Data))
+		from, to := max(off, patch.Offset), min(end, patchEnd)
+		if from >= to {
+			continue
+		}
+		copy(p[from-off:to-off], patch.Data[from-patch.Offset:to-patch.Offset])
+	}
+}
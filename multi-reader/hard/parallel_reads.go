@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// prefetchParallel читает участок [from, end) позиционного источника номер idx блоками по bufferSize,
+// держа в полёте до parallelReads запросов ReadAt, и публикует блоки в out строго по порядку.
+// Возвращает количество опубликованных байт; io.EOF - источник оказался короче объявленного размера.
+func (m *MultiReader) prefetchParallel(ctx context.Context, out chan<- []byte, ra io.ReaderAt, idx int, from, end int64) (int64, error) {
+	base := m.prefixes()[idx]
+	var workers sync.WaitGroup
+	defer workers.Wait() // Чтения в полёте не должны пережить префетчер: после него Close закрывает источники
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel() // Отменяет чтения, оставшиеся в полёте после ошибки
+
+	type result struct {
+		buf []byte
+		err error
+	}
+	var (
+		queue     []chan result // запущенные чтения в порядке блоков
+		next      = from        // начало следующего ещё не запущенного блока
+		published int64
+	)
+	for published < end-from {
+		for next < end && len(queue) < m.parallelReads {
+			off, size := next, min(end-next, bufferSize)
+			res := make(chan result, 1)
+			queue = append(queue, res)
+			next += size
+			workers.Add(1)
+			go func() {
+				defer workers.Done()
+				if ctx.Err() != nil {
+					res <- result{err: ctx.Err()}
+					return
+				}
+				buf := make([]byte, size)
+				start := time.Now()
+				var n int
+				err := guardSource(idx, func() (err error) {
+					n, err = readAtFull(ra, buf, off-base)
+					return err
+				})
+				m.noteSourceRead(idx, n, start)
+				res <- result{buf[:n], err}
+			}()
+		}
+
+		var r result
+		select {
+		case r = <-queue[0]:
+			queue = queue[1:]
+		case <-ctx.Done():
+			return published, ctx.Err()
+		}
+		if len(r.buf) > 0 {
+			if err := m.publishBlock(ctx, out, r.buf); err != nil {
+				return published, err
+			}
+			published += int64(len(r.buf))
+		}
+		if r.err != nil {
+			return published, r.err
+		}
+	}
+
+	return published, nil
+}
+
+// positionalSource - positionalReaderOf, который с WithReadConcurrency признаёт позиционным и любой источник,
+// реализующий io.ReaderAt: контракт io.ReaderAt допускает параллельные вызовы ReadAt.
+func (m *MultiReader) positionalSource(r SizedReadSeekCloser) (io.ReaderAt, bool) {
+	if ra, ok := positionalReaderOf(r); ok {
+		return ra, true
+	}
+	if m.readAtAny {
+		ra, ok := r.(io.ReaderAt)
+		return ra, ok
+	}
+	return nil, false
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// Peek возвращает следующие n байт из окна, не сдвигая курсор, - например, чтобы распознать
+// сигнатуру gzip/tar в текущей позиции. Если поток кончается раньше, возвращает оставшиеся байты и ошибку (io.EOF).
+// Срез указывает в окно: его нельзя изменять и рассчитывать на него после следующего вызова мультиридера.
+func (m *MultiReader) Peek(n int) ([]byte, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+
+	start := time.Now()
+	if _, err := m.beginRead(); err != nil {
+		return nil, err
+	}
+	defer m.inflight.Done()
+
+	for {
+		m.mu.Lock()
+		window := m.windowBuf
+		m.mu.Unlock()
+		if len(window) >= n {
+			return window[:n:n], nil
+		}
+
+		if err := m.fillWindow(start); err != nil {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			return m.windowBuf[:len(m.windowBuf):len(m.windowBuf)], err
+		}
+	}
+}
+
+// Discard пропускает n байт без копирования и возвращает, сколько пропущено. Внутри окна это сдвиг смещения,
+// дальше - перезапуск префетча с новой позиции. Если поток кончается раньше, возвращает io.EOF.
+// Пропущенные байты не считаются отданными: хуки и дайджесты их не видят.
+func (m *MultiReader) Discard(n int64) (int64, error) {
+	if n < 0 {
+		return 0, fmt.Errorf("invalid discard count: %d", n)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return 0, io.ErrClosedPipe
+	}
+	skip := min(n, m.size.Load()-m.absPos)
+	if _, err := m.seekLocked(skip, io.SeekCurrent); err != nil {
+		return 0, err
+	}
+	if skip < n {
+		return skip, io.EOF
+	}
+
+	return skip, nil
+}
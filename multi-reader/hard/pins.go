@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+)
+
+// pinnedRegion - закреплённая область потока, хранящаяся в памяти независимо от окна и кэша.
+type pinnedRegion struct {
+	off  int64  // абсолютное смещение начала
+	data []byte // содержимое области
+	refs int    // сколько раз область закреплена
+}
+
+// Pin читает область [offset, offset+length) и держит её в памяти, пока не будет вызван парный Unpin:
+// префетчер отдаёт её без обращения к источникам, сколько бы Seek ни было между чтениями. Подходит для
+// индексного футера в конце потока, который формат перечитывает после каждого перехода. Повторный Pin той же
+// области увеличивает счётчик закреплений.
+func (m *MultiReader) Pin(offset, length int64) error {
+	if size := m.size.Load(); offset < 0 || length <= 0 || offset+length > size {
+		return fmt.Errorf("pin range [%d, %d) should be non-empty and within totalSize (%d)", offset, offset+length, size)
+	}
+
+	m.pinMu.Lock()
+	for _, r := range m.pins {
+		if r.off == offset && int64(len(r.data)) == length {
+			r.refs++
+			m.pinMu.Unlock()
+			return nil
+		}
+	}
+	m.pinMu.Unlock()
+
+	data := make([]byte, length)
+	if _, err := m.ReadAt(data, offset); err != nil {
+		return err
+	}
+
+	m.pinMu.Lock()
+	defer m.pinMu.Unlock()
+	m.pins = append(m.pins, &pinnedRegion{off: offset, data: data, refs: 1})
+
+	return nil
+}
+
+// Unpin снимает закрепление области, заданной в Pin теми же offset и length. Память освобождается,
+// когда сняты все закрепления.
+func (m *MultiReader) Unpin(offset, length int64) error {
+	m.pinMu.Lock()
+	defer m.pinMu.Unlock()
+
+	for i, r := range m.pins {
+		if r.off == offset && int64(len(r.data)) == length {
+			r.refs--
+			if r.refs == 0 {
+				m.pins = slices.Delete(m.pins, i, i+1)
+			}
+			return nil
+		}
+	}
+
+	return fmt.Errorf("range [%d, %d) is not pinned", offset, offset+length)
+}
+
+// pinnedAt возвращает содержимое закреплённой области от позиции pos до её конца (nil - pos не закреплена).
+func (m *MultiReader) pinnedAt(pos int64) []byte {
+	m.pinMu.Lock()
+	defer m.pinMu.Unlock()
+
+	for _, r := range m.pins {
+		if r.off <= pos && pos < r.off+int64(len(r.data)) {
+			return r.data[pos-r.off:]
+		}
+	}
+
+	return nil
+}
+
+// pinLimit ограничивает чтение с позиции pos до начала ближайшей закреплённой области после неё,
+// чтобы префетчер не читал из источников то, что уже лежит в памяти.
+func (m *MultiReader) pinLimit(pos, end int64) int64 {
+	m.pinMu.Lock()
+	defer m.pinMu.Unlock()
+
+	for _, r := range m.pins {
+		if pos < r.off && r.off < end {
+			end = r.off
+		}
+	}
+
+	return end
+}
@@ -1,9 +1,39 @@
 package main
 
 import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/xml"
 	"errors"
+	"expvar"
+	"fmt"
 	"io"
+	"io/fs"
+	"math"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"testing/fstest"
+	"testing/iotest"
+	"time"
+	"unicode/utf8"
 )
 
 var privateTestCases = []TestCase{
@@ -272,16 +302,16 @@ var privateTestCases = []TestCase{
 	{
 		name: "Дальний Seek вперёд за окно и немедленный Read — новый Seek",
 		run: func() bool {
-			// С одним буфером окно = [bufferStart, bufferStart+bufferSize).
-			// Длина данных > bufferSize, поэтому Seek далеко вперёд выйдет за текущий буфер и потребует нового нижнего Seek.
+			// С одним буфером в памяти окно и не больше одного блока в очереди префетчера.
+			// Длина данных > 2*bufferSize, поэтому Seek далеко вперёд выйдет за них и потребует нового нижнего Seek.
 			var seeks int
-			r := newMockStringsReader(strings.Repeat("x", bufferSize+100))
+			r := newMockStringsReader(strings.Repeat("x", 2*bufferSize+100))
 			r.seekCalls = &seeks
 			m := NewMultiReader(1, r)
 			buf := make([]byte, 8)
 			_, _ = m.Read(buf) // прогреем окно, префетчер сделает первый Seek
 			before := seeks
-			if _, err := m.Seek(int64(bufferSize+50), io.SeekStart); err != nil {
+			if _, err := m.Seek(int64(2*bufferSize+50), io.SeekStart); err != nil {
 				return false
 			}
 			b2 := make([]byte, 1)
@@ -385,4 +415,4435 @@ var privateTestCases = []TestCase{
 			return true
 		},
 	},
+	{
+		name: "GeneratorSource: чтение, Seek и повторное чтение того же диапазона",
+		run: func() bool {
+			pattern := func(off int64, p []byte) (int, error) {
+				for i := range p {
+					p[i] = byte('a' + (off+int64(i))%26)
+				}
+				return len(p), nil
+			}
+			g := GeneratorSource(30, pattern)
+			m := NewMultiReader(2, NewStringReader("01"), g, ZeroSource(2))
+			if m.Size() != 34 {
+				return false
+			}
+
+			buf := make([]byte, 34)
+			n, err := io.ReadFull(m, buf)
+			if err != nil || n != 34 {
+				return false
+			}
+			expected := "01abcdefghijklmnopqrstuvwxyzabcd\x00\x00"
+			if string(buf) != expected {
+				return false
+			}
+
+			if _, err = m.Seek(27, io.SeekStart); err != nil {
+				return false
+			}
+			b := make([]byte, 4)
+			if n, err = m.Read(b); err != nil || n != 4 {
+				return false
+			}
+			return string(b) == expected[27:31]
+		},
+	},
+	{
+		name: "GeneratorSource: ошибка генератора пробрасывается в Read",
+		run: func() bool {
+			errGen := errors.New("gen")
+			g := GeneratorSource(10, func(off int64, p []byte) (int, error) {
+				if off >= 5 {
+					return 0, errGen
+				}
+				n := min(len(p), int(5-off))
+				return n, nil
+			})
+			m := NewMultiReader(2, g)
+			buf := make([]byte, 10)
+			n, err := io.ReadFull(m, buf)
+			return n == 5 && errors.Is(err, errGen)
+		},
+	},
+	{
+		name: "Разреженная раскладка: промежутки отдаются нулями",
+		run: func() bool {
+			m, err := NewMultiReaderWithLayout(2, []Entry{
+				{Offset: 6, Source: NewStringReader("cd")},
+				{Offset: 1, Source: NewStringReader("ab")},
+			})
+			if err != nil || m.Size() != 8 {
+				return false
+			}
+			buf := make([]byte, 8)
+			if n, err := io.ReadFull(m, buf); err != nil || n != 8 {
+				return false
+			}
+			return string(buf) == "\x00ab\x00\x00\x00cd"
+		},
+	},
+	{
+		name: "Разреженная раскладка: пересечение источников - ошибка",
+		run: func() bool {
+			_, err := NewMultiReaderWithLayout(2, []Entry{
+				{Offset: 0, Source: NewStringReader("abc")},
+				{Offset: 2, Source: NewStringReader("de")},
+			})
+			return err != nil
+		},
+	},
+	{
+		name: "Разреженная раскладка: отрицательное смещение - отдельная ошибка, а не пересечение",
+		run: func() bool {
+			_, err := NewMultiReaderWithLayout(2, []Entry{
+				{Offset: -3, Source: NewStringReader("abc")},
+				{Offset: 4, Source: NewStringReader("de")},
+			})
+			return err != nil && strings.Contains(err.Error(), "negative offset -3")
+		},
+	},
+	{
+		name: "Совместимость с io.MultiReader и bytes.Reader на случайных композициях",
+		run: func() bool {
+			rnd := rand.New(rand.NewSource(42))
+			for iter := 0; iter < 50; iter++ {
+				parts := make([]string, rnd.Intn(6)+1)
+				for i := range parts {
+					b := make([]byte, rnd.Intn(64)) // Пустые источники тоже допустимы
+					for j := range b {
+						b[j] = byte('a' + rnd.Intn(26))
+					}
+					parts[i] = string(b)
+				}
+				all := strings.Join(parts, "")
+
+				sources := make([]SizedReadSeekCloser, len(parts))
+				plain := make([]io.Reader, len(parts))
+				for i, s := range parts {
+					sources[i] = newMockStringsReader(s)
+					plain[i] = strings.NewReader(s)
+				}
+				m := NewMultiReader(rnd.Intn(3)+1, sources...)
+
+				// Полное последовательное чтение совпадает с io.MultiReader
+				got, err := io.ReadAll(m)
+				if err != nil {
+					return false
+				}
+				want, _ := io.ReadAll(io.MultiReader(plain...))
+				if !bytes.Equal(got, want) {
+					return false
+				}
+
+				// Случайные ReadAt совпадают с bytes.Reader над конкатенацией
+				ref := bytes.NewReader([]byte(all))
+				for k := 0; k < 20; k++ {
+					off := rnd.Int63n(int64(len(all)) + 2)
+					p1 := make([]byte, rnd.Intn(40)+1)
+					p2 := make([]byte, len(p1))
+					n1, err1 := m.ReadAt(p1, off)
+					n2, err2 := ref.ReadAt(p2, off)
+					if n1 != n2 || !bytes.Equal(p1[:n1], p2[:n2]) || (err1 == nil) != (err2 == nil) {
+						return false
+					}
+					if err2 != nil && !errors.Is(err1, err2) {
+						return false
+					}
+				}
+
+				// ReadAt не сдвигает курсор: поток по-прежнему на EOF
+				if n, err := m.Read(make([]byte, 1)); n != 0 || !errors.Is(err, io.EOF) {
+					return false
+				}
+				_ = m.Close()
+			}
+			return true
+		},
+	},
+	{
+		name: "ReadAt во время префетча не портит последовательное чтение",
+		run: func() bool {
+			a := newMockStringsReader(strings.Repeat("a", 1000))
+			b := newMockStringsReader(strings.Repeat("b", 1000))
+			m := NewMultiReader(1, a, b)
+			defer m.Close()
+
+			head := make([]byte, 10)
+			if _, err := io.ReadFull(m, head); err != nil {
+				return false
+			}
+			p := make([]byte, 4)
+			if n, err := m.ReadAt(p, 998); err != nil || n != 4 || string(p) != "aabb" {
+				return false
+			}
+			rest, err := io.ReadAll(m)
+			if err != nil {
+				return false
+			}
+			return string(head)+string(rest) == strings.Repeat("a", 1000)+strings.Repeat("b", 1000)
+		},
+	},
+	{
+		name: "Overlay: заплатки подменяют данные в Read, ReadAt и через границы ридеров",
+		run: func() bool {
+			m := NewMultiReader(2, NewStringReader("hello"), NewStringReader("world"))
+			o, err := NewOverlay(m,
+				Patch{Offset: 3, Data: []byte("LOW")},
+				Patch{Offset: 5, Data: []byte("#")}, // Более поздняя заплатка побеждает
+			)
+			if err != nil || o.Size() != 10 {
+				return false
+			}
+
+			got, err := io.ReadAll(o)
+			if err != nil || string(got) != "helLO#orld" {
+				return false
+			}
+
+			p := make([]byte, 4)
+			if n, err := o.ReadAt(p, 2); err != nil || n != 4 || string(p) != "lLO#" {
+				return false
+			}
+
+			if _, err = o.Seek(4, io.SeekStart); err != nil {
+				return false
+			}
+			b := make([]byte, 3)
+			if n, err := io.ReadFull(o, b); err != nil || n != 3 || string(b) != "O#o" {
+				return false
+			}
+			return o.Close() == nil
+		},
+	},
+	{
+		name: "Overlay: заплатка за пределами потока - ошибка",
+		run: func() bool {
+			m := NewMultiReader(2, NewStringReader("abc"))
+			_, err := NewOverlay(m, Patch{Offset: 2, Data: []byte("xy")})
+			return err != nil
+		},
+	},
+	{
+		name: "ReadRangesBestEffort: сбойный источник не мешает остальным диапазонам",
+		run: func() bool {
+			errBad := errors.New("bad sector")
+			a := newMockStringsReader("aaaa")
+			b := newMockStringsReader("bbbb")
+			c := newMockStringsReader("cccc")
+			b.readErr = errBad
+			m := NewMultiReader(2, a, b, c)
+
+			ranges := []Range{{Off: 0, Len: 3}, {Off: 2, Len: 4}, {Off: 9, Len: 3}, {Off: 11, Len: 5}}
+			if _, err := m.ReadRanges(ranges); !errors.Is(err, errBad) {
+				return false
+			}
+
+			res := m.ReadRangesBestEffort(ranges)
+			if res[0].Err != nil || string(res[0].Data) != "aaa" {
+				return false
+			}
+			var rangeErr *RangeError
+			if !errors.As(res[1].Err, &rangeErr) || !errors.Is(res[1].Err, errBad) {
+				return false
+			}
+			if rangeErr.Offset != 4 || rangeErr.Source != 1 || string(res[1].Data) != "aa" {
+				return false
+			}
+			if res[2].Err != nil || string(res[2].Data) != "ccc" {
+				return false
+			}
+			return errors.As(res[3].Err, &rangeErr) && rangeErr.Source == -1
+		},
+	},
+	{
+		name: "RepeatReader: конечный повтор через границы ридеров и Seek",
+		run: func() bool {
+			m := NewMultiReader(2, NewStringReader("ab"), NewStringReader("c"))
+			r := NewRepeatReader(m, 3)
+			if r.Size() != 9 {
+				return false
+			}
+			got, err := io.ReadAll(r)
+			if err != nil || string(got) != "abcabcabc" {
+				return false
+			}
+			if _, err = r.Seek(-4, io.SeekEnd); err != nil {
+				return false
+			}
+			got, err = io.ReadAll(r)
+			return err == nil && string(got) == "cabc"
+		},
+	},
+	{
+		name: "RepeatReader: бесконечный повтор имеет неизвестный размер",
+		run: func() bool {
+			r := NewRepeatReader(NewStringReader("xyz"), 0)
+			if r.Size() != UnknownSize {
+				return false
+			}
+			// Бесконечный повтор не годится в источник склейки
+			if _, err := NewMultiReaderE(2, []SizedReadSeekCloser{NewStringReader("a"), r}); !errors.Is(err, ErrInvalidSource) {
+				return false
+			}
+			if _, err := r.Seek(0, io.SeekEnd); !errors.Is(err, ErrUnknownSize) {
+				return false
+			}
+			buf := make([]byte, 10)
+			if n, err := io.ReadFull(r, buf); err != nil || n != 10 || string(buf) != "xyzxyzxyzx" {
+				return false
+			}
+			if _, err := r.Seek(3001, io.SeekStart); err != nil {
+				return false
+			}
+			b := make([]byte, 2)
+			n, err := io.ReadFull(r, b)
+			return err == nil && n == 2 && string(b) == "yz"
+		},
+	},
+	{
+		name: "WithColdStartTimeout: зависший первый блок даёт типизированную ошибку с индексом источника",
+		run: func() bool {
+			a := newMockStringsReader("abc")
+			b := newMockStringsReader("def")
+			b.readGate = make(chan struct{})
+			m := NewMultiReaderWithOptions(2, []SizedReadSeekCloser{a, b}, WithColdStartTimeout(20*time.Millisecond))
+			defer m.Close()
+
+			if _, err := m.Seek(4, io.SeekStart); err != nil {
+				return false
+			}
+			buf := make([]byte, 2)
+			n, err := m.Read(buf)
+			var coldErr *ColdStartTimeoutError
+			if n != 0 || !errors.Is(err, ErrColdStartTimeout) || !errors.As(err, &coldErr) || coldErr.Segment != 1 {
+				return false
+			}
+
+			close(b.readGate) // Источник «ожил» - следующее чтение получает данные
+			n, err = io.ReadFull(m, buf)
+			return err == nil && n == 2 && string(buf) == "ef"
+		},
+	},
+	{
+		name: "NewMultiReaderFromSeekers: размер определяется через Seek",
+		run: func() bool {
+			a := newMockStringsReader("abc")
+			b := newMockStringsReader("defg")
+			if _, err := b.Seek(2, io.SeekStart); err != nil { // Позиция источника не должна сбиться
+				return false
+			}
+			m, err := NewMultiReaderFromSeekers(2, a, b)
+			if err != nil || m.Size() != 7 {
+				return false
+			}
+			if pos, _ := b.Seek(0, io.SeekCurrent); pos != 2 {
+				return false
+			}
+			got, err := io.ReadAll(m)
+			return err == nil && string(got) == "abcdefg"
+		},
+	},
+	{
+		name: "ForwardReader: пайпы читаются только вперёд",
+		run: func() bool {
+			pr, pw := io.Pipe()
+			go func() {
+				_, _ = pw.Write([]byte("world"))
+				_ = pw.Close()
+			}()
+			f := NewForwardReader(strings.NewReader("hello "), pr)
+			if f.Size() != UnknownSize {
+				return false
+			}
+			if _, err := f.Seek(0, io.SeekEnd); !errors.Is(err, ErrUnknownSize) {
+				return false
+			}
+			if pos, err := f.Seek(2, io.SeekStart); err != nil || pos != 2 {
+				return false
+			}
+			if _, err := f.Seek(1, io.SeekStart); !errors.Is(err, ErrSeekBackward) {
+				return false
+			}
+			got, err := io.ReadAll(f)
+			if err != nil || string(got) != "llo world" {
+				return false
+			}
+			return f.Close() == nil
+		},
+	},
+	{
+		name: "NewMultiReaderLazy: источники открываются при входе курсора и закрываются после прочтения",
+		run: func() bool {
+			var opened []int
+			var sources []*mockStringsReader
+			spec := func(i int, s string) LazySpec {
+				return LazySpec{
+					Size: int64(len(s)),
+					Open: func(ctx context.Context) (SizedReadSeekCloser, error) {
+						opened = append(opened, i)
+						r := newMockStringsReader(s)
+						sources = append(sources, r)
+						return r, nil
+					},
+				}
+			}
+			m := NewMultiReaderLazy(context.Background(), 1, spec(0, "abc"), spec(1, "def"), spec(2, "ghi"))
+			if len(opened) != 0 || m.Size() != 9 {
+				return false
+			}
+
+			if _, err := m.Seek(4, io.SeekStart); err != nil {
+				return false
+			}
+			got, err := io.ReadAll(m)
+			if err != nil || string(got) != "efghi" {
+				return false
+			}
+			if len(opened) != 2 || opened[0] != 1 || opened[1] != 2 {
+				return false
+			}
+			for _, r := range sources {
+				if !r.closed {
+					return false
+				}
+			}
+
+			// Seek назад переоткрывает уже освобождённый источник
+			p := make([]byte, 2)
+			if n, err := m.ReadAt(p, 3); err != nil || n != 2 || string(p) != "de" {
+				return false
+			}
+			return len(opened) == 3 && m.Close() == nil
+		},
+	},
+	{
+		name: "NewMultiReaderLazy: ошибка фабрики возвращается из Read",
+		run: func() bool {
+			errOpen := errors.New("open")
+			m := NewMultiReaderLazy(context.Background(), 1, LazySpec{
+				Size: 3,
+				Open: func(ctx context.Context) (SizedReadSeekCloser, error) { return nil, errOpen },
+			})
+			_, err := m.Read(make([]byte, 3))
+			return errors.Is(err, errOpen)
+		},
+	},
+	{
+		name: "WithEagerClose: прочитанные источники закрываются до Close, Seek назад к ним - ошибка",
+		run: func() bool {
+			a := newMockStringsReader("abc")
+			b := newMockStringsReader("def")
+			c := newMockStringsReader("ghi")
+			m := NewMultiReaderWithOptions(1, []SizedReadSeekCloser{a, b, c}, WithEagerClose())
+
+			got, err := io.ReadAll(m)
+			if err != nil || string(got) != "abcdefghi" {
+				return false
+			}
+			if !a.closed || !b.closed || c.closed {
+				return false
+			}
+
+			if _, err = m.Seek(1, io.SeekStart); err != nil {
+				return false
+			}
+			if _, err = m.Read(make([]byte, 1)); !errors.Is(err, ErrSourceClosed) {
+				return false
+			}
+			return m.Close() == nil && c.closed
+		},
+	},
+	{
+		name: "WithEagerClose: ленивые источники переоткрываются при Seek назад",
+		run: func() bool {
+			opens := 0
+			open := func(ctx context.Context) (SizedReadSeekCloser, error) {
+				opens++
+				return newMockStringsReader("xyz"), nil
+			}
+			lazy := LazySource(context.Background(), 3, open)
+			m := NewMultiReaderWithOptions(1, []SizedReadSeekCloser{lazy, NewStringReader("!")}, WithEagerClose())
+
+			got, err := io.ReadAll(m)
+			if err != nil || string(got) != "xyz!" {
+				return false
+			}
+			if _, err = m.Seek(0, io.SeekStart); err != nil {
+				return false
+			}
+			got, err = io.ReadAll(m)
+			return err == nil && string(got) == "xyz!" && opens == 2
+		},
+	},
+	{
+		name: "LatencyPolicy: сначала опрашивает все реплики, затем предпочитает самую быструю",
+		run: func() bool {
+			p := LatencyPolicy(0.5, time.Second)
+			if order := p.Order(0, 3); order[0] != 0 || order[1] != 1 || order[2] != 2 {
+				return false
+			}
+
+			p.Observe(0, 0, 30*time.Millisecond, nil)
+			p.Observe(0, 1, 10*time.Millisecond, nil)
+			if order := p.Order(0, 3); order[0] != 2 { // Реплика 2 ещё не измерена
+				return false
+			}
+
+			p.Observe(0, 2, 0, errors.New("timeout")) // Ошибка засчитывается штрафной задержкой
+			order := p.Order(0, 3)
+			if order[0] != 1 || order[1] != 0 || order[2] != 2 {
+				return false
+			}
+
+			// Статистика ведётся по сегментам независимо
+			other := p.Order(1, 2)
+			return other[0] == 0 && other[1] == 1
+		},
+	},
+	{
+		name: "LocalityPolicy и RandomPolicy возвращают перестановку реплик",
+		run: func() bool {
+			if order := LocalityPolicy().Order(5, 3); order[0] != 0 || order[1] != 1 || order[2] != 2 {
+				return false
+			}
+			order := RandomPolicy(1).Order(0, 4)
+			seen := make(map[int]bool)
+			for _, i := range order {
+				seen[i] = true
+			}
+			return len(order) == 4 && len(seen) == 4
+		},
+	},
+	{
+		name: "WithConsistencyToken: токен доходит до обычных и ленивых источников",
+		run: func() bool {
+			a := newMockStringsReader("ab")
+			var ctxToken string
+			var opened *mockStringsReader
+			lazy := LazySource(context.Background(), 2, func(ctx context.Context) (SizedReadSeekCloser, error) {
+				ctxToken, _ = ConsistencyTokenFromContext(ctx)
+				opened = newMockStringsReader("cd")
+				return opened, nil
+			})
+			m := NewMultiReaderWithOptions(1, []SizedReadSeekCloser{a, lazy}, WithConsistencyToken("snap-42"))
+			if m.ConsistencyToken() != "snap-42" || a.token != "snap-42" {
+				return false
+			}
+			got, err := io.ReadAll(m)
+			if err != nil || string(got) != "abcd" {
+				return false
+			}
+			return ctxToken == "snap-42" && opened.token == "snap-42"
+		},
+	},
+	{
+		name: "WithoutClosingSources: Close останавливает мультиридер, но не закрывает источники",
+		run: func() bool {
+			a := newMockStringsReader("abc")
+			b := newMockStringsReader("def")
+			b.closeErr = errors.New("must not be called")
+			m := NewMultiReaderWithOptions(1, []SizedReadSeekCloser{a, b}, WithoutClosingSources(), WithEagerClose())
+
+			got, err := io.ReadAll(m)
+			if err != nil || string(got) != "abcdef" {
+				return false
+			}
+			if err = m.Close(); err != nil || a.closed || b.closed {
+				return false
+			}
+			if _, err = m.Read(make([]byte, 1)); !errors.Is(err, io.ErrClosedPipe) {
+				return false
+			}
+
+			// Источники можно использовать повторно в другом мультиридере
+			m2 := NewMultiReader(1, a, b)
+			got, err = io.ReadAll(m2)
+			return err == nil && string(got) == "abcdef"
+		},
+	},
+	{
+		name: "Shutdown: начатый Read дочитывается, новые Read отклоняются",
+		run: func() bool {
+			a := newMockStringsReader("abc")
+			b := newMockStringsReader("def")
+			b.readGate = make(chan struct{})
+			m := NewMultiReader(1, a, b)
+
+			type result struct {
+				n   int
+				err error
+			}
+			readDone := make(chan result, 1)
+			go func() {
+				buf := make([]byte, 6)
+				n, err := io.ReadFull(m, buf) // Застрянет на источнике b
+				readDone <- result{n, err}
+			}()
+			readStarted := func() bool {
+				m.mu.Lock()
+				defer m.mu.Unlock()
+				return m.absPos > 0
+			}
+			for !readStarted() { // Ждём, пока Read получит первые байты
+				time.Sleep(time.Millisecond)
+			}
+
+			shutdownDone := make(chan error, 1)
+			go func() { shutdownDone <- m.Shutdown(context.Background()) }()
+			time.Sleep(10 * time.Millisecond)
+			if _, err := m.Read(make([]byte, 1)); !errors.Is(err, io.ErrClosedPipe) {
+				return false
+			}
+
+			close(b.readGate)
+			res := <-readDone
+			if res.err != nil || res.n != 6 {
+				return false
+			}
+			return <-shutdownDone == nil && a.closed && b.closed
+		},
+	},
+	{
+		name: "Shutdown: по истечении ctx зависший Read прерывается",
+		run: func() bool {
+			a := newMockStringsReader("abc")
+			a.readGate = make(chan struct{})
+			m := NewMultiReader(1, a)
+
+			readDone := make(chan struct{})
+			go func() {
+				_, _ = m.Read(make([]byte, 3))
+				close(readDone)
+			}()
+			time.Sleep(10 * time.Millisecond)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+			go func() {
+				time.Sleep(50 * time.Millisecond)
+				close(a.readGate) // Источник «отвисает» уже после дедлайна
+			}()
+			err := m.Shutdown(ctx)
+			<-readDone
+			return errors.Is(err, context.DeadlineExceeded) && a.closed
+		},
+	},
+	{
+		name: "WithCloseConcurrency: источники закрываются параллельно, ошибки агрегируются",
+		run: func() bool {
+			errB := errors.New("B")
+			sources := make([]SizedReadSeekCloser, 8)
+			mocks := make([]*mockStringsReader, 8)
+			for i := range sources {
+				mocks[i] = newMockStringsReader("x")
+				mocks[i].closeWait = 50 * time.Millisecond
+				sources[i] = mocks[i]
+			}
+			mocks[1].closeErr = errB
+			m := NewMultiReaderWithOptions(1, sources, WithCloseConcurrency(8))
+
+			start := time.Now()
+			err := m.Close()
+			if time.Since(start) > 300*time.Millisecond || !errors.Is(err, errB) {
+				return false
+			}
+			for _, r := range mocks {
+				if !r.closed {
+					return false
+				}
+			}
+			return true
+		},
+	},
+	{
+		name: "CloseContext: зависшее закрытие источника бросается по истечении ctx",
+		run: func() bool {
+			a := newMockStringsReader("a")
+			a.closeWait = time.Second
+			m := NewMultiReader(1, a)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+			start := time.Now()
+			err := m.CloseContext(ctx)
+			if !errors.Is(err, context.DeadlineExceeded) || time.Since(start) > 500*time.Millisecond {
+				return false
+			}
+			return m.Close() == nil // Повторный Close идемпотентен
+		},
+	},
+	{
+		name: "WithCloseOrder(CloseReverse) и OnSourceClose: обратный порядок и видимость ошибок",
+		run: func() bool {
+			errB := errors.New("B")
+			a := newMockStringsReader("a")
+			b := newMockStringsReader("b")
+			c := newMockStringsReader("c")
+			b.closeErr = errB
+
+			var order []int
+			var failed []int
+			hook := func(i int, err error) {
+				order = append(order, i)
+				if err != nil {
+					failed = append(failed, i)
+				}
+			}
+			m := NewMultiReaderWithOptions(1, []SizedReadSeekCloser{a, b, c}, WithCloseOrder(CloseReverse), OnSourceClose(hook))
+
+			if err := m.Close(); !errors.Is(err, errB) {
+				return false
+			}
+			return len(order) == 3 && order[0] == 2 && order[1] == 1 && order[2] == 0 &&
+				len(failed) == 1 && failed[0] == 1
+		},
+	},
+	{
+		name: "ServeMultiReader: файловые и обычные сегменты отдаются в HTTP-ответ целиком",
+		run: func() bool {
+			dir, err := os.MkdirTemp("", "multireader")
+			if err != nil {
+				return false
+			}
+			defer os.RemoveAll(dir)
+			path := filepath.Join(dir, "part1")
+			if err = os.WriteFile(path, []byte(strings.Repeat("F", 5000)), 0o600); err != nil {
+				return false
+			}
+			file, err := OpenFileSource(path)
+			if err != nil {
+				return false
+			}
+			m := NewMultiReader(2, NewStringReader("head-"), file, NewStringReader("-tail"))
+			defer m.Close()
+			expected := "head-" + strings.Repeat("F", 5000) + "-tail"
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ServeMultiReader(w, r, m)
+			}))
+			defer srv.Close()
+
+			resp, err := http.Get(srv.URL)
+			if err != nil {
+				return false
+			}
+			body, err := io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+			if err != nil || string(body) != expected || resp.ContentLength != int64(len(expected)) {
+				return false
+			}
+
+			// Запись без io.ReaderFrom (httptest.ResponseRecorder) идёт через запасной путь
+			rec := httptest.NewRecorder()
+			ServeMultiReader(rec, httptest.NewRequest(http.MethodGet, "/", nil), m)
+			if rec.Body.String() != expected {
+				return false
+			}
+
+			// Курсор мультиридера не сдвинулся
+			got, err := io.ReadAll(m)
+			return err == nil && string(got) == expected
+		},
+	},
+	{
+		name: "Close прерывает зависшее чтение источника с ReadContext",
+		run: func() bool {
+			a := ctxMockReader{newMockStringsReader("abc")}
+			a.readGate = make(chan struct{}) // Никогда не откроется
+			m := NewMultiReader(1, a)
+
+			readDone := make(chan error, 1)
+			go func() {
+				_, err := m.Read(make([]byte, 3))
+				readDone <- err
+			}()
+			time.Sleep(10 * time.Millisecond)
+
+			if err := m.Close(); err != nil {
+				return false
+			}
+			<-readDone
+			return a.closed
+		},
+	},
+	{
+		name: "WithCloseGracePeriod: Close не ждёт зависший префетчер дольше периода",
+		run: func() bool {
+			a := newMockStringsReader("abc")
+			a.readGate = make(chan struct{})
+			closedHook := make(chan struct{})
+			m := NewMultiReaderWithOptions(1, []SizedReadSeekCloser{a},
+				WithCloseGracePeriod(20*time.Millisecond),
+				OnSourceClose(func(int, error) { close(closedHook) }),
+			)
+
+			readDone := make(chan struct{})
+			go func() {
+				_, _ = m.Read(make([]byte, 3))
+				close(readDone)
+			}()
+			time.Sleep(10 * time.Millisecond)
+
+			start := time.Now()
+			if err := m.Close(); !errors.Is(err, ErrCloseTimeout) || time.Since(start) > 500*time.Millisecond {
+				return false
+			}
+			<-readDone // Read разблокирован отменой префетча
+
+			close(a.readGate) // Источник «отвис» - брошенная горутина закрывает источники сама
+			select {
+			case <-closedHook:
+				return true
+			case <-time.After(time.Second):
+				return false
+			}
+		},
+	},
+	{
+		name: "WithReplayProtection: возобновление проверяет отданный префикс",
+		run: func() bool {
+			m := NewMultiReaderWithOptions(1, []SizedReadSeekCloser{
+				NewStringReader("hello "), NewStringReader("world!"),
+			}, WithReplayProtection(4))
+			buf := make([]byte, 10)
+			if _, err := io.ReadFull(m, buf); err != nil {
+				return false
+			}
+			cp, err := m.Checkpoint()
+			if err != nil || cp.Offset != 8 || len(cp.Digests) != 2 {
+				return false
+			}
+			_ = m.Close()
+
+			// Источники не изменились - продолжаем с cp.Offset
+			resumed := NewMultiReaderWithOptions(1, []SizedReadSeekCloser{
+				NewStringReader("hello "), NewStringReader("world!"),
+			}, WithReplayProtection(4))
+			if err = resumed.ResumeFromCheckpoint(cp); err != nil {
+				return false
+			}
+			rest, err := io.ReadAll(resumed)
+			if err != nil || string(rest) != "rld!" {
+				return false
+			}
+			if cp2, _ := resumed.Checkpoint(); cp2.Offset != 12 || !bytes.Equal(cp2.Digests[0], cp.Digests[0]) {
+				return false
+			}
+
+			// Источник изменился во втором диапазоне - типизированная ошибка
+			changed := NewMultiReader(1, NewStringReader("hello "), NewStringReader("World!"))
+			err = changed.ResumeFromCheckpoint(cp)
+			var mismatch *ReplayMismatchError
+			return errors.Is(err, ErrSourceChanged) && errors.As(err, &mismatch) && mismatch.Range == 1 && mismatch.Offset == 4
+		},
+	},
+	{
+		name: "Детерминированно: Seek за окно во время публикации блока отбрасывает устаревший блок",
+		run: func() bool {
+			r := newMockStringsReader(strings.Repeat("a", bufferSize) + strings.Repeat("b", bufferSize))
+			m := NewMultiReader(1, r)
+			sched := newStepScheduler(schedBeforePublish)
+			m.sched = sched
+			defer m.Close()
+
+			type result struct {
+				data string
+				err  error
+			}
+			read := func() chan result {
+				ch := make(chan result, 1)
+				go func() {
+					b := make([]byte, 1)
+					n, err := m.Read(b)
+					ch <- result{string(b[:n]), err}
+				}()
+				return ch
+			}
+
+			first := read()
+			if !sched.await(schedBeforePublish) { // Первый блок прочитан и ждёт публикации
+				return false
+			}
+			sched.step()
+			if res := <-first; res.err != nil || res.data != "a" {
+				return false
+			}
+			if !sched.await(schedBeforePublish) { // Второй блок прочитан, префетчер остановлен перед публикацией
+				return false
+			}
+
+			// Seek за пределы окна, пока блок не опубликован: префетчер отменяется и блок не попадает в окно
+			if _, err := m.Seek(int64(bufferSize+5), io.SeekStart); err != nil {
+				return false
+			}
+			second := read()
+			if !sched.await(schedBeforePublish) { // Новый префетчер читает с новой позиции
+				return false
+			}
+			sched.step()
+			res := <-second
+			if res.err != nil || res.data != "b" {
+				return false
+			}
+			pos, err := m.Seek(0, io.SeekCurrent)
+			return err == nil && pos == int64(bufferSize+6)
+		},
+	},
+	{
+		name: "Детерминированно: Close во время отправки EOF не теряет закрытие источников",
+		run: func() bool {
+			r := newMockStringsReader("abc")
+			m := NewMultiReader(1, r)
+			sched := newStepScheduler(schedBeforePublish, schedBeforeEOF)
+			m.sched = sched
+
+			done := make(chan string, 1)
+			go func() {
+				b := make([]byte, 3)
+				n, _ := m.Read(b)
+				done <- string(b[:n])
+			}()
+			if !sched.await(schedBeforePublish) {
+				return false
+			}
+			sched.step()
+			if <-done != "abc" {
+				return false
+			}
+			if !sched.await(schedBeforeEOF) { // Префетчер вот-вот отправит EOF
+				return false
+			}
+
+			if err := m.Close(); err != nil || !r.closed {
+				return false
+			}
+			_, err := m.Read(make([]byte, 1))
+			return errors.Is(err, io.ErrClosedPipe)
+		},
+	},
+	{
+		name: "Реплики: при сбое основной реплики чтение продолжается из запасной",
+		run: func() bool {
+			errDown := errors.New("primary down")
+			primary := newMockStringsReader("hello")
+			primary.readErr = errDown
+			mirror := newMockStringsReader("hello")
+			m, err := NewMultiReaderWithReplicas(1, nil,
+				[]SizedReadSeekCloser{primary, mirror},
+				[]SizedReadSeekCloser{NewStringReader(" world")},
+			)
+			if err != nil {
+				return false
+			}
+			got, err := io.ReadAll(m)
+			if err != nil || string(got) != "hello world" {
+				return false
+			}
+			return m.Close() == nil && primary.closed && mirror.closed
+		},
+	},
+	{
+		name: "Реплики: отказ всех реплик возвращает ошибку, разный размер - ошибка конструктора",
+		run: func() bool {
+			errA, errB := errors.New("A"), errors.New("B")
+			a := newMockStringsReader("xy")
+			b := newMockStringsReader("xy")
+			a.readErr, b.readErr = errA, errB
+			m, err := NewMultiReaderWithReplicas(1, LatencyPolicy(0.5, time.Second), []SizedReadSeekCloser{a, b})
+			if err != nil {
+				return false
+			}
+			if _, err = m.Read(make([]byte, 2)); !errors.Is(err, errA) || !errors.Is(err, errB) {
+				return false
+			}
+			_, err = ReplicaSource(nil, 0, NewStringReader("a"), NewStringReader("ab"))
+			return err != nil
+		},
+	},
+	{
+		name: "Downloader: параллельное скачивание частей с повторами и прогрессом",
+		run: func() bool {
+			object := []byte(strings.Repeat("0123456789", 100))
+			var mu sync.Mutex
+			failures := map[int64]int{300: 2, 700: 1} // Части с этих смещений падают заданное число раз
+			open := func(ctx context.Context, off, length int64) (io.ReadCloser, error) {
+				mu.Lock()
+				defer mu.Unlock()
+				if failures[off] > 0 {
+					failures[off]--
+					return nil, errors.New("503 slow down")
+				}
+				return io.NopCloser(bytes.NewReader(object[off : off+length])), nil
+			}
+			var retries atomic.Int32
+			var lastDone atomic.Int64
+			d := &Downloader{
+				Open:        open,
+				Size:        int64(len(object)),
+				PartSize:    100,
+				Concurrency: 3,
+				Retries:     2,
+				OnRetry:     func(part, attempt int, err error) { retries.Add(1) },
+				OnProgress:  func(done, total int64) { lastDone.Store(done) },
+			}
+
+			out := make([]byte, len(object))
+			if err := d.DownloadTo(context.Background(), &sliceWriterAt{buf: out}); err != nil {
+				return false
+			}
+			if !bytes.Equal(out, object) || retries.Load() != 3 || lastDone.Load() != int64(len(object)) {
+				return false
+			}
+
+			var seq bytes.Buffer
+			if n, err := d.Download(context.Background(), &seq); err != nil || n != int64(len(object)) {
+				return false
+			}
+			if !bytes.Equal(seq.Bytes(), object) {
+				return false
+			}
+
+			m := d.NewReader(context.Background(), 2)
+			defer m.Close()
+			p := make([]byte, 10)
+			n, err := m.ReadAt(p, 295)
+			return err == nil && n == 10 && string(p) == "5678901234"
+		},
+	},
+	{
+		name: "Downloader: исчерпание повторов возвращает ошибку части",
+		run: func() bool {
+			errGone := errors.New("gone")
+			d := &Downloader{
+				Open: func(ctx context.Context, off, length int64) (io.ReadCloser, error) {
+					if off >= 10 {
+						return nil, errGone
+					}
+					return io.NopCloser(strings.NewReader(strings.Repeat("x", int(length)))), nil
+				},
+				Size:     20,
+				PartSize: 10,
+				Retries:  1,
+			}
+			_, err := d.Download(context.Background(), io.Discard)
+			return errors.Is(err, errGone)
+		},
+	},
+	{
+		name: "WithDigest: хеш отданных байт совпадает с хешем конкатенации",
+		run: func() bool {
+			data := strings.Repeat("abc", 1000) + strings.Repeat("xyz", 500)
+			m := NewMultiReaderWithOptions(2, []SizedReadSeekCloser{
+				NewStringReader(data[:3000]), NewStringReader(data[3000:]),
+			}, WithDigest(sha256.New()))
+
+			if _, err := io.Copy(io.Discard, m); err != nil {
+				return false
+			}
+			sum, n, err := m.Digest()
+			want := sha256.Sum256([]byte(data))
+			if err != nil || n != int64(len(data)) || !bytes.Equal(sum, want[:]) {
+				return false
+			}
+			_, _, err = NewMultiReader(1).Digest()
+			return err != nil
+		},
+	},
+	{
+		name: "WithHooks: OnRead, OnSeek и OnSourceSwitch видят чтения, перемещения и границы источников",
+		run: func() bool {
+			var (
+				switches [][2]int
+				seeks    [][2]int64
+				readN    int
+			)
+			h := &Hooks{
+				OnRead: func(_ int64, data []byte, _ error) {
+					readN += len(data)
+					for i := range data { // Модификация отдаваемых данных
+						if data[i] == 'b' {
+							data[i] = 'B'
+						}
+					}
+				},
+				OnSeek:         func(from, to int64) { seeks = append(seeks, [2]int64{from, to}) },
+				OnSourceSwitch: func(from, to int) { switches = append(switches, [2]int{from, to}) },
+			}
+			m := NewMultiReaderWithOptions(2, []SizedReadSeekCloser{
+				NewStringReader("aaa"), NewStringReader(""), NewStringReader("bbb"), NewStringReader("ccc"),
+			}, WithHooks(h))
+			defer m.Close()
+
+			got, err := io.ReadAll(m)
+			if err != nil || string(got) != "aaaBBBccc" || readN != 9 {
+				return false
+			}
+			if _, err := m.Seek(1, io.SeekStart); err != nil {
+				return false
+			}
+			buf := make([]byte, 1)
+			if _, err := m.Read(buf); err != nil {
+				return false
+			}
+
+			wantSwitches := [][2]int{{-1, 0}, {0, 2}, {2, 3}, {3, 0}}
+			if len(switches) != len(wantSwitches) {
+				return false
+			}
+			for i := range wantSwitches {
+				if switches[i] != wantSwitches[i] {
+					return false
+				}
+			}
+			return len(seeks) == 1 && seeks[0] == [2]int64{9, 1}
+		},
+	},
+	{
+		name: "TransformSource: префиксные суммы считаются по логическому размеру после преобразования",
+		run: func() bool {
+			stripHeader := func(raw SizedReadSeekCloser) (io.ReadSeeker, error) {
+				ra, ok := raw.(io.ReaderAt)
+				if !ok {
+					return nil, errors.New("raw source is not io.ReaderAt")
+				}
+				return io.NewSectionReader(ra, 4, raw.Size()-4), nil
+			}
+			first, second := newMockStringsReader("HDR:hello, "), newMockStringsReader("HDR:world")
+			m := NewMultiReader(2,
+				TransformSource(first, 7, stripHeader),
+				TransformSource(second, 5, stripHeader),
+			)
+			if m.Size() != 12 {
+				return false
+			}
+
+			got, err := io.ReadAll(m)
+			if err != nil || string(got) != "hello, world" {
+				return false
+			}
+			if _, err := m.Seek(7, io.SeekStart); err != nil {
+				return false
+			}
+			tail, err := io.ReadAll(m)
+			if err != nil || string(tail) != "world" {
+				return false
+			}
+
+			short := NewMultiReader(1, TransformSource(NewStringReader("HDR:ab"), 5, stripHeader))
+			defer short.Close()
+			_, err = io.ReadAll(short)
+			return errors.Is(err, io.ErrUnexpectedEOF) && m.Close() == nil && first.closed && second.closed
+		},
+	},
+	{
+		name: "GzipSource: конкатенация сжатых сегментов с Seek по индексу блоков",
+		run: func() bool {
+			compress := func(blocks ...string) *mockStringsReader {
+				var buf bytes.Buffer
+				for _, b := range blocks {
+					zw := gzip.NewWriter(&buf)
+					_, _ = zw.Write([]byte(b))
+					_ = zw.Close()
+				}
+				return newMockStringsReader(buf.String())
+			}
+			segments := []*mockStringsReader{
+				compress("line 1\n", "line 2\n", "line 3\n"),
+				compress(strings.Repeat("x", 5000), "tail\n"),
+			}
+			want := "line 1\nline 2\nline 3\n" + strings.Repeat("x", 5000) + "tail\n"
+
+			readers := make([]SizedReadSeekCloser, len(segments))
+			for i, seg := range segments {
+				idx, err := BuildGzipIndex(seg)
+				if err != nil || (i == 0 && len(idx.Blocks) != 3) {
+					return false
+				}
+				readers[i] = GzipSource(seg, idx)
+			}
+			m := NewMultiReader(2, readers...)
+			defer m.Close()
+			if m.Size() != int64(len(want)) {
+				return false
+			}
+
+			got, err := io.ReadAll(m)
+			if err != nil || string(got) != want {
+				return false
+			}
+			for _, off := range []int64{14, 3, 21, 5020, 0} {
+				if _, err := m.Seek(off, io.SeekStart); err != nil {
+					return false
+				}
+				buf := make([]byte, 6)
+				if _, err := io.ReadFull(m, buf); err != nil || string(buf) != want[off:off+6] {
+					return false
+				}
+			}
+			return true
+		},
+	},
+	{
+		name: "AESCTRSource: расшифровка зашифрованных чанков как одного потока с Seek",
+		run: func() bool {
+			key := bytes.Repeat([]byte{7}, 32)
+			encrypt := func(plain string, iv []byte) *mockStringsReader {
+				block, _ := aes.NewCipher(key)
+				out := make([]byte, len(plain))
+				cipher.NewCTR(block, iv).XORKeyStream(out, []byte(plain))
+				return newMockStringsReader(string(out))
+			}
+			ivA := bytes.Repeat([]byte{0xff}, aes.BlockSize) // Переполнение счётчика на первом же блоке
+			ivB := append(bytes.Repeat([]byte{0}, aes.BlockSize-1), 0xfe)
+			plainA, plainB := strings.Repeat("secret chunk A. ", 100), strings.Repeat("chunk B! ", 77)
+
+			srcA, err := AESCTRSource(encrypt(plainA, ivA), key, ivA)
+			if err != nil {
+				return false
+			}
+			srcB, err := AESCTRSource(encrypt(plainB, ivB), key, ivB)
+			if err != nil {
+				return false
+			}
+			m := NewMultiReader(2, srcA, srcB)
+			defer m.Close()
+
+			want := plainA + plainB
+			got, err := io.ReadAll(m)
+			if err != nil || string(got) != want {
+				return false
+			}
+			for _, off := range []int64{17, 1, 1599, 1600, 2000, 33} {
+				if _, err := m.Seek(off, io.SeekStart); err != nil {
+					return false
+				}
+				buf := make([]byte, 40)
+				if _, err := io.ReadFull(m, buf); err != nil || string(buf) != want[off:off+40] {
+					return false
+				}
+			}
+
+			_, err = AESCTRSource(NewStringReader(""), key, []byte("short"))
+			return err != nil
+		},
+	},
+	{
+		name: "ZipEntrySource/TarEntrySource: виртуальный файл из членов архивов с Seek",
+		run: func() bool {
+			var zipBuf bytes.Buffer
+			zw := zip.NewWriter(&zipBuf)
+			for _, e := range []struct {
+				name   string
+				method uint16
+				body   string
+			}{{"stored.txt", zip.Store, "stored data|"}, {"deflated.txt", zip.Deflate, strings.Repeat("deflated|", 300)}} {
+				w, err := zw.CreateHeader(&zip.FileHeader{Name: e.name, Method: e.method})
+				if err != nil {
+					return false
+				}
+				_, _ = w.Write([]byte(e.body))
+			}
+			_ = zw.Close()
+
+			var tarBuf bytes.Buffer
+			tw := tar.NewWriter(&tarBuf)
+			_ = tw.WriteHeader(&tar.Header{Name: "dir/", Typeflag: tar.TypeDir, Mode: 0o755})
+			for _, body := range []string{"tar one|", "tar two"} {
+				_ = tw.WriteHeader(&tar.Header{Name: body, Typeflag: tar.TypeReg, Size: int64(len(body)), Mode: 0o644})
+				_, _ = tw.Write([]byte(body))
+			}
+			_ = tw.Close()
+
+			zr, err := zip.NewReader(bytes.NewReader(zipBuf.Bytes()), int64(zipBuf.Len()))
+			if err != nil {
+				return false
+			}
+			var readers []SizedReadSeekCloser
+			for _, f := range zr.File {
+				src, err := ZipEntrySource(f)
+				if err != nil {
+					return false
+				}
+				readers = append(readers, src)
+			}
+			tarEntries, err := BuildTarIndex(bytes.NewReader(tarBuf.Bytes()))
+			if err != nil || len(tarEntries) != 2 {
+				return false
+			}
+			for _, e := range tarEntries {
+				readers = append(readers, TarEntrySource(bytes.NewReader(tarBuf.Bytes()), e))
+			}
+
+			m := NewMultiReader(2, readers...)
+			defer m.Close()
+			want := "stored data|" + strings.Repeat("deflated|", 300) + "tar one|tar two"
+			got, err := io.ReadAll(m)
+			if err != nil || string(got) != want {
+				return false
+			}
+			for _, off := range []int64{2000, 20, 2715, 3} { // Seek назад внутри сжатого члена требует переоткрытия
+				if _, err := m.Seek(off, io.SeekStart); err != nil {
+					return false
+				}
+				buf := make([]byte, 10)
+				if _, err := io.ReadFull(m, buf); err != nil || string(buf) != want[off:off+10] {
+					return false
+				}
+			}
+			return true
+		},
+	},
+	{
+		name: "NewMultiReaderFromDir/Glob/FS: сборка файла из частей",
+		run: func() bool {
+			dir, err := os.MkdirTemp("", "multireader")
+			if err != nil {
+				return false
+			}
+			defer os.RemoveAll(dir)
+
+			parts := []string{"alpha-", "beta-", "gamma"}
+			for i := len(parts) - 1; i >= 0; i-- { // Порядок создания не влияет на порядок чтения
+				if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("file.part%03d", i+1)), []byte(parts[i]), 0o600); err != nil {
+					return false
+				}
+			}
+			if err := os.WriteFile(filepath.Join(dir, "other.txt"), []byte("!"), 0o600); err != nil {
+				return false
+			}
+			if err := os.Mkdir(filepath.Join(dir, "sub"), 0o700); err != nil {
+				return false
+			}
+
+			readAll := func(m *MultiReader, err error) string {
+				if err != nil {
+					return "error: " + err.Error()
+				}
+				defer m.Close()
+				got, err := io.ReadAll(m)
+				if err != nil {
+					return "error: " + err.Error()
+				}
+				return string(got)
+			}
+
+			if got := readAll(NewMultiReaderFromGlob(0, filepath.Join(dir, "file.part*"))); got != "alpha-beta-gamma" {
+				return false
+			}
+			if got := readAll(NewMultiReaderFromDir(0, dir)); got != "alpha-beta-gamma!" {
+				return false
+			}
+			if got := readAll(NewMultiReaderFromFS(0, os.DirFS(dir), "other.txt", "file.part002")); got != "!beta-" {
+				return false
+			}
+			_, err = NewMultiReaderFromGlob(0, filepath.Join(dir, "missing*"))
+			return err != nil
+		},
+	},
+	{
+		name: "NewFSReader: файлы fs.FS с Seek и без него склеиваются с источниками на диске",
+		run: func() bool {
+			fsys := fstest.MapFS{
+				"assets/header.txt": {Data: []byte("<header>")},
+				"assets/footer.txt": {Data: []byte("<footer>")},
+			}
+			header, err := NewFSReader(fsys, "assets/header.txt") // fstest.MapFS отдаёт файлы с Seek
+			if err != nil {
+				return false
+			}
+			footer, err := NewFSReader(noSeekFS{fsys}, "assets/footer.txt")
+			if err != nil {
+				return false
+			}
+			m := NewMultiReader(2, header, NewStringReader("body"), footer)
+			defer m.Close()
+
+			got, err := io.ReadAll(m)
+			if err != nil || string(got) != "<header>body<footer>" {
+				return false
+			}
+			if _, err := m.Seek(13, io.SeekStart); err != nil { // Назад внутри файла без Seek - переоткрытие
+				return false
+			}
+			tail, err := io.ReadAll(m)
+			if err != nil || string(tail) != "footer>" {
+				return false
+			}
+			_, err = NewFSReader(fsys, "assets")
+			return err != nil
+		},
+	},
+	{
+		name: "NewBytesReader/NewStringReader: источники в памяти с размером, Seek и пустым Close",
+		run: func() bool {
+			data := []byte("bytes|")
+			m := NewMultiReader(2, NewBytesReader(data), NewStringReader("string"), NewBytesReader(nil))
+			if m.Size() != 12 {
+				return false
+			}
+			got, err := io.ReadAll(m)
+			if err != nil || string(got) != "bytes|string" || m.Close() != nil {
+				return false
+			}
+
+			src := NewStringReader("abc")
+			if _, err := src.Seek(-1, io.SeekEnd); err != nil {
+				return false
+			}
+			buf := make([]byte, 2)
+			n, _ := src.Read(buf)
+			return n == 1 && buf[0] == 'c' && src.Close() == nil && src.Size() == 3
+		},
+	},
+	{
+		name: "NewReaderAtSource: один ReaderAt разделяется несколькими мультиридерами без Seek",
+		run: func() bool {
+			data := strings.Repeat("0123456789", bufferSize/4)
+			shared := struct{ io.ReaderAt }{strings.NewReader(data)} // Только ReadAt: Seek недоступен
+			size := int64(len(data))
+
+			var wg sync.WaitGroup
+			results := make([]bool, 3)
+			for i := range results {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					m := NewMultiReader(2, NewReaderAtSource(shared, size), NewStringReader("|"), NewReaderAtSource(shared, size))
+					defer m.Close()
+					if _, err := m.Seek(int64(i*1000), io.SeekStart); err != nil {
+						return
+					}
+					got, err := io.ReadAll(m)
+					want := (data + "|" + data)[i*1000:]
+					results[i] = err == nil && string(got) == want
+				}()
+			}
+			wg.Wait()
+			for _, ok := range results {
+				if !ok {
+					return false
+				}
+			}
+
+			m := NewMultiReader(1, NewStringReader("ab"), NewReaderAtSource(shared, 4))
+			buf := make([]byte, 4)
+			n, err := m.ReadAt(buf, 1)
+			return err == nil && n == 4 && string(buf) == "b012"
+		},
+	},
+	{
+		name: "Файловый источник читается через pread: один дескриптор обслуживает несколько мультиридеров",
+		run: func() bool {
+			f, err := os.CreateTemp("", "multireader")
+			if err != nil {
+				return false
+			}
+			defer os.Remove(f.Name())
+			defer f.Close()
+			data := strings.Repeat("file-data;", bufferSize/5)
+			if _, err := f.WriteString(data); err != nil {
+				return false
+			}
+
+			src, err := NewFileSource(f) // Курсор файла стоит в конце после записи - pread его не использует
+			if err != nil {
+				return false
+			}
+			var wg sync.WaitGroup
+			var failed atomic.Bool
+			for i := range 4 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					m := NewMultiReaderWithOptions(2, []SizedReadSeekCloser{src}, WithoutClosingSources())
+					defer m.Close()
+					if _, err := m.Seek(int64(i*3), io.SeekStart); err != nil {
+						failed.Store(true)
+						return
+					}
+					got, err := io.ReadAll(m)
+					if err != nil || string(got) != data[i*3:] {
+						failed.Store(true)
+					}
+				}()
+			}
+			wg.Wait()
+			return !failed.Load()
+		},
+	},
+	{
+		name: "OpenMmapSource: блоки отдаются из отображения файла, после Close память не используется",
+		run: func() bool {
+			dir, err := os.MkdirTemp("", "multireader")
+			if err != nil {
+				return false
+			}
+			defer os.RemoveAll(dir)
+			data := strings.Repeat("mmap!", bufferSize/2)
+			path := filepath.Join(dir, "data.bin")
+			empty := filepath.Join(dir, "empty.bin")
+			if os.WriteFile(path, []byte(data), 0o600) != nil || os.WriteFile(empty, nil, 0o600) != nil {
+				return false
+			}
+
+			src, err := OpenMmapSource(path)
+			if err != nil {
+				return false
+			}
+			emptySrc, err := OpenMmapSource(empty)
+			if err != nil || emptySrc.Size() != 0 {
+				return false
+			}
+			m := NewMultiReader(2, src, emptySrc, NewStringReader("|tail"))
+
+			got, err := io.ReadAll(m)
+			if err != nil || string(got) != data+"|tail" {
+				return false
+			}
+			buf := make([]byte, 9)
+			if n, err := m.ReadAt(buf, int64(len(data))-4); err != nil || n != 9 || string(buf) != "map!|tail" {
+				return false
+			}
+			if _, err := m.Seek(3, io.SeekStart); err != nil {
+				return false
+			}
+			if _, err := io.ReadFull(m, buf[:4]); err != nil || string(buf[:4]) != "p!mm" {
+				return false
+			}
+			if m.Close() != nil {
+				return false
+			}
+			_, err = m.Read(buf)
+			return errors.Is(err, io.ErrClosedPipe)
+		},
+	},
+	{
+		name: "WithParallelReads: несколько ReadAt в полёте, блоки публикуются по порядку",
+		run: func() bool {
+			var data strings.Builder
+			for i := 0; data.Len() < 6*bufferSize+123; i++ {
+				fmt.Fprintf(&data, "%08d", i)
+			}
+			slow := &slowReaderAt{ra: strings.NewReader(data.String()), delay: 20 * time.Millisecond}
+			m := NewMultiReaderWithOptions(2, []SizedReadSeekCloser{
+				NewStringReader("head|"), NewReaderAtSource(slow, int64(data.Len())),
+			}, WithParallelReads(4))
+			defer m.Close()
+
+			got, err := io.ReadAll(m)
+			if err != nil || string(got) != "head|"+data.String() || slow.maxSeen.Load() < 2 {
+				return false
+			}
+
+			if _, err := m.Seek(int64(3*bufferSize+7), io.SeekStart); err != nil {
+				return false
+			}
+			tail, err := io.ReadAll(m)
+			return err == nil && string(tail) == ("head|" + data.String())[3*bufferSize+7:]
+		},
+	},
+	{
+		name: "WithFadvise: подсказки ядру не меняют отдаваемые данные",
+		run: func() bool {
+			dir, err := os.MkdirTemp("", "multireader")
+			if err != nil {
+				return false
+			}
+			defer os.RemoveAll(dir)
+			data := strings.Repeat("fadvise;", bufferSize/3)
+			path := filepath.Join(dir, "data.bin")
+			if os.WriteFile(path, []byte(data), 0o600) != nil {
+				return false
+			}
+
+			src, err := OpenFileSource(path)
+			if err != nil {
+				return false
+			}
+			if err := fadvise(src.(osFileProvider).OSFile(), 0, 0, fadvSequential); err != nil {
+				return false
+			}
+			m := NewMultiReaderWithOptions(2, []SizedReadSeekCloser{NewStringReader(">"), src}, WithFadvise())
+			defer m.Close()
+			got, err := io.ReadAll(m)
+			return err == nil && string(got) == ">"+data
+		},
+	},
+	{
+		name: "ReadV и Next: векторное чтение и блоки окна без копирования",
+		run: func() bool {
+			m := NewMultiReader(2, NewStringReader("abc"), NewStringReader("defgh"), NewStringReader("ij"))
+			defer m.Close()
+
+			bufs := [][]byte{make([]byte, 2), make([]byte, 4), make([]byte, 1)}
+			n, err := m.ReadV(bufs)
+			if err != nil || n != 7 || string(bufs[0])+string(bufs[1])+string(bufs[2]) != "abcdefg" {
+				return false
+			}
+
+			var got []byte
+			for {
+				data, err := m.Next(2)
+				if err == io.EOF {
+					break
+				}
+				if err != nil || len(data) == 0 || len(data) > 2 {
+					return false
+				}
+				got = append(got, data...)
+			}
+			if string(got) != "hij" {
+				return false
+			}
+
+			n, err = m.ReadV([][]byte{make([]byte, 1)})
+			return n == 0 && err == io.EOF
+		},
+	},
+	{
+		name: "Peek и Discard: просмотр без сдвига курсора и пропуск без копирования",
+		run: func() bool {
+			var gz bytes.Buffer
+			zw := gzip.NewWriter(&gz)
+			_, _ = zw.Write([]byte("payload"))
+			_ = zw.Close()
+			m := NewMultiReader(2, NewStringReader("h"), NewBytesReader(gz.Bytes()), NewStringReader("tail"))
+			defer m.Close()
+
+			if _, err := m.Discard(1); err != nil {
+				return false
+			}
+			magic, err := m.Peek(2) // Сигнатура gzip на границе источников
+			if err != nil || !bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+				return false
+			}
+			zr, err := gzip.NewReader(io.LimitReader(m, int64(gz.Len())))
+			if err != nil {
+				return false
+			}
+			if payload, err := io.ReadAll(zr); err != nil || string(payload) != "payload" {
+				return false
+			}
+
+			rest, err := m.Peek(10)
+			if err != io.EOF || string(rest) != "tail" {
+				return false
+			}
+			buf := make([]byte, 10) // Итог потока уже забран Peek - Read должен отдать остаток окна и тот же EOF
+			if n, err := m.Read(buf); err != io.EOF || string(buf[:n]) != "tail" {
+				return false
+			}
+			if _, err := m.Seek(-4, io.SeekEnd); err != nil {
+				return false
+			}
+			if n, err := m.Discard(2); err != nil || n != 2 {
+				return false
+			}
+			n, err := m.Discard(5)
+			if err != io.EOF || n != 2 {
+				return false
+			}
+			_, err = m.Peek(1)
+			return err == io.EOF
+		},
+	},
+	{
+		name: "ReadByte/ReadRune: руны и varint на границах источников, Unread*",
+		run: func() bool {
+			var varint [binary.MaxVarintLen64]byte
+			k := binary.PutUvarint(varint[:], 300)
+			word := "привет, мир"
+			m := NewMultiReader(2,
+				NewBytesReader(varint[:1]), NewBytesReader(varint[1:k]), // varint разрезан границей источников
+				NewStringReader(word[:3]), NewStringReader(word[3:]+"\xff"), // и руна тоже
+			)
+			defer m.Close()
+
+			if v, err := binary.ReadUvarint(m); err != nil || v != 300 {
+				return false
+			}
+			var got []rune
+			for {
+				r, size, err := m.ReadRune()
+				if err == io.EOF {
+					break
+				}
+				if err != nil || size == 0 {
+					return false
+				}
+				got = append(got, r)
+			}
+			if string(got[:len(got)-1]) != word || got[len(got)-1] != utf8.RuneError {
+				return false
+			}
+
+			if _, err := m.Seek(int64(k), io.SeekStart); err != nil {
+				return false
+			}
+			r, _, err := m.ReadRune()
+			if err != nil || r != 'п' || m.UnreadRune() != nil || m.UnreadRune() == nil {
+				return false
+			}
+			if r, _, err = m.ReadRune(); err != nil || r != 'п' {
+				return false
+			}
+			if b, err := m.ReadByte(); err != nil || b != word[2] || m.UnreadRune() == nil || m.UnreadByte() != nil {
+				return false
+			}
+			buf := make([]byte, 4)
+			if _, err := io.ReadFull(m, buf); err != nil || string(buf) != word[2:6] {
+				return false
+			}
+			return m.UnreadByte() == bufio.ErrInvalidUnreadByte
+		},
+	},
+	{
+		name: "Blocks: поблочная итерация со смещениями, досрочный выход и продолжение через Read",
+		run: func() bool {
+			first := strings.Repeat("a", bufferSize+10)
+			m := NewMultiReader(2, NewStringReader(first), NewStringReader("bbb"), NewStringReader("cc"))
+			defer m.Close()
+
+			head := make([]byte, 5)
+			if _, err := io.ReadFull(m, head); err != nil {
+				return false
+			}
+			var (
+				got    = string(head)
+				offset = int64(len(head))
+				blocks int
+			)
+			for b, err := range m.Blocks(context.Background()) {
+				if err != nil || b.Offset != offset {
+					return false
+				}
+				got += string(b.Data)
+				offset += int64(len(b.Data))
+				blocks++
+			}
+			if got != first+"bbbcc" || blocks < 3 {
+				return false
+			}
+
+			if _, err := m.Seek(int64(len(first)), io.SeekStart); err != nil {
+				return false
+			}
+			for b, err := range m.Blocks(context.Background()) {
+				if err != nil || string(b.Data) != "bbb" {
+					return false
+				}
+				break
+			}
+			rest, err := io.ReadAll(m)
+			if err != nil || string(rest) != "cc" {
+				return false
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			for _, err := range m.Blocks(ctx) {
+				return errors.Is(err, context.Canceled)
+			}
+			return false
+		},
+	},
+	{
+		name: "Split: параллельное чтение непересекающихся участков, покрывающих поток",
+		run: func() bool {
+			var data strings.Builder
+			for i := 0; data.Len() < 3*bufferSize; i++ {
+				fmt.Fprintf(&data, "%07d;", i)
+			}
+			s := data.String()
+			m := NewMultiReader(2, NewStringReader(s[:1000]), NewStringReader(s[1000:2*bufferSize]), NewStringReader(s[2*bufferSize:]))
+			defer m.Close()
+
+			parts := m.Split(5)
+			got := make([]string, len(parts))
+			var wg sync.WaitGroup
+			for i, p := range parts {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					defer p.Close()
+					b, err := io.ReadAll(p)
+					if err == nil {
+						got[i] = string(b)
+					}
+				}()
+			}
+			wg.Wait()
+
+			var total int64
+			for i, p := range parts {
+				total += p.Size()
+				if int64(len(got[i])) != p.Size() {
+					return false
+				}
+			}
+			if total != m.Size() || strings.Join(got, "") != s {
+				return false
+			}
+			if len(m.Split(0)) != 0 || len(NewMultiReader(1, NewStringReader("ab")).Split(3)) != 3 {
+				return false
+			}
+
+			// Основной поток не тронут чтением участков
+			head := make([]byte, 8)
+			_, err := io.ReadFull(m, head)
+			return err == nil && string(head) == s[:8]
+		},
+	},
+	{
+		name: "ComputeHashes: дайджесты участков совпадают с последовательным хешированием",
+		run: func() bool {
+			data := strings.Repeat("multipart-etag|", bufferSize/7)
+			m := NewMultiReader(2, NewStringReader(data[:12345]), NewStringReader(data[12345:]))
+			defer m.Close()
+
+			digests, err := m.ComputeHashes(context.Background(), 3, sha256.New)
+			if err != nil || len(digests) != 3 {
+				return false
+			}
+			size := int64(len(data))
+			for i, d := range digests {
+				want := sha256.Sum256([]byte(data[size*int64(i)/3 : size*int64(i+1)/3]))
+				if !bytes.Equal(d, want[:]) {
+					return false
+				}
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			if _, err := m.ComputeHashes(ctx, 2, sha256.New); !errors.Is(err, context.Canceled) {
+				return false
+			}
+			_, err = m.ComputeHashes(context.Background(), 0, sha256.New)
+			return err != nil
+		},
+	},
+	{
+		name: "MultiWriter: запись через границы писателей, Seek и агрегация ошибок Close",
+		run: func() bool {
+			a, empty, b, c := newMockWriteSeeker(3), newMockWriteSeeker(0), newMockWriteSeeker(4), newMockWriteSeeker(2)
+			c.closeErr = errors.New("c close")
+			w := NewMultiWriter(a, empty, b, c)
+			if w.Size() != 9 {
+				return false
+			}
+
+			if n, err := w.Write([]byte("abcdefg")); err != nil || n != 7 {
+				return false
+			}
+			if _, err := w.Seek(2, io.SeekStart); err != nil {
+				return false
+			}
+			if _, err := w.Write([]byte("XY")); err != nil {
+				return false
+			}
+			if _, err := w.Seek(-2, io.SeekEnd); err != nil {
+				return false
+			}
+			n, err := w.Write([]byte("hij"))
+			if n != 2 || !errors.Is(err, io.ErrShortWrite) {
+				return false
+			}
+			if string(a.buf)+string(b.buf)+string(c.buf) != "abXYefghi" {
+				return false
+			}
+
+			err = w.Close()
+			if err == nil || !strings.Contains(err.Error(), "c close") || !a.closed || !b.closed || !empty.closed {
+				return false
+			}
+			_, err = w.Write([]byte("z"))
+			return errors.Is(err, io.ErrClosedPipe) && w.Close() == nil
+		},
+	},
+	{
+		name: "NewTeeMultiReader: копия отданных байт и ошибка записи из Read",
+		run: func() bool {
+			var copyBuf bytes.Buffer
+			m := NewTeeMultiReader(2, &copyBuf, NewStringReader("hello, "), NewStringReader("world"))
+			defer m.Close()
+
+			head := make([]byte, 3)
+			if _, err := io.ReadFull(m, head); err != nil {
+				return false
+			}
+			if _, err := m.Seek(7, io.SeekStart); err != nil { // Пропущенные байты в копию не попадают
+				return false
+			}
+			if _, err := io.ReadAll(m); err != nil || copyBuf.String() != "helworld" {
+				return false
+			}
+
+			failing := NewTeeMultiReader(1, errWriter{errors.New("disk full")}, NewStringReader("abc"))
+			defer failing.Close()
+			n, err := failing.Read(make([]byte, 3))
+			return n == 3 && err != nil && err.Error() == "disk full"
+		},
+	},
+	{
+		name: "Limit: представление префикса с Seek, ReadAt и EOF на границе",
+		run: func() bool {
+			m := NewMultiReader(2, NewStringReader("abc"), NewStringReader("defgh"))
+			if _, err := m.Seek(1, io.SeekStart); err != nil {
+				return false
+			}
+			l := m.Limit(5)
+			if l.Size() != 5 {
+				return false
+			}
+			got, err := io.ReadAll(l)
+			if err != nil || string(got) != "bcdef" {
+				return false
+			}
+			if _, err := l.Seek(-2, io.SeekEnd); err != nil {
+				return false
+			}
+			buf := make([]byte, 4)
+			if n, err := l.Read(buf); err != nil || string(buf[:n]) != "ef" {
+				return false
+			}
+			if n, err := l.ReadAt(buf, 3); err != io.EOF || string(buf[:n]) != "ef" {
+				return false
+			}
+			if _, err := l.Seek(6, io.SeekStart); err == nil {
+				return false
+			}
+			if _, err := m.Seek(6, io.SeekStart); err != nil || m.Limit(100).Size() != 2 {
+				return false
+			}
+			return l.Close() == nil
+		},
+	},
+	{
+		name: "WithBlockCache: повторное чтение заголовка и ReadAt не обращаются к источникам, бюджет соблюдается",
+		run: func() bool {
+			var generated atomic.Int64
+			gen := func(off int64, p []byte) (int, error) {
+				generated.Add(int64(len(p)))
+				for i := range p {
+					p[i] = byte('a' + (off+int64(i))%26)
+				}
+				return len(p), nil
+			}
+			size := int64(3*bufferSize + 100)
+			want := func(off int64) byte { return byte('a' + off%26) }
+
+			m := NewMultiReaderWithOptions(2, []SizedReadSeekCloser{GeneratorSource(size, gen)}, WithBlockCache(4*bufferSize))
+			defer m.Close()
+			if n, err := io.Copy(io.Discard, m); err != nil || n != size {
+				return false
+			}
+			afterScan := generated.Load()
+
+			if _, err := m.Seek(10, io.SeekStart); err != nil {
+				return false
+			}
+			header := make([]byte, 16)
+			if _, err := io.ReadFull(m, header); err != nil || header[0] != want(10) || header[15] != want(25) {
+				return false
+			}
+			buf := make([]byte, 200)
+			if _, err := m.ReadAt(buf, size-200); err != nil || buf[199] != want(size-1) {
+				return false
+			}
+			if generated.Load() != afterScan {
+				return false
+			}
+
+			// Бюджет в один блок: после прохода заголовок вытеснен и читается заново
+			small := NewMultiReaderWithOptions(2, []SizedReadSeekCloser{GeneratorSource(size, gen)}, WithBlockCache(bufferSize))
+			defer small.Close()
+			if _, err := io.Copy(io.Discard, small); err != nil {
+				return false
+			}
+			before := generated.Load()
+			if _, err := small.ReadAt(header, 0); err != nil || header[0] != 'a' {
+				return false
+			}
+			return generated.Load() > before
+		},
+	},
+	{
+		name: "WithDiskCache: вытесненные из памяти блоки читаются с диска, файл удаляется в Close",
+		run: func() bool {
+			dir, err := os.MkdirTemp("", "multireader")
+			if err != nil {
+				return false
+			}
+			defer os.RemoveAll(dir)
+
+			var generated atomic.Int64
+			gen := func(off int64, p []byte) (int, error) {
+				generated.Add(int64(len(p)))
+				for i := range p {
+					p[i] = byte((off + int64(i)) % 251)
+				}
+				return len(p), nil
+			}
+			size := int64(4*bufferSize + 7)
+			m := NewMultiReaderWithOptions(2, []SizedReadSeekCloser{GeneratorSource(size, gen)},
+				WithBlockCache(bufferSize), WithDiskCache(dir, 8*bufferSize))
+
+			if n, err := io.Copy(io.Discard, m); err != nil || n != size {
+				return false
+			}
+			afterScan := generated.Load()
+			if _, err := m.Seek(0, io.SeekStart); err != nil {
+				return false
+			}
+			h := sha256.New()
+			if _, err := io.Copy(h, m); err != nil || generated.Load() != afterScan {
+				return false
+			}
+			want := sha256.New()
+			if _, err := io.Copy(want, GeneratorSource(size, func(off int64, p []byte) (int, error) {
+				for i := range p {
+					p[i] = byte((off + int64(i)) % 251)
+				}
+				return len(p), nil
+			})); err != nil || !bytes.Equal(h.Sum(nil), want.Sum(nil)) {
+				return false
+			}
+
+			if spilled, _ := os.ReadDir(dir); len(spilled) != 1 {
+				return false
+			}
+			if m.Close() != nil {
+				return false
+			}
+			left, _ := os.ReadDir(dir)
+			return len(left) == 0
+		},
+	},
+	{
+		name: "Pin/Unpin: закреплённый футер переживает Seek и не читается из источников повторно",
+		run: func() bool {
+			size := int64(2*bufferSize + 500)
+			footerOff, footerLen := size-64, int64(64)
+			var footerReads atomic.Int64
+			gen := func(off int64, p []byte) (int, error) {
+				if off+int64(len(p)) > footerOff {
+					footerReads.Add(1)
+				}
+				for i := range p {
+					p[i] = byte((off + int64(i)) % 199)
+				}
+				return len(p), nil
+			}
+			m := NewMultiReader(2, GeneratorSource(size-100, gen), GeneratorSource(100, func(off int64, p []byte) (int, error) {
+				return gen(off+size-100, p)
+			}))
+			defer m.Close()
+
+			if err := m.Pin(footerOff, footerLen); err != nil || m.Pin(footerOff, footerLen) != nil {
+				return false
+			}
+			pinnedReads := footerReads.Load()
+			footer := make([]byte, footerLen)
+			for range 3 { // Типичный шаблон: футер, начало, снова футер
+				if _, err := m.Seek(footerOff, io.SeekStart); err != nil {
+					return false
+				}
+				if _, err := io.ReadFull(m, footer); err != nil || footer[0] != byte(footerOff%199) {
+					return false
+				}
+				if _, err := m.Seek(0, io.SeekStart); err != nil {
+					return false
+				}
+				head := make([]byte, 10)
+				if _, err := io.ReadFull(m, head); err != nil || head[9] != 9 {
+					return false
+				}
+			}
+			if n, err := m.ReadAt(footer[:8], footerOff+8); err != nil || n != 8 || footerReads.Load() != pinnedReads {
+				return false
+			}
+
+			if m.Unpin(footerOff, footerLen) != nil || m.Unpin(footerOff, footerLen) != nil || m.Unpin(footerOff, footerLen) == nil {
+				return false
+			}
+			return m.Pin(size-1, 2) != nil
+		},
+	},
+	{
+		name: "WithEdgePrefetch: футер и начало потока читаются из кэша без обращения к источникам",
+		run: func() bool {
+			size := int64(5*bufferSize + 123)
+			var calls atomic.Int64 // чтения, задевающие первые или последние 200 байт
+			gen := func(off int64, p []byte) (int, error) {
+				if off < 200 || off+int64(len(p)) > size-200 {
+					calls.Add(1)
+				}
+				for i := range p {
+					p[i] = byte((off + int64(i)) % 251)
+				}
+				return len(p), nil
+			}
+			m := NewMultiReaderWithOptions(2, []SizedReadSeekCloser{GeneratorSource(size/2, gen), GeneratorSource(size-size/2, func(off int64, p []byte) (int, error) {
+				return gen(off+size/2, p)
+			})}, WithEdgePrefetch(200))
+			defer m.Close()
+			if calls.Load() == 0 {
+				return false
+			}
+			calls.Store(0)
+
+			footer := make([]byte, 200)
+			if _, err := m.Seek(-200, io.SeekEnd); err != nil {
+				return false
+			}
+			if _, err := io.ReadFull(m, footer); err != nil || footer[0] != byte((size-200)%251) {
+				return false
+			}
+			if n, err := m.ReadAt(footer[:10], size-10); err != nil || n != 10 || footer[0] != byte((size-10)%251) {
+				return false
+			}
+			if _, err := m.Seek(0, io.SeekStart); err != nil {
+				return false
+			}
+			head := make([]byte, 200)
+			if _, err := io.ReadFull(m, head); err != nil || head[199] != 199 {
+				return false
+			}
+
+			return calls.Load() == 0
+		},
+	},
+	{
+		name: "Multiplexer: параллельные дескрипторы читают пересекающиеся диапазоны, каждый блок запрашивается у источников один раз",
+		run: func() bool {
+			size := int64(6*bufferSize + 77)
+			var calls atomic.Int64
+			gen := func(off int64, p []byte) (int, error) {
+				calls.Add(1)
+				time.Sleep(time.Millisecond) // Даём потребителям пересечься на одном блоке
+				for i := range p {
+					p[i] = byte((off + int64(i)) % 241)
+				}
+				return len(p), nil
+			}
+			x := NewMultiplexer(size, GeneratorSource(size, gen))
+
+			var wg sync.WaitGroup
+			var failed atomic.Bool
+			for i := range 8 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					var r SizedReadSeekCloser
+					if i%2 == 0 {
+						r = x.NewReader()
+					} else {
+						var err error
+						if r, err = x.NewRangeReader(int64(i)*100, size-int64(i)*100); err != nil {
+							failed.Store(true)
+							return
+						}
+					}
+					defer r.Close()
+					data, err := io.ReadAll(r)
+					if err != nil || int64(len(data)) != r.Size() {
+						failed.Store(true)
+						return
+					}
+					base := size - r.Size()
+					for j, b := range data {
+						if b != byte((base+int64(j))%241) {
+							failed.Store(true)
+							return
+						}
+					}
+				}()
+			}
+			wg.Wait()
+			if failed.Load() || calls.Load() != (size+bufferSize-1)/bufferSize {
+				return false
+			}
+
+			r := x.NewReader()
+			if _, err := x.NewRangeReader(size-1, 2); err == nil {
+				return false
+			}
+			if err := x.Close(); err != nil {
+				return false
+			}
+			_, err := r.Read(make([]byte, 1))
+			return errors.Is(err, io.ErrClosedPipe)
+		},
+	},
+	{
+		name: "Тысячи мелких источников: грубый индекс и CoalesceSources дают тот же поток",
+		run: func() bool {
+			var want []byte
+			build := func() []SizedReadSeekCloser {
+				want = want[:0]
+				readers := make([]SizedReadSeekCloser, 3000)
+				for i := range readers {
+					chunk := strings.Repeat(string(rune('a'+i%26)), i%7) // Среди источников есть пустые
+					want = append(want, chunk...)
+					readers[i] = NewStringReader(chunk)
+				}
+				return readers
+			}
+
+			for _, m := range []*MultiReader{NewMultiReader(2, build()...), NewMultiReaderCoalesced(2, 64, build()...)} {
+				got, err := io.ReadAll(m)
+				if err != nil || !bytes.Equal(got, want) {
+					return false
+				}
+				for _, off := range []int64{0, 1, 4095, int64(len(want)) / 2, int64(len(want)) - 3} {
+					if _, err := m.Seek(off, io.SeekStart); err != nil {
+						return false
+					}
+					p := make([]byte, 3)
+					if _, err := io.ReadFull(m, p); err != nil || !bytes.Equal(p, want[off:off+3]) {
+						return false
+					}
+					if _, err := m.ReadAt(p, off); err != nil || !bytes.Equal(p, want[off:off+3]) {
+						return false
+					}
+				}
+				if err := m.Close(); err != nil {
+					return false
+				}
+			}
+
+			segments := CoalesceSources(64, build()...)
+			return len(segments) < 3000/10 && segments[0].Size() >= 64
+		},
+	},
+	{
+		name: "WithCoalescedReads: тысячи мелких источников публикуются полными блоками",
+		run: func() bool {
+			build := func() ([]SizedReadSeekCloser, []byte) {
+				var want []byte
+				readers := make([]SizedReadSeekCloser, 2000)
+				for i := range readers {
+					chunk := strings.Repeat(string(rune('a'+i%26)), 100+i%3)
+					want = append(want, chunk...)
+					readers[i] = NewStringReader(chunk)
+				}
+				return readers, want
+			}
+			countBlocks := func(m *MultiReader, want []byte) int {
+				defer m.Close()
+				var got []byte
+				blocks := 0
+				for b, err := range m.Blocks(context.Background()) {
+					if err != nil {
+						return -1
+					}
+					got = append(got, b.Data...)
+					blocks++
+				}
+				if !bytes.Equal(got, want) {
+					return -1
+				}
+				return blocks
+			}
+
+			readers, want := build()
+			if countBlocks(NewMultiReader(2, readers...), want) != len(readers) {
+				return false
+			}
+			readers, want = build()
+			m := NewMultiReaderWithOptions(2, readers, WithCoalescedReads())
+			if n := countBlocks(m, want); n != (len(want)+bufferSize-1)/bufferSize {
+				return false
+			}
+
+			readers, want = build()
+			m = NewMultiReaderWithOptions(2, readers, WithCoalescedReads())
+			defer m.Close()
+			if _, err := m.Seek(12345, io.SeekStart); err != nil {
+				return false
+			}
+			got, err := io.ReadAll(m)
+			return err == nil && bytes.Equal(got, want[12345:])
+		},
+	},
+	{
+		name: "WithReadConcurrency: несколько запросов в полёте внутри одной части удалённого объекта",
+		run: func() bool {
+			object := bytes.Repeat([]byte("0123456789abcdef"), 6*bufferSize/16+5)
+			var inFlight, maxSeen atomic.Int64
+			d := &Downloader{
+				Open: func(ctx context.Context, off, length int64) (io.ReadCloser, error) {
+					cur := inFlight.Add(1)
+					defer inFlight.Add(-1)
+					for {
+						seen := maxSeen.Load()
+						if cur <= seen || maxSeen.CompareAndSwap(seen, cur) {
+							break
+						}
+					}
+					time.Sleep(5 * time.Millisecond) // Задержка удалённого хранилища
+					return io.NopCloser(bytes.NewReader(object[off : off+length])), nil
+				},
+				Size:     int64(len(object)),
+				PartSize: int64(len(object)), // Одна часть - конвейер только внутри источника
+			}
+
+			read := func(opts ...Option) bool {
+				m := d.NewReader(context.Background(), 2, opts...)
+				defer m.Close()
+				got, err := io.ReadAll(m)
+				return err == nil && bytes.Equal(got, object)
+			}
+			if !read() || maxSeen.Load() != 1 {
+				return false
+			}
+			maxSeen.Store(0)
+			if !read(WithReadConcurrency(4)) || maxSeen.Load() < 2 {
+				return false
+			}
+
+			p := make([]byte, 10)
+			m := d.NewReader(context.Background(), 2, WithReadConcurrency(4))
+			defer m.Close()
+			if _, err := m.Seek(int64(len(object))-10, io.SeekStart); err != nil {
+				return false
+			}
+			_, err := io.ReadFull(m, p)
+			return err == nil && bytes.Equal(p, object[len(object)-10:])
+		},
+	},
+	{
+		name: "Stats: заполненность окна, простои префетчера и потребителя, перезапуски",
+		run: func() bool {
+			var slowStart sync.Once
+			gen := func(off int64, p []byte) (int, error) {
+				slowStart.Do(func() { time.Sleep(10 * time.Millisecond) }) // Первое чтение гарантированно ждёт блок
+				return len(p), nil
+			}
+			m := NewMultiReader(2, GeneratorSource(6*bufferSize, gen))
+			defer m.Close()
+
+			if _, err := m.Read(make([]byte, 1)); err != nil {
+				return false
+			}
+			deadline := time.Now().Add(time.Second)
+			for m.Stats().ProducerStalls == 0 { // Префетчер упрётся в заполненное окно
+				if time.Now().After(deadline) {
+					return false
+				}
+				time.Sleep(time.Millisecond)
+			}
+			st := m.Stats()
+			if st.BlocksBuffered != 2 || st.BytesBuffered != 3*bufferSize-1 || st.ConsumerStalls == 0 || st.PrefetchRestarts != 0 {
+				return false
+			}
+
+			if _, err := m.Seek(5*bufferSize, io.SeekStart); err != nil {
+				return false
+			}
+			if _, err := m.Read(make([]byte, 1)); err != nil {
+				return false
+			}
+			if m.Stats().PrefetchRestarts != 1 {
+				return false
+			}
+
+			c := NewMultiReaderWithOptions(2, []SizedReadSeekCloser{NewStringReader("hello")}, WithBlockCache(bufferSize))
+			defer c.Close()
+			p := make([]byte, 5)
+			_, _ = c.ReadAt(p, 0)
+			_, _ = c.ReadAt(p, 0)
+			st = c.Stats()
+			return st.CacheMisses == 1 && st.CacheHits == 1
+		},
+	},
+	{
+		name: "WaitIdle: ждёт остановки префетчера и фонового закрытия источников",
+		run: func() bool {
+			m := NewMultiReader(1, GeneratorSource(4*bufferSize, func(off int64, p []byte) (int, error) { return len(p), nil }))
+			if _, err := m.Read(make([]byte, 1)); err != nil {
+				return false
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+			if err := m.WaitIdle(ctx); !errors.Is(err, context.DeadlineExceeded) { // Префетчер ждёт места в окне
+				return false
+			}
+			if err := m.Close(); err != nil || m.WaitIdle(context.Background()) != nil {
+				return false
+			}
+
+			a := newMockStringsReader("abc")
+			a.readGate = make(chan struct{})
+			m = NewMultiReaderWithOptions(1, []SizedReadSeekCloser{a}, WithCloseGracePeriod(10*time.Millisecond))
+			readDone := make(chan struct{})
+			go func() {
+				_, _ = m.Read(make([]byte, 3))
+				close(readDone)
+			}()
+			time.Sleep(10 * time.Millisecond)
+			if err := m.Close(); !errors.Is(err, ErrCloseTimeout) {
+				return false
+			}
+			<-readDone
+			ctx2, cancel2 := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel2()
+			if err := m.WaitIdle(ctx2); !errors.Is(err, context.DeadlineExceeded) { // Источник всё ещё висит
+				return false
+			}
+			close(a.readGate)
+			return m.WaitIdle(context.Background()) == nil && a.closed
+		},
+	},
+	{
+		name: "WithFinalizer и LivePrefetchers: брошенный мультиридер закрывает источники, счётчик видит префетчер",
+		run: func() bool {
+			m := NewMultiReader(1, GeneratorSource(4*bufferSize, func(off int64, p []byte) (int, error) { return len(p), nil }))
+			if _, err := m.Read(make([]byte, 1)); err != nil || LivePrefetchers() < 1 {
+				return false
+			}
+			_ = m.Close()
+
+			closed := make(chan struct{})
+			func() {
+				m := NewMultiReaderWithOptions(1, []SizedReadSeekCloser{NewStringReader("abc")},
+					WithFinalizer(),
+					OnSourceClose(func(int, error) { close(closed) }),
+				)
+				if data, err := io.ReadAll(m); err != nil || string(data) != "abc" {
+					return
+				}
+				_ = m.WaitIdle(context.Background()) // Префетчер остановился на EOF - ссылок на m больше нет
+			}()
+
+			deadline := time.After(time.Second)
+			for {
+				runtime.GC()
+				select {
+				case <-closed:
+					return true
+				case <-deadline:
+					return false
+				case <-time.After(5 * time.Millisecond):
+				}
+			}
+		},
+	},
+	{
+		name: "SaveState/NewMultiReaderFromState: другой процесс продолжает чтение с сохранённой позиции",
+		run: func() bool {
+			dir, err := os.MkdirTemp("", "multireader")
+			if err != nil {
+				return false
+			}
+			defer os.RemoveAll(dir)
+
+			parts := []string{"first-", "second-", "third"}
+			for i, part := range parts {
+				if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("chunk%d", i)), []byte(part), 0o600); err != nil {
+					return false
+				}
+			}
+			m, err := NewMultiReaderFromDir(1, dir)
+			if err != nil {
+				return false
+			}
+			if _, err := io.ReadFull(m, make([]byte, 9)); err != nil {
+				return false
+			}
+			saved, err := json.Marshal(m.SaveState())
+			_ = m.Close()
+			if err != nil {
+				return false
+			}
+
+			var st State
+			if err := json.Unmarshal(saved, &st); err != nil || st.Offset != 9 || st.Sources[1].Name != filepath.Join(dir, "chunk1") {
+				return false
+			}
+			open := func(_ int, src SourceState) (SizedReadSeekCloser, error) { return OpenFileSource(src.Name) }
+			resumed, err := NewMultiReaderFromState(1, st, open)
+			if err != nil {
+				return false
+			}
+			rest, err := io.ReadAll(resumed)
+			_ = resumed.Close()
+			if err != nil || string(rest) != "ond-third" {
+				return false
+			}
+
+			if err := os.WriteFile(filepath.Join(dir, "chunk2"), []byte("changed"), 0o600); err != nil {
+				return false
+			}
+			_, err = NewMultiReaderFromState(1, st, open)
+			return errors.Is(err, ErrSourceChanged)
+		},
+	},
+	{
+		name: "Manifest: экспорт в JSON, воссоздание склейки и сверка контрольных сумм",
+		run: func() bool {
+			contents := map[string]string{"a.bin": "alpha-", "b.bin": "beta-", "c.bin": "gamma"}
+			open := func(_ context.Context, e ManifestEntry) (SizedReadSeekCloser, error) {
+				data, ok := contents[e.Name]
+				if !ok {
+					return nil, fs.ErrNotExist
+				}
+				return NewStringReader(data), nil
+			}
+			specs := make([]LazySpec, 0, 3)
+			for _, name := range []string{"a.bin", "b.bin", "c.bin"} {
+				specs = append(specs, LazySpec{
+					Size: int64(len(contents[name])),
+					Open: func(ctx context.Context) (SizedReadSeekCloser, error) { return open(ctx, ManifestEntry{Name: name}) },
+					Name: name,
+				})
+			}
+			m := NewMultiReaderLazy(context.Background(), 1, specs...)
+			defer m.Close()
+
+			mf, err := m.Manifest(context.Background(), "sha256", sha256.New)
+			if err != nil || mf.TotalSize != 16 || mf.Sources[1].Name != "b.bin" || mf.Sources[2].Checksum == "" {
+				return false
+			}
+			var buf bytes.Buffer
+			if err := WriteManifest(&buf, mf); err != nil {
+				return false
+			}
+			mf, err = ReadManifest(&buf)
+			if err != nil {
+				return false
+			}
+
+			rebuilt, err := NewMultiReaderFromManifest(context.Background(), 1, mf, open)
+			if err != nil {
+				return false
+			}
+			defer rebuilt.Close()
+			data, err := io.ReadAll(rebuilt)
+			if err != nil || string(data) != "alpha-beta-gamma" || rebuilt.VerifyManifest(context.Background(), mf, sha256.New) != nil {
+				return false
+			}
+
+			contents["b.bin"] = "BETA-"
+			changed, err := NewMultiReaderFromManifest(context.Background(), 1, mf, open)
+			if err != nil {
+				return false
+			}
+			defer changed.Close()
+			return errors.Is(changed.VerifyManifest(context.Background(), mf, sha256.New), ErrSourceChanged)
+		},
+	},
+	{
+		name: "HTTPHandler: Range, HEAD, ETag из манифеста и условные запросы",
+		run: func() bool {
+			x := NewMultiplexer(bufferSize, NewStringReader("hello "), NewStringReader("world"))
+			defer x.Close()
+			m := NewMultiReader(1, NewStringReader("hello "), NewStringReader("world"))
+			defer m.Close()
+			mf, err := m.Manifest(context.Background(), "sha256", sha256.New)
+			if err != nil {
+				return false
+			}
+			etag, ok := ManifestETag(mf)
+			if !ok || !strings.HasSuffix(etag, "-2") {
+				return false
+			}
+			srv := httptest.NewServer(NewHTTPHandler(x, etag))
+			defer srv.Close()
+
+			do := func(method string, header ...string) (*http.Response, string) {
+				req, _ := http.NewRequest(method, srv.URL, nil)
+				for i := 0; i+1 < len(header); i += 2 {
+					req.Header.Set(header[i], header[i+1])
+				}
+				resp, err := http.DefaultClient.Do(req)
+				if err != nil {
+					return nil, ""
+				}
+				defer resp.Body.Close()
+				body, _ := io.ReadAll(resp.Body)
+				return resp, string(body)
+			}
+
+			resp, body := do(http.MethodGet)
+			if resp == nil || resp.StatusCode != http.StatusOK || body != "hello world" || resp.Header.Get("ETag") != `"`+etag+`"` {
+				return false
+			}
+			resp, body = do(http.MethodGet, "Range", "bytes=4-7")
+			if resp == nil || resp.StatusCode != http.StatusPartialContent || body != "o wo" || resp.Header.Get("Content-Range") != "bytes 4-7/11" {
+				return false
+			}
+			resp, body = do(http.MethodHead)
+			if resp == nil || resp.StatusCode != http.StatusOK || body != "" || resp.ContentLength != 11 {
+				return false
+			}
+			resp, _ = do(http.MethodGet, "If-None-Match", `"`+etag+`"`)
+			if resp == nil || resp.StatusCode != http.StatusNotModified {
+				return false
+			}
+			resp, body = do(http.MethodGet, "Range", "bytes=0-4", "If-Range", `"stale"`) // Устаревший ETag - отдаём целиком
+			if resp == nil || resp.StatusCode != http.StatusOK || body != "hello world" {
+				return false
+			}
+			resp, _ = do(http.MethodPost)
+			return resp != nil && resp.StatusCode == http.StatusMethodNotAllowed
+		},
+	},
+	{
+		name: "ChunkServer: диапазон отдаётся фрагментами, ошибки send и отмена прерывают передачу",
+		run: func() bool {
+			x := NewMultiplexer(bufferSize, NewStringReader("0123456789"), NewStringReader("abcdef"))
+			defer x.Close()
+			s := NewChunkServer(x, 4)
+
+			var chunks []string
+			collect := func(chunk []byte) error {
+				chunks = append(chunks, string(chunk))
+				return nil
+			}
+			if err := s.ServeRange(context.Background(), 7, 7, collect); err != nil || strings.Join(chunks, "|") != "789a|bcd" {
+				return false
+			}
+			chunks = nil
+			if err := s.ServeRange(context.Background(), 14, -1, collect); err != nil || strings.Join(chunks, "|") != "ef" {
+				return false
+			}
+			if s.ServeRange(context.Background(), 10, 7, collect) == nil {
+				return false
+			}
+
+			errStop := errors.New("client gone")
+			if err := s.ServeRange(context.Background(), 0, -1, func([]byte) error { return errStop }); !errors.Is(err, errStop) {
+				return false
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			return errors.Is(s.ServeRange(ctx, 0, -1, collect), context.Canceled)
+		},
+	},
+	{
+		name: "bench: замер по манифесту печатает строку на каждую глубину окна",
+		run: func() bool {
+			dir, err := os.MkdirTemp("", "multireader")
+			if err != nil {
+				return false
+			}
+			defer os.RemoveAll(dir)
+
+			mf := Manifest{}
+			for i := range 3 {
+				path := filepath.Join(dir, fmt.Sprintf("part%d", i))
+				if err := os.WriteFile(path, bytes.Repeat([]byte{byte(i)}, 100_000), 0o600); err != nil {
+					return false
+				}
+				mf.Sources = append(mf.Sources, ManifestEntry{Name: path, Size: 100_000})
+				mf.TotalSize += 100_000
+			}
+			manifestPath := filepath.Join(dir, "layout.json")
+			f, err := os.Create(manifestPath)
+			if err != nil {
+				return false
+			}
+			if err := errors.Join(WriteManifest(f, mf), f.Close()); err != nil {
+				return false
+			}
+
+			var out bytes.Buffer
+			if err := runBench([]string{"-manifest", manifestPath, "-windows", "1, 4", "-runs", "1"}, &out); err != nil {
+				return false
+			}
+			lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+			if len(lines) != 3 || !strings.Contains(lines[0], "MiB/s") || !strings.HasPrefix(strings.TrimSpace(lines[2]), "4 ") {
+				return false
+			}
+			return runBench([]string{"-manifest", manifestPath, "-windows", "0"}, io.Discard) != nil
+		},
+	},
+	{
+		name: "RunSourceConformance: встроенные источники проходят набор, нарушения контракта обнаруживаются",
+		run: func() bool {
+			want := []byte(strings.Repeat("conformance!", 50))
+			sources := map[string]func() SizedReadSeekCloser{
+				"string": func() SizedReadSeekCloser { return NewStringReader(string(want)) },
+				"bytes":  func() SizedReadSeekCloser { return NewBytesReader(want) },
+				"generator": func() SizedReadSeekCloser {
+					return GeneratorSource(int64(len(want)), func(off int64, p []byte) (int, error) {
+						return copy(p, want[off:]), nil
+					})
+				},
+				"composite": func() SizedReadSeekCloser {
+					return newCompositeSource([]SizedReadSeekCloser{NewBytesReader(want[:7]), NewBytesReader(want[7:300]), NewBytesReader(want[300:])})
+				},
+				"multireader": func() SizedReadSeekCloser {
+					return NewMultiReader(2, NewBytesReader(want[:100]), NewBytesReader(want[100:]))
+				},
+			}
+			for _, newSource := range sources {
+				var t recordingT
+				RunSourceConformance(&t, newSource, want)
+				if len(t.errs) != 0 {
+					return false
+				}
+			}
+
+			var t recordingT
+			RunSourceConformance(&t, func() SizedReadSeekCloser { return NewBytesReader(want[1:]) }, want) // Неверное содержимое
+			return len(t.errs) >= 2 && strings.HasPrefix(t.errs[0], "size:")
+		},
+	},
+	{
+		name: "FaultSource: ошибка после N байт, сбой K-го Seek, ошибка Close и короткие чтения",
+		run: func() bool {
+			errRead := errors.New("disk gone")
+			m := NewMultiReader(1, NewStringReader("ok-"), NewFaultSource([]byte("0123456789"), Faults{ReadErr: errRead, ReadErrAfter: 4}))
+			data, err := io.ReadAll(m)
+			_ = m.Close()
+			if !errors.Is(err, errRead) || string(data) != "ok-0123" {
+				return false
+			}
+
+			short := NewFaultSource([]byte("abcdef"), Faults{MaxRead: 2})
+			if n, err := short.Read(make([]byte, 10)); n != 2 || err != nil {
+				return false
+			}
+			m = NewMultiReader(1, short)
+			data, err = io.ReadAll(m)
+			_ = m.Close()
+			if err != nil || string(data) != "abcdef" {
+				return false
+			}
+
+			errSeek := errors.New("seek refused")
+			errClose := errors.New("close refused")
+			flaky := NewFaultSource([]byte("xyz"), Faults{SeekErr: errSeek, SeekErrOn: 2, CloseErr: errClose})
+			if _, err := flaky.Seek(1, io.SeekStart); err != nil {
+				return false
+			}
+			if _, err := flaky.Seek(0, io.SeekStart); !errors.Is(err, errSeek) || flaky.Seeks() != 2 {
+				return false
+			}
+			m = NewMultiReader(1, flaky)
+			return errors.Is(m.Close(), errClose) && flaky.Closes() == 1
+		},
+	},
+	{
+		name: "FaultSource: задержки, jitter с зерном, ограничение полосы и Close во время медленного Read",
+		run: func() bool {
+			data := bytes.Repeat([]byte("x"), 1000)
+			m := NewMultiReader(2, NewFaultSource(data, Faults{Bandwidth: 20_000, MaxRead: 100}))
+			start := time.Now()
+			got, err := io.ReadAll(m)
+			_ = m.Close()
+			if err != nil || len(got) != len(data) || time.Since(start) < 45*time.Millisecond {
+				return false
+			}
+
+			a := NewFaultSource(data, Faults{Latency: time.Millisecond, Jitter: time.Second, Seed: 7})
+			b := NewFaultSource(data, Faults{Latency: time.Millisecond, Jitter: time.Second, Seed: 7})
+			for range 5 {
+				if da, db := a.delay(10), b.delay(10); da != db || da < time.Millisecond {
+					return false
+				}
+			}
+
+			slow := NewFaultSource(data, Faults{Latency: 10 * time.Second})
+			m = NewMultiReader(1, slow)
+			readErr := make(chan error, 1)
+			go func() {
+				_, err := m.Read(make([]byte, 10))
+				readErr <- err
+			}()
+			time.Sleep(10 * time.Millisecond)
+			start = time.Now()
+			if err := m.Close(); err != nil || time.Since(start) > time.Second {
+				return false
+			}
+			select {
+			case err := <-readErr:
+				return err != nil
+			case <-time.After(time.Second):
+				return false
+			}
+		},
+	},
+	{
+		name: "SyntheticSource: многогигабайтная склейка читается и сверяется без хранения данных",
+		run: func() bool {
+			const gib = int64(1) << 30
+			m := NewMultiReader(2, SyntheticSource(3*gib, 1), SyntheticSource(2*gib+13, 2))
+			defer m.Close()
+			if m.Size() != 5*gib+13 {
+				return false
+			}
+
+			got, want := make([]byte, 4096), make([]byte, 4096)
+			for _, off := range []int64{0, 7, 3*gib - 100, 4*gib + 12345, 5*gib + 13 - 4096} {
+				if _, err := m.ReadAt(got, off); err != nil {
+					return false
+				}
+				if off < 3*gib {
+					n := min(int64(len(want)), 3*gib-off)
+					FillSynthetic(1, off, want[:n])
+					FillSynthetic(2, 0, want[n:])
+				} else {
+					FillSynthetic(2, off-3*gib, want)
+				}
+				if !bytes.Equal(got, want) {
+					return false
+				}
+			}
+
+			// Seek и последовательное чтение дают то же содержимое; разные зёрна дают разные данные
+			if _, err := m.Seek(4*gib, io.SeekStart); err != nil {
+				return false
+			}
+			if _, err := io.ReadFull(m, got); err != nil {
+				return false
+			}
+			FillSynthetic(2, gib, want)
+			other := make([]byte, 4096)
+			FillSynthetic(3, gib, other)
+			return bytes.Equal(got, want) && !bytes.Equal(want, other)
+		},
+	},
+	{
+		name: "ShortReadSource: крошечные частичные чтения на границах источников не теряют и не дублируют байты",
+		run: func() bool {
+			for _, k := range []int{1, 2, 3, 7} {
+				var want []byte
+				var readers []SizedReadSeekCloser
+				for i := range 20 {
+					chunk := bytes.Repeat([]byte{byte('a' + i)}, i%5) // Есть пустые источники
+					want = append(want, chunk...)
+					readers = append(readers, ShortReadSource(NewBytesReader(chunk), k))
+				}
+				m := NewMultiReader(2, readers...)
+				for _, bufSize := range []int{1, 4, 1000} {
+					if _, err := m.Seek(0, io.SeekStart); err != nil {
+						return false
+					}
+					var got []byte
+					buf := make([]byte, bufSize)
+					for {
+						n, err := m.Read(buf)
+						got = append(got, buf[:n]...)
+						if errors.Is(err, io.EOF) {
+							break
+						}
+						if err != nil {
+							return false
+						}
+					}
+					if !bytes.Equal(got, want) {
+						return false
+					}
+				}
+				_ = m.Close()
+			}
+
+			var t recordingT
+			RunSourceConformance(&t, func() SizedReadSeekCloser { return ShortReadSource(NewStringReader("short reads"), 2) }, []byte("short reads"))
+			return len(t.errs) == 0
+		},
+	},
+	{
+		name: "iotest.TestReader: MultiReader и его представления соблюдают контракты io.Reader, io.ReaderAt и io.Seeker",
+		run: func() bool {
+			content := []byte(strings.Repeat("iotest-compliance/", 300))
+			build := func() *MultiReader {
+				return NewMultiReader(2, NewBytesReader(content[:1000]), NewBytesReader(nil), NewBytesReader(content[1000:]))
+			}
+
+			m := build()
+			defer m.Close()
+			if err := iotest.TestReader(m, content); err != nil {
+				return false
+			}
+			cached := NewMultiReaderWithOptions(2, []SizedReadSeekCloser{NewBytesReader(content)}, WithBlockCache(bufferSize))
+			defer cached.Close()
+			if err := iotest.TestReader(cached, content); err != nil {
+				return false
+			}
+			src := build()
+			defer src.Close()
+			if err := iotest.TestReader(src.Limit(777), content[:777]); err != nil {
+				return false
+			}
+			x := NewMultiplexer(bufferSize, NewBytesReader(content))
+			defer x.Close()
+			if err := iotest.TestReader(x.NewReader(), content); err != nil {
+				return false
+			}
+
+			// Ошибка источника (iotest.ErrReader) приходит после всех байт, прочитанных до неё
+			errBroken := errors.New("broken source")
+			broken := NewMultiReader(2, NewStringReader("head-"), newReopenSource(10, func() (io.ReadCloser, error) {
+				return io.NopCloser(io.MultiReader(strings.NewReader("ab"), iotest.ErrReader(errBroken))), nil
+			}))
+			defer broken.Close()
+			got, err := io.ReadAll(iotest.OneByteReader(broken))
+			return errors.Is(err, errBroken) && string(got) == "head-ab"
+		},
+	},
+	{
+		name: "Отчёт раннера: JSON Lines и JUnit XML собирают все провалы и панику, не завершая процесс",
+		run: func() bool {
+			cases := []TestCase{
+				{name: "ok", run: func() bool { return true }},
+				{name: "bad", run: func() bool { return false }},
+				{name: "boom", run: func() bool { panic("boom") }},
+			}
+
+			var jsonOut bytes.Buffer
+			failed, err := runReport(cases, "json", &jsonOut)
+			if err != nil || failed != 2 {
+				return false
+			}
+			var statuses []string
+			dec := json.NewDecoder(&jsonOut)
+			for dec.More() {
+				var res caseResult
+				if err := dec.Decode(&res); err != nil {
+					return false
+				}
+				statuses = append(statuses, res.Name+":"+res.Status)
+			}
+			if strings.Join(statuses, ",") != "ok:pass,bad:fail,boom:panic" {
+				return false
+			}
+
+			var junitOut bytes.Buffer
+			if failed, err = runReport(cases, "junit", &junitOut); err != nil || failed != 2 {
+				return false
+			}
+			var suite junitSuite
+			if err := xml.Unmarshal(junitOut.Bytes(), &suite); err != nil {
+				return false
+			}
+			if suite.Tests != 3 || suite.Failures != 2 || suite.Cases[0].Failure != nil || suite.Cases[2].Failure == nil {
+				return false
+			}
+
+			_, err = runReport(cases, "yaml", io.Discard)
+			return err != nil
+		},
+	},
+	{
+		name: "Таймаут кейса: зависший кейс получает статус timeout, а стеки горутин показывают место зависания",
+		run: func() bool {
+			release := make(chan struct{})
+			defer close(release)
+			hung := TestCase{
+				name:    "hung",
+				run:     func() bool { <-release; return true },
+				timeout: 50 * time.Millisecond,
+			}
+
+			var stacks bytes.Buffer
+			start := time.Now()
+			res := runCase(hung, &stacks)
+			if res.Status != "timeout" || time.Since(start) > 5*time.Second {
+				return false
+			}
+			if !strings.Contains(stacks.String(), "goroutine") || !strings.Contains(stacks.String(), "private_test_cases.go") {
+				return false
+			}
+
+			quick := TestCase{name: "quick", run: func() bool { return true }, timeout: time.Second}
+			return runCase(quick, io.Discard).Status == "pass" && (TestCase{}).caseTimeout() == concurrentTestTimeout
+		},
+	},
+	{
+		name: "Стресс: случайные Read/Seek, параллельные ReadAt и Close совпадают с последовательной эталонной реализацией",
+		run: func() bool {
+			for seed := int64(1); seed <= 25; seed++ {
+				cfg := StressConfig{Seed: seed, Ops: 300, Workers: 3, Sources: 6, MaxSource: 64 << 10}
+				if err := RunStress(cfg); err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					return false
+				}
+			}
+			return true
+		},
+	},
+	{
+		name: "Дифференциальная проверка: случайные части и операции дают те же байты, ошибки и позиции, что bytes.Reader",
+		run: func() bool {
+			optionSets := [][]Option{nil, {WithBlockCache(bufferSize)}, {WithReadConcurrency(3)}, {WithCoalescedReads()}}
+			for seed := int64(1); seed <= 200; seed++ {
+				rnd := rand.New(rand.NewSource(seed))
+				parts, ops := randomDiffCase(rnd, 4096, 60)
+				if err := checkDifferential(1+rnd.Intn(3), parts, ops, optionSets[seed%4]...); err != nil {
+					fmt.Fprintf(os.Stderr, "seed %d: %v\n", seed, err)
+					return false
+				}
+			}
+			return true
+		},
+	},
+	{
+		name: "WithPrefetch(false): синхронное чтение ровно запрошенного объёма без горутины префетча",
+		run: func() bool {
+			var generated atomic.Int64
+			gen := GeneratorSource(3*bufferSize, func(off int64, p []byte) (int, error) {
+				generated.Add(int64(len(p)))
+				FillSynthetic(7, off, p)
+				return len(p), nil
+			})
+			m := NewMultiReaderWithOptions(4, []SizedReadSeekCloser{NewStringReader("head-"), gen}, WithPrefetch(false))
+			defer m.Close()
+
+			before := LivePrefetchers()
+			buf := make([]byte, 15)
+			if n, err := io.ReadFull(m, buf); err != nil || n != 15 || string(buf[:5]) != "head-" {
+				return false
+			}
+			want := make([]byte, 10)
+			FillSynthetic(7, 0, want)
+			if !bytes.Equal(buf[5:], want) || generated.Load() != 10 || LivePrefetchers() != before {
+				return false
+			}
+
+			// Seek, Peek и ReadByte работают без префетчера, Stats не видит буферизованных блоков
+			if _, err := m.Seek(-3, io.SeekEnd); err != nil {
+				return false
+			}
+			if peeked, err := m.Peek(2); err != nil || len(peeked) != 2 {
+				return false
+			}
+			if _, err := m.ReadByte(); err != nil {
+				return false
+			}
+			rest, err := io.ReadAll(m)
+			if err != nil || len(rest) != 2 || m.Stats().PrefetchRestarts != 0 || m.Stats().BlocksBuffered != 0 {
+				return false
+			}
+
+			// Поведение совпадает с bytes.Reader на случайных операциях, в том числе с кэшем блоков
+			for seed := int64(1); seed <= 50; seed++ {
+				rnd := rand.New(rand.NewSource(seed))
+				parts, ops := randomDiffCase(rnd, 4096, 60)
+				opts := []Option{WithPrefetch(false)}
+				if seed%2 == 0 {
+					opts = append(opts, WithBlockCache(bufferSize))
+				}
+				if err := checkDifferential(1, parts, ops, opts...); err != nil {
+					fmt.Fprintf(os.Stderr, "seed %d: %v\n", seed, err)
+					return false
+				}
+			}
+			return true
+		},
+	},
+	{
+		name: "Стиль EOF: строгий и ранний на границе источников и в конце потока, в том числе без префетча",
+		run: func() bool {
+			type result struct {
+				n   int
+				err error
+			}
+			// reads читает буферами указанных длин и возвращает (n, err) каждого вызова
+			reads := func(opts []Option, sizes ...int) []result {
+				m := NewMultiReaderWithOptions(2, []SizedReadSeekCloser{NewStringReader("ab"), NewStringReader("cd")}, opts...)
+				defer m.Close()
+				var out []result
+				for _, size := range sizes {
+					n, err := m.Read(make([]byte, size))
+					out = append(out, result{n, err})
+				}
+				return out
+			}
+			equal := func(got []result, want ...result) bool {
+				if len(got) != len(want) {
+					return false
+				}
+				for i := range got {
+					if got[i].n != want[i].n || got[i].err != want[i].err {
+						return false
+					}
+				}
+				return true
+			}
+
+			// По умолчанию EOF приходит вместе с данными, только если буфер не заполнен
+			if !equal(reads(nil, 2, 2, 1), result{2, nil}, result{2, nil}, result{0, io.EOF}) ||
+				!equal(reads(nil, 10), result{4, io.EOF}) {
+				return false
+			}
+			for _, sync := range []bool{false, true} {
+				strict := []Option{WithEOFStyle(EOFStrict), WithPrefetch(!sync)}
+				eager := []Option{WithEOFStyle(EOFEager), WithPrefetch(!sync)}
+				if !equal(reads(strict, 2, 10, 1), result{2, nil}, result{2, nil}, result{0, io.EOF}) ||
+					!equal(reads(eager, 2, 2, 1), result{2, nil}, result{2, io.EOF}, result{0, io.EOF}) ||
+					!equal(reads(eager, 10, 1), result{4, io.EOF}, result{0, io.EOF}) {
+					return false
+				}
+			}
+			return true
+		},
+	},
+	{
+		name: "Источник, отвечающий (0, nil), даёт io.ErrNoProgress вместо бесконечного цикла префетчера",
+		run: func() bool {
+			// Несколько пустых чтений подряд в пределах лимита переживаются незаметно
+			m := NewMultiReader(2, &stallingReader{SizedReadSeekCloser: newMockStringsReader("data"), stalls: 5})
+			defer m.Close()
+			got, err := io.ReadAll(m)
+			if err != nil || string(got) != "data" {
+				return false
+			}
+
+			// Зависший источник: префетчер, синхронное чтение и ReadAt отдают прочитанное и io.ErrNoProgress
+			for _, opts := range [][]Option{{WithMaxEmptyReads(3)}, {WithMaxEmptyReads(3), WithPrefetch(false)}} {
+				stuck := NewMultiReaderWithOptions(2, []SizedReadSeekCloser{
+					newMockStringsReader("ab"),
+					&stallingReader{SizedReadSeekCloser: newMockStringsReader("cd"), stalls: -1},
+				}, opts...)
+				got, err := io.ReadAll(stuck)
+				if string(got) != "ab" || !errors.Is(err, io.ErrNoProgress) {
+					return false
+				}
+				if _, err := stuck.ReadAt(make([]byte, 2), 2); !errors.Is(err, io.ErrNoProgress) {
+					return false
+				}
+				_ = stuck.Close()
+			}
+			return true
+		},
+	},
+	{
+		name: "NewMultiReaderE отвергает nil, отрицательный размер, переполнение суммы и неперематываемый источник",
+		run: func() bool {
+			zero := func(_ int64, p []byte) (int, error) { clear(p); return len(p), nil }
+			var typedNil *FaultSource
+			bad := [][]SizedReadSeekCloser{
+				{NewStringReader("ok"), nil},
+				{typedNil},
+				{GeneratorSource(-1, zero)},
+				{GeneratorSource(math.MaxInt64, zero), GeneratorSource(1, zero)},
+			}
+			for _, readers := range bad {
+				if m, err := NewMultiReaderE(2, readers); m != nil || !errors.Is(err, ErrInvalidSource) {
+					return false
+				}
+			}
+			_, err := NewMultiReaderE(2, []SizedReadSeekCloser{NewStringReader("a"), nil})
+			if err == nil || !strings.Contains(err.Error(), "source 1") {
+				return false
+			}
+
+			// Без WithSeekProbe сломанный Seek всплывёт только при чтении, с ним - сразу
+			seekErr := errors.New("not seekable")
+			broken := NewFaultSource([]byte("data"), Faults{SeekErr: seekErr})
+			if _, err := NewMultiReaderE(2, []SizedReadSeekCloser{broken}, WithSeekProbe()); !errors.Is(err, ErrInvalidSource) || !errors.Is(err, seekErr) {
+				return false
+			}
+
+			m, err := NewMultiReaderE(2, []SizedReadSeekCloser{NewStringReader("he"), NewStringReader("llo")}, WithSeekProbe())
+			if err != nil {
+				return false
+			}
+			defer m.Close()
+			got, err := io.ReadAll(m)
+			return err == nil && string(got) == "hello"
+		},
+	},
+	{
+		name: "WithFollow и Refresh: чтение продолжается за старым концом дописываемого файла",
+		run: func() bool {
+			path := filepath.Join(os.TempDir(), fmt.Sprintf("multireader-follow-%d.log", time.Now().UnixNano()))
+			defer os.Remove(path)
+			if err := os.WriteFile(path, []byte("hello"), 0o600); err != nil {
+				return false
+			}
+			appendLog := func(s string) {
+				f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+				_, _ = f.WriteString(s)
+				_ = f.Close()
+			}
+			src, err := OpenFileSource(path)
+			if err != nil {
+				return false
+			}
+			m := NewMultiReaderWithOptions(2, []SizedReadSeekCloser{NewStringReader("log:"), src}, WithFollow(0), WithBlockCache(bufferSize))
+			defer m.Close()
+
+			// Без роста на конце - временный ErrWouldBlock вместо EOF
+			readAvailable := func() string {
+				var out []byte
+				buf := make([]byte, 3)
+				for {
+					n, err := m.Read(buf)
+					out = append(out, buf[:n]...)
+					if err != nil {
+						if !errors.Is(err, ErrWouldBlock) {
+							return "error: " + err.Error()
+						}
+						return string(out)
+					}
+				}
+			}
+			if readAvailable() != "log:hello" {
+				return false
+			}
+			appendLog(" world")
+			if got := readAvailable(); got != " world" || m.Size() != int64(len("log:hello world")) {
+				return false
+			}
+			// Кэш не отдаёт устаревший неполный последний блок
+			got := make([]byte, 11)
+			if n, err := m.ReadAt(got, 4); err != nil || n != 11 || string(got) != "hello world" {
+				return false
+			}
+
+			// С интервалом опроса Read ждёт новых данных, а Close его будит
+			blocking := NewMultiReaderWithOptions(2, []SizedReadSeekCloser{NewStringReader("x")}, WithFollow(5*time.Millisecond))
+			if _, err := io.ReadFull(blocking, make([]byte, 1)); err != nil {
+				return false
+			}
+			done := make(chan error, 1)
+			go func() {
+				_, err := blocking.Read(make([]byte, 1))
+				done <- err
+			}()
+			time.Sleep(20 * time.Millisecond)
+			_ = blocking.Close()
+			if err := <-done; !errors.Is(err, io.ErrClosedPipe) {
+				return false
+			}
+
+			// Изменился не последний источник - ErrSourceChanged
+			first, err := OpenFileSource(path)
+			if err != nil {
+				return false
+			}
+			changed := NewMultiReader(2, first, NewStringReader("tail"))
+			defer changed.Close()
+			appendLog("!")
+			_, err = changed.Refresh()
+			return errors.Is(err, ErrSourceChanged)
+		},
+	},
+	{
+		name: "SeekToSource, NextSource и CurrentSource переходят по границам источников, пропуская пустые",
+		run: func() bool {
+			m := NewMultiReader(2, NewStringReader("rec-a"), NewStringReader(""), NewStringReader("rec-bb"), NewStringReader("rec-c"))
+			defer m.Close()
+
+			if pos, err := m.SeekToSource(2); err != nil || pos != 5 || m.CurrentSource() != 2 {
+				return false
+			}
+			if b, err := m.ReadByte(); err != nil || b != 'r' {
+				return false
+			}
+			if pos, err := m.NextSource(); err != nil || pos != 11 || m.CurrentSource() != 3 {
+				return false
+			}
+			got, err := io.ReadAll(m)
+			if err != nil || string(got) != "rec-c" || m.CurrentSource() != 4 {
+				return false
+			}
+			if _, err := m.NextSource(); !errors.Is(err, io.EOF) {
+				return false
+			}
+
+			// Пустой источник начинается там же, где следующий; вне диапазона - ошибка без сдвига курсора
+			if pos, err := m.SeekToSource(1); err != nil || pos != 5 || m.CurrentSource() != 2 {
+				return false
+			}
+			if _, err := m.SeekToSource(5); err == nil {
+				return false
+			}
+			if _, err := m.SeekToSource(-1); err == nil {
+				return false
+			}
+			pos, err := m.Seek(0, io.SeekCurrent)
+			return err == nil && pos == 5
+		},
+	},
+	{
+		name: "OnSourceEnter/OnSourceExit: вход и выход из источников с именем и абсолютной позицией",
+		run: func() bool {
+			var events []string
+			hooks := &Hooks{
+				OnSourceEnter: func(ev SourceEvent) {
+					events = append(events, fmt.Sprintf("enter %d %s %d", ev.Index, ev.Name, ev.Offset))
+				},
+				OnSourceExit: func(ev SourceEvent) {
+					events = append(events, fmt.Sprintf("exit %d %s %d", ev.Index, ev.Name, ev.Offset))
+				},
+			}
+			named := func(name, data string) LazySpec {
+				return LazySpec{Name: name, Size: int64(len(data)), Open: func(context.Context) (SizedReadSeekCloser, error) {
+					return NewStringReader(data), nil
+				}}
+			}
+			readers := []SizedReadSeekCloser{
+				newLazySource(context.Background(), named("a.log", "aaaa")),
+				NewStringReader(""),
+				newLazySource(context.Background(), named("b.log", "bbbbbb")),
+				NewStringReader("cc"),
+			}
+			m := NewMultiReaderWithOptions(2, readers, WithHooks(hooks))
+			defer m.Close()
+
+			// Чтение пересекает границы внутри одного Read; пустой источник не даёт событий
+			buf := make([]byte, 6)
+			if _, err := io.ReadFull(m, buf); err != nil {
+				return false
+			}
+			// Seek из середины b.log в c: выход по последнему отданному байту
+			if _, err := m.SeekToSource(3); err != nil {
+				return false
+			}
+			if _, err := io.ReadAll(m); err != nil {
+				return false
+			}
+			// Повторный вход в дочитанный источник
+			if _, err := m.SeekToSource(3); err != nil {
+				return false
+			}
+			if _, err := m.ReadByte(); err != nil {
+				return false
+			}
+
+			want := []string{
+				"enter 0 a.log 0", "exit 0 a.log 4", "enter 2 b.log 4",
+				"exit 2 b.log 6", "enter 3  10", "exit 3  12",
+				"enter 3  10",
+			}
+			return slices.Equal(events, want)
+		},
+	},
+	{
+		name: "Stats: байты, чтения, Seek и время чтений по каждому источнику",
+		run: func() bool {
+			slow := NewFaultSource(bytes.Repeat([]byte("s"), 100), Faults{Latency: 5 * time.Millisecond})
+			readers := []SizedReadSeekCloser{slow, NewStringReader(""), NewStringReader("fast")}
+			m := NewMultiReader(2, readers...)
+			defer m.Close()
+
+			if data, err := io.ReadAll(m); err != nil || len(data) != 104 {
+				return false
+			}
+			st := m.Stats().Sources
+			if len(st) != 3 || st[0].BytesRead != 100 || st[1].BytesRead != 0 || st[2].BytesRead != 4 {
+				return false
+			}
+			if st[0].ReadCalls == 0 || st[0].SeekCalls == 0 || st[0].ReadTime < 5*time.Millisecond || st[2].ReadTime >= st[0].ReadTime {
+				return false
+			}
+
+			// ReadAt без позиционного интерфейса источника делает Seek и чтение
+			if _, err := m.ReadAt(make([]byte, 9), 95); err != nil {
+				return false
+			}
+			after := m.Stats().Sources
+			return after[0].SeekCalls == st[0].SeekCalls+1 && after[0].BytesRead == 105 &&
+				after[2].SeekCalls == st[2].SeekCalls+1 && after[2].BytesRead == 8
+		},
+	},
+	{
+		name: "Register: счётчики открытых мультиридеров, префетчеров и отданных байт в expvar",
+		run: func() bool {
+			Register()
+			Register() // Повторная регистрация не паникует
+			vars := func() (v map[string]int64) {
+				if err := json.Unmarshal([]byte(expvar.Get("multireader").String()), &v); err != nil {
+					return nil
+				}
+				return v
+			}
+
+			m := NewMultiReader(2, NewStringReader("hello"), NewStringReader(" world"))
+			before := vars()
+			if before == nil || before["open_readers"] < 1 {
+				return false
+			}
+			if _, err := io.ReadAll(m); err != nil {
+				return false
+			}
+			if _, err := m.ReadAt(make([]byte, 5), 6); err != nil {
+				return false
+			}
+			if got := vars()["bytes_streamed"] - before["bytes_streamed"]; got != 16 {
+				return false
+			}
+			open := vars()["open_readers"]
+			_ = m.Close()
+			_ = m.Close() // Повторный Close не уменьшает счётчик ещё раз
+			return vars()["open_readers"] == open-1
+		},
+	},
+	{
+		name: "Dump: стабильный снимок позиции, окна, префетчера и границ источников",
+		run: func() bool {
+			open := func(context.Context) (SizedReadSeekCloser, error) { return NewStringReader("hello"), nil }
+			readers := []SizedReadSeekCloser{
+				newLazySource(context.Background(), LazySpec{Size: 5, Open: open, Name: "a.log"}),
+				NewStringReader(""),
+				NewStringReader("world!!"),
+			}
+			m := NewMultiReaderWithOptions(2, readers, WithPrefetch(false))
+			defer m.Close()
+			if _, err := m.Read(make([]byte, 7)); err != nil {
+				return false
+			}
+
+			var b strings.Builder
+			if err := m.Dump(&b); err != nil {
+				return false
+			}
+			want := "MultiReader size=12 pos=7 closed=false\n" +
+				"window: [7, 7) len=0\n" +
+				"prefetch: disabled queued_blocks=0 buffered_bytes=0 buffers=2\n" +
+				"stalls: producer=0 consumer=0 restarts=0\n" +
+				"sources: 3\n" +
+				"   #  start  end  size  read  name\n" +
+				"   0  0      5    5     5     a.log\n" +
+				"   1  5      5    0     0     \n" +
+				">  2  5      12   7     2     \n"
+			return b.String() == want
+		},
+	},
+	{
+		name: "WithTrace и ReplayTrace: запись операций и воспроизведение над другой реализацией",
+		run: func() bool {
+			parts := func() []SizedReadSeekCloser {
+				return []SizedReadSeekCloser{NewStringReader("hello"), NewStringReader(""), NewStringReader(" world")}
+			}
+			var trace bytes.Buffer
+			m := NewMultiReaderWithOptions(2, parts(), WithTrace(&trace), WithPrefetch(false))
+			_, _ = m.Read(make([]byte, 3))
+			_, _ = m.Seek(-2, io.SeekEnd)
+			_, _ = m.Read(make([]byte, 8))
+			_, _ = m.Read(make([]byte, 8))
+			_, _ = m.Seek(100, io.SeekStart)
+			_, _ = m.ReadAt(make([]byte, 4), 4)
+			_ = m.Close()
+			_, _ = m.Read(make([]byte, 1))
+
+			want := "R 3 3 e50bf11b nil\n" +
+				"S -2 2 9 nil\n" +
+				"R 8 2 c24e9315 nil\n" +
+				"R 8 0 00000000 EOF\n" +
+				"S 100 0 0 error\n" +
+				"A 4 4 4 c73fed51 nil\n" +
+				"R 1 0 00000000 closed\n"
+			if trace.String() != want {
+				return false
+			}
+
+			// Воспроизведение над свежим мультиридером с теми же данными сходится
+			replay := NewMultiReaderWithOptions(2, parts(), WithPrefetch(false))
+			defer replay.Close()
+			if err := ReplayTrace(strings.NewReader(strings.TrimSuffix(want, "R 1 0 00000000 closed\n")), replay); err != nil {
+				return false
+			}
+
+			// Испорченный байт во втором Read ловится на строке 3
+			var mismatch *TraceMismatchError
+			err := ReplayTrace(strings.NewReader(want), NewMultiReader(2, NewStringReader("hello worXd")))
+			return errors.As(err, &mismatch) && mismatch.Line == 3
+		},
+	},
+	{
+		name: "SourcePanicError: паника источника в префетчере и в ReadAt становится ошибкой, а не падением процесса",
+		run: func() bool {
+			readers := []SizedReadSeekCloser{NewStringReader("hello"), &panickingReader{NewStringReader("world")}}
+			m := NewMultiReader(2, readers...)
+			defer m.Close()
+
+			data, err := io.ReadAll(m)
+			var panicErr *SourcePanicError
+			var runtimeErr runtime.Error
+			if string(data) != "hello" || !errors.As(err, &panicErr) || panicErr.Index != 1 || len(panicErr.Stack) == 0 ||
+				!errors.As(err, &runtimeErr) {
+				return false
+			}
+
+			n, err := m.ReadAt(make([]byte, 4), 3)
+			return n == 2 && errors.As(err, &panicErr) && panicErr.Index == 1
+		},
+	},
+	{
+		name: "SetReadDeadline и WithReadTimeout: Read, ждущий зависший источник, отдаёт частичные данные и ErrDeadlineExceeded",
+		run: func() bool {
+			release := make(chan struct{})
+			gen := func(off int64, p []byte) (int, error) {
+				if off >= bufferSize {
+					<-release // Второй блок зависает до отпускания
+				}
+				return len(p), nil
+			}
+			m := NewMultiReader(2, GeneratorSource(2*bufferSize, gen))
+			defer m.Close()
+			t := NewMultiReaderWithOptions(2, []SizedReadSeekCloser{GeneratorSource(2*bufferSize, gen)}, WithReadTimeout(20*time.Millisecond))
+			defer t.Close()
+			defer close(release) // Отпускаем источники до Close
+
+			if err := m.SetReadDeadline(time.Now().Add(20 * time.Millisecond)); err != nil {
+				return false
+			}
+			p := make([]byte, 2*bufferSize)
+			n, err := m.Read(p)
+			if n != bufferSize || !errors.Is(err, os.ErrDeadlineExceeded) {
+				return false
+			}
+
+			// Срок в прошлом, выставленный из другой горутины, будит уже заблокированный Read
+			_ = m.SetReadDeadline(time.Time{})
+			go func() {
+				time.Sleep(10 * time.Millisecond)
+				_ = m.SetReadDeadline(time.Now().Add(-time.Second))
+			}()
+			if n, err := m.Read(p); n != 0 || !errors.Is(err, os.ErrDeadlineExceeded) {
+				return false
+			}
+
+			// WithReadTimeout ограничивает каждый Read отдельно
+			if n, err := t.Read(p); n != bufferSize || !errors.Is(err, os.ErrDeadlineExceeded) {
+				return false
+			}
+			if n, err := t.Read(p); n != 0 || !errors.Is(err, os.ErrDeadlineExceeded) {
+				return false
+			}
+			return true
+		},
+	},
+	{
+		name: "WithWatchdog: зависшее чтение отмечается, а с Retries отменяется и уходит на другую реплику",
+		run: func() bool {
+			var mu sync.Mutex
+			var events []StuckRead
+			onStuck := func(e StuckRead) {
+				mu.Lock()
+				events = append(events, e)
+				mu.Unlock()
+			}
+
+			// Без повторов сторож только сообщает: медленное чтение дочитывается
+			slow := NewFaultSource([]byte("slow data"), Faults{Latency: 60 * time.Millisecond})
+			m := NewMultiReaderWithOptions(2, []SizedReadSeekCloser{NewStringReader("fast "), slow},
+				WithWatchdog(Watchdog{Threshold: 20 * time.Millisecond, OnStuck: onStuck}))
+			defer m.Close()
+			if data, err := io.ReadAll(m); err != nil || string(data) != "fast slow data" {
+				return false
+			}
+			mu.Lock()
+			reported := slices.Clone(events)
+			events = nil
+			mu.Unlock()
+			if len(reported) != 1 || reported[0].Index != 1 || reported[0].Offset != 5 || reported[0].Elapsed < 20*time.Millisecond ||
+				m.Stats().StuckReads != 1 {
+				return false
+			}
+
+			// С повтором зависшая реплика прерывается, а чтение продолжается с быстрой
+			stuck := NewFaultSource([]byte("replica"), Faults{Latency: time.Hour})
+			seg, err := ReplicaSource(LatencyPolicy(0.5, time.Minute), 0, stuck, NewStringReader("replica"))
+			if err != nil {
+				return false
+			}
+			r := NewMultiReaderWithOptions(2, []SizedReadSeekCloser{seg},
+				WithWatchdog(Watchdog{Threshold: 20 * time.Millisecond, OnStuck: onStuck, Retries: 1}))
+			defer r.Close()
+			if data, err := io.ReadAll(r); err != nil || string(data) != "replica" {
+				return false
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			return len(events) == 1 && events[0].Attempt == 0 && r.Stats().StuckReads == 1
+		},
+	},
+	{
+		name: "Мелкие Read: быстрый путь отдаёт те же байты, уведомляет подписчиков и соблюдает EOFStyle",
+		run: func() bool {
+			want := make([]byte, 3*bufferSize/2)
+			FillSynthetic(7, 0, want)
+			for _, opts := range [][]Option{nil, {WithPrefetch(false)}} {
+				readers := []SizedReadSeekCloser{NewBytesReader(want[:bufferSize/3]), NewBytesReader(want[bufferSize/3:])}
+				h := sha256.New()
+				m := NewMultiReaderWithOptions(2, readers, append(opts, WithDigest(h), WithEOFStyle(EOFEager))...)
+				var got []byte
+				buf := make([]byte, smallReadMax)
+				for i := 0; ; i++ {
+					n, err := m.Read(buf[:1+i%smallReadMax])
+					got = append(got, buf[:n]...)
+					if err == io.EOF {
+						if n == 0 { // EOFEager отдаёт EOF вместе с последними байтами
+							return false
+						}
+						break
+					}
+					if err != nil {
+						return false
+					}
+				}
+				digest, digested, err := m.Digest()
+				_ = m.Close()
+				sum := sha256.Sum256(want)
+				if !bytes.Equal(got, want) || err != nil || digested != int64(len(want)) || !bytes.Equal(digest, sum[:]) {
+					return false
+				}
+			}
+			return true
+		},
+	},
+	{
+		name: "Size и ReadAt не берут мьютекс окна: работают, пока он занят потребителем",
+		run: func() bool {
+			m := NewMultiReader(2, NewStringReader("hello"), NewStringReader(" world"))
+			m.mu.Lock() // Имитируем потребителя, держащего окно
+			done := make(chan bool, 1)
+			go func() {
+				p := make([]byte, 5)
+				n, err := m.ReadAt(p, 6)
+				done <- m.Size() == 11 && n == 5 && err == nil && string(p) == "world"
+			}()
+			var ok bool
+			select {
+			case ok = <-done:
+			case <-time.After(time.Second):
+			}
+			m.mu.Unlock()
+			_ = m.Close()
+
+			_, err := m.ReadAt(make([]byte, 1), 0)
+			return ok && errors.Is(err, io.ErrClosedPipe)
+		},
+	},
+	{
+		name: "ReadContext через обёртки: Seek за окно и Close сразу прерывают зависшее чтение ленивого и потокового источников",
+		run: func() bool {
+			within := func(f func()) bool {
+				done := make(chan struct{})
+				go func() {
+					f()
+					close(done)
+				}()
+				select {
+				case <-done:
+					return true
+				case <-time.After(time.Second):
+					return false
+				}
+			}
+
+			// Ленивый источник передаёт контекст префетчера открытому ContextReader
+			hung := LazySource(context.Background(), 8, func(context.Context) (SizedReadSeekCloser, error) {
+				return NewFaultSource([]byte("never..."), Faults{Latency: time.Hour}), nil
+			})
+			m := NewMultiReaderWithOptions(2, []SizedReadSeekCloser{hung}, WithReadTimeout(10*time.Millisecond))
+			if _, err := m.Read(make([]byte, 4)); !errors.Is(err, os.ErrDeadlineExceeded) {
+				return false
+			}
+			if !within(func() { _ = m.Close() }) {
+				return false
+			}
+
+			// Поток без контекста (тело ответа, pipe) прерывается закрытием
+			pr, pw := io.Pipe()
+			defer pw.Close()
+			stream := newReopenSource(10, func() (io.ReadCloser, error) { return pr, nil })
+			r := NewMultiReaderWithOptions(2, []SizedReadSeekCloser{stream, NewStringReader("tail")}, WithReadTimeout(10*time.Millisecond))
+			defer r.Close()
+			if _, err := r.Read(make([]byte, 4)); !errors.Is(err, os.ErrDeadlineExceeded) {
+				return false
+			}
+			var seekErr error
+			if !within(func() { _, seekErr = r.Seek(10, io.SeekStart) }) || seekErr != nil {
+				return false
+			}
+			rest, err := io.ReadAll(r)
+			return err == nil && string(rest) == "tail"
+		},
+	},
+	{
+		name: "Seek вперёд в блоки из очереди префетчера берёт их в окно без перезапуска",
+		run: func() bool {
+			gen := GeneratorSource(8*bufferSize, func(off int64, p []byte) (int, error) {
+				FillSynthetic(3, off, p)
+				return len(p), nil
+			})
+			m := NewMultiReader(3, gen)
+			defer m.Close()
+
+			if _, err := m.Read(make([]byte, 1)); err != nil {
+				return false
+			}
+			deadline := time.Now().Add(time.Second)
+			for m.Stats().BytesBuffered != 4*bufferSize-1 { // Окно и три блока в очереди
+				if time.Now().After(deadline) {
+					return false
+				}
+				time.Sleep(time.Millisecond)
+			}
+
+			for _, pos := range []int64{bufferSize + 5, 3*bufferSize + 7} {
+				if _, err := m.Seek(pos, io.SeekStart); err != nil {
+					return false
+				}
+				got, want := make([]byte, 100), make([]byte, 100)
+				FillSynthetic(3, pos, want)
+				if _, err := io.ReadFull(m, got); err != nil || !bytes.Equal(got, want) {
+					return false
+				}
+			}
+			if m.Stats().PrefetchRestarts != 0 {
+				return false
+			}
+
+			// Назад и за пределы очереди - обычный перезапуск
+			if _, err := m.Seek(0, io.SeekStart); err != nil {
+				return false
+			}
+			got, want := make([]byte, 10), make([]byte, 10)
+			FillSynthetic(3, 0, want)
+			if _, err := io.ReadFull(m, got); err != nil || !bytes.Equal(got, want) {
+				return false
+			}
+			return m.Stats().PrefetchRestarts == 1
+		},
+	},
+	{
+		name: "WithWindowCompression: сжимаемые данные уходят в очередь дальше buffersNum блоков, чтение побайтно совпадает",
+		run: func() bool {
+			line := "2026-10-16T10:00:00Z INFO request served path=/api/v1/items status=200\n"
+			text := []byte(strings.Repeat(line, 8*bufferSize/len(line)))
+			random := make([]byte, 2*bufferSize)
+			FillSynthetic(9, 0, random)
+			m := NewMultiReaderWithOptions(1, []SizedReadSeekCloser{NewBytesReader(text), NewBytesReader(random)},
+				WithWindowCompression())
+			defer m.Close()
+
+			head := make([]byte, 10)
+			if _, err := io.ReadFull(m, head); err != nil || !bytes.Equal(head, text[:10]) {
+				return false
+			}
+			deadline := time.Now().Add(time.Second)
+			for m.Stats().BlocksBuffered < 2 { // Сжатые блоки текста умещаются в бюджет одного блока
+				if time.Now().After(deadline) {
+					return false
+				}
+				time.Sleep(time.Millisecond)
+			}
+			if m.Stats().CompressionSaved == 0 {
+				return false
+			}
+
+			rest, err := io.ReadAll(m)
+			return err == nil && bytes.Equal(rest, append(text[10:], random...))
+		},
+	},
+	{
+		name: "WithWindowCompression: Seek вперёд в сжатые блоки очереди и назад",
+		run: func() bool {
+			text := bytes.Repeat([]byte("abcdefgh"), 4*bufferSize/8)
+			m := NewMultiReaderWithOptions(2, []SizedReadSeekCloser{NewBytesReader(text)}, WithWindowCompression())
+			defer m.Close()
+
+			for _, pos := range []int64{1, 2*bufferSize + 3, 5} {
+				if _, err := m.Seek(pos, io.SeekStart); err != nil {
+					return false
+				}
+				got := make([]byte, 16)
+				if _, err := io.ReadFull(m, got); err != nil || !bytes.Equal(got, text[pos:pos+16]) {
+					return false
+				}
+			}
+			return true
+		},
+	},
+	{
+		name: "Учёт памяти: Stats.WindowMemory и CacheMemory, хуки OnEvict и OnDrop при вытеснении из кэша",
+		run: func() bool {
+			m := NewMultiReader(2, SyntheticSource(8*bufferSize, 1))
+			defer m.Close()
+			if _, err := m.Read(make([]byte, 1)); err != nil {
+				return false
+			}
+			deadline := time.Now().Add(time.Second)
+			for m.Stats().WindowMemory != 3*bufferSize { // Массив окна и два блока в очереди
+				if time.Now().After(deadline) {
+					return false
+				}
+				time.Sleep(time.Millisecond)
+			}
+			if _, err := m.Seek(6*bufferSize, io.SeekStart); err != nil || m.Stats().WindowMemory != 0 {
+				return false
+			}
+
+			var mu sync.Mutex
+			var evicted, dropped []EvictEvent
+			hooks := &Hooks{
+				OnEvict: func(ev EvictEvent) { mu.Lock(); evicted = append(evicted, ev); mu.Unlock() },
+				OnDrop:  func(ev EvictEvent) { mu.Lock(); dropped = append(dropped, ev); mu.Unlock() },
+			}
+			c := NewMultiReaderWithOptions(1, []SizedReadSeekCloser{SyntheticSource(3*bufferSize, 2)},
+				WithHooks(hooks), WithBlockCache(bufferSize), WithDiskCache("", bufferSize))
+			defer c.Close()
+			p := make([]byte, 10)
+			for _, off := range []int64{0, bufferSize, 2 * bufferSize} {
+				if _, err := c.ReadAt(p, off); err != nil {
+					return false
+				}
+			}
+
+			// Блок 0 ушёл на диск, блок 1 - тоже, вытеснив оттуда блок 0
+			mu.Lock()
+			defer mu.Unlock()
+			st := c.Stats()
+			return slices.Equal(evicted, []EvictEvent{{0, bufferSize, true}, {bufferSize, bufferSize, true}}) &&
+				slices.Equal(dropped, []EvictEvent{{0, bufferSize, false}}) &&
+				st.CacheEvictions == 2 && st.CacheMemory == bufferSize
+		},
+	},
+	{
+		name: "WithMemoryLimitAware: у GOMEMLIMIT окно сжимается до одного блока и растёт, когда давление спадает",
+		run: func() bool {
+			if debug.SetMemoryLimit(-1) == math.MaxInt64 && runtimeMemoryPressure() != 0 {
+				return false // Без лимита давления нет
+			}
+			prev := debug.SetMemoryLimit(1 << 40)
+			pressure := runtimeMemoryPressure()
+			debug.SetMemoryLimit(prev)
+			if pressure <= 0 || pressure >= 1 {
+				return false
+			}
+
+			var high atomic.Bool
+			high.Store(true)
+			m := NewMultiReaderWithOptions(4, []SizedReadSeekCloser{SyntheticSource(8*bufferSize, 4)}, WithMemoryLimitAware(0.9))
+			defer m.Close()
+			m.memPressure.probe = func() float64 {
+				if high.Load() {
+					return 0.95
+				}
+				return 0.5
+			}
+
+			if _, err := m.Read(make([]byte, 1)); err != nil {
+				return false
+			}
+			waitStats := func(ok func(Stats) bool) bool {
+				deadline := time.Now().Add(time.Second)
+				for !ok(m.Stats()) {
+					if time.Now().After(deadline) {
+						return false
+					}
+					time.Sleep(time.Millisecond)
+				}
+				return true
+			}
+			if !waitStats(func(st Stats) bool { return st.PressureStalls > 0 && st.BlocksBuffered == 1 }) {
+				return false
+			}
+			time.Sleep(3 * memoryPressurePoll) // Префетчер перепроверяет давление, но блоков не добавляет
+			if m.Stats().BlocksBuffered != 1 {
+				return false
+			}
+			high.Store(false)
+			if !waitStats(func(st Stats) bool { return st.BlocksBuffered == 4 }) {
+				return false
+			}
+
+			got, err := io.ReadAll(m)
+			want := make([]byte, 8*bufferSize-1)
+			FillSynthetic(4, 1, want)
+			return err == nil && bytes.Equal(got, want)
+		},
+	},
+	{
+		name: "ReadRange и WriteRangeTo: произвольный диапазон через границы источников без сдвига курсора",
+		run: func() bool {
+			parts := make([][]byte, 3)
+			for i := range parts {
+				parts[i] = make([]byte, bufferSize+i*1000)
+				FillSynthetic(int64(i), 0, parts[i])
+			}
+			all := bytes.Join(parts, nil)
+			m := NewMultiReader(2, NewBytesReader(parts[0]), NewBytesReader(parts[1]), NewBytesReader(parts[2]))
+			defer m.Close()
+
+			head := make([]byte, 7)
+			if _, err := io.ReadFull(m, head); err != nil {
+				return false
+			}
+			start, end := int64(bufferSize-5), int64(2*bufferSize+1500)
+			got, err := m.ReadRange(start, end)
+			if err != nil || !bytes.Equal(got, all[start:end]) {
+				return false
+			}
+			var buf bytes.Buffer
+			if n, err := m.WriteRangeTo(&buf, start, end); err != nil || n != end-start || !bytes.Equal(buf.Bytes(), all[start:end]) {
+				return false
+			}
+			if empty, err := m.ReadRange(5, 5); err != nil || len(empty) != 0 {
+				return false
+			}
+
+			// Курсор остался на месте
+			next := make([]byte, 10)
+			if _, err := io.ReadFull(m, next); err != nil || !bytes.Equal(next, all[7:17]) {
+				return false
+			}
+
+			var rangeErr *RangeError
+			if _, err := m.ReadRange(10, 5); !errors.As(err, &rangeErr) || rangeErr.Source != -1 {
+				return false
+			}
+			if n, err := m.WriteRangeTo(&buf, 0, m.Size()+1); !errors.As(err, &rangeErr) || n != 0 {
+				return false
+			}
+			return true
+		},
+	},
+	{
+		name: "WriteRangeTo: сбой источника - *RangeError с позицией, ошибка писателя возвращается как есть",
+		run: func() bool {
+			errBad := errors.New("bad sector")
+			a := newMockStringsReader("aaaa")
+			b := newMockStringsReader("bbbb")
+			b.readErr = errBad
+			m := NewMultiReader(2, a, b)
+			defer m.Close()
+
+			var buf bytes.Buffer
+			n, err := m.WriteRangeTo(&buf, 1, 8)
+			var rangeErr *RangeError
+			if !errors.As(err, &rangeErr) || !errors.Is(err, errBad) || rangeErr.Offset != 4 || rangeErr.Source != 1 {
+				return false
+			}
+			if n != 3 || buf.String() != "aaa" {
+				return false
+			}
+
+			errFull := errors.New("disk full")
+			m2 := NewMultiReader(2, NewStringReader("0123456789"))
+			defer m2.Close()
+			n, err = m2.WriteRangeTo(errWriter{errFull}, 2, 9)
+			return errors.Is(err, errFull) && !errors.As(err, &rangeErr) && n == 0
+		},
+	},
+	{
+		name: "SniffContentType: тип по первым 512 байтам склейки без сдвига курсора",
+		run: func() bool {
+			m := NewMultiReader(2, NewStringReader("\x89PN"), NewStringReader("G\r\n\x1a\n"), NewStringReader(strings.Repeat("x", 1000)))
+			defer m.Close()
+			if _, err := m.Seek(600, io.SeekStart); err != nil {
+				return false
+			}
+			if ct, err := m.SniffContentType(); err != nil || ct != "image/png" {
+				return false
+			}
+			if pos, err := m.Seek(0, io.SeekCurrent); err != nil || pos != 600 {
+				return false
+			}
+
+			html := NewMultiReader(1, NewStringReader("<!DOCTYPE html><title>"), NewStringReader("t</title>"))
+			if ct, err := html.SniffContentType(); err != nil || ct != "text/html; charset=utf-8" {
+				return false
+			}
+			_ = html.Close()
+			_, err := html.SniffContentType()
+			return errors.Is(err, io.ErrClosedPipe)
+		},
+	},
+	{
+		name: "WithParallelReads: Close дожидается чтений ReadAt, оставшихся в полёте",
+		run: func() bool {
+			data := strings.Repeat("p", 8*bufferSize)
+			slow := &slowReaderAt{ra: strings.NewReader(data), delay: 50 * time.Millisecond}
+			m := NewMultiReaderWithOptions(1, []SizedReadSeekCloser{NewReaderAtSource(slow, int64(len(data)))},
+				WithParallelReads(4))
+			if _, err := m.Read(make([]byte, 1)); err != nil {
+				return false
+			}
+			if slow.inFlight.Load() == 0 { // Следующие блоки ещё читаются
+				return false
+			}
+			return m.Close() == nil && slow.inFlight.Load() == 0
+		},
+	},
+	{
+		name: "WithFollow: ReadAt и Size безопасны одновременно с ростом потока в Refresh",
+		run: func() bool {
+			path := filepath.Join(os.TempDir(), fmt.Sprintf("multireader-follow-race-%d.log", time.Now().UnixNano()))
+			defer os.Remove(path)
+			if err := os.WriteFile(path, []byte("0"), 0o600); err != nil {
+				return false
+			}
+			src, err := OpenFileSource(path)
+			if err != nil {
+				return false
+			}
+			m := NewMultiReaderWithOptions(2, []SizedReadSeekCloser{NewStringReader("log:"), src}, WithFollow(0), WithBlockCache(bufferSize))
+			defer m.Close()
+
+			stop := make(chan struct{})
+			bad := make(chan string, 1)
+			go func() {
+				defer close(bad)
+				buf := make([]byte, 5)
+				for {
+					select {
+					case <-stop:
+						return
+					default:
+					}
+					size := m.Size()
+					if n, err := m.ReadAt(buf, 0); n != 5 || (err != nil && err != io.EOF) || string(buf) != "log:0" || size < 5 {
+						bad <- string(buf[:n])
+						return
+					}
+				}
+			}()
+
+			f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+			if err != nil {
+				return false
+			}
+			defer f.Close()
+			var got []byte
+			buf := make([]byte, 64)
+			for i := 1; i <= 50; i++ {
+				_, _ = f.Write([]byte{byte('0' + i%10)})
+				for {
+					n, err := m.Read(buf)
+					got = append(got, buf[:n]...)
+					if errors.Is(err, ErrWouldBlock) {
+						break
+					}
+					if err != nil {
+						return false
+					}
+				}
+			}
+			close(stop)
+			if _, failed := <-bad; failed {
+				return false
+			}
+
+			return len(got) == 4+51 && m.Size() == 4+51
+		},
+	},
+	{
+		name: "WithHooks: nil не задаёт перехватчиков, а один набор можно передать нескольким мультиридерам",
+		run: func() bool {
+			plain := NewMultiReaderWithOptions(2, []SizedReadSeekCloser{NewStringReader("ab"), NewStringReader("cd")}, WithHooks(nil))
+			got, err := io.ReadAll(plain)
+			if err != nil || string(got) != "abcd" || plain.Close() != nil {
+				return false
+			}
+
+			// Позиция потребителя своя у каждого мультиридера: второй тоже начинает с from = -1
+			var mu sync.Mutex
+			var switches []string
+			hooks := &Hooks{OnSourceSwitch: func(from, to int) {
+				mu.Lock()
+				switches = append(switches, fmt.Sprintf("%d->%d", from, to))
+				mu.Unlock()
+			}}
+			for range 2 {
+				m := NewMultiReaderWithOptions(2, []SizedReadSeekCloser{NewStringReader("ab"), NewStringReader("cd")}, WithHooks(hooks))
+				if _, err := io.ReadAll(m); err != nil || m.Close() != nil {
+					return false
+				}
+			}
+
+			return slices.Equal(switches, []string{"-1->0", "0->1", "-1->0", "0->1"})
+		},
+	},
+	{
+		name: "CloseContext: незакрытые по истечении ctx источники закрываются в фоне и перечисляются в ошибке",
+		run: func() bool {
+			a, b, c := newMockStringsReader("a"), newMockStringsReader("b"), newMockStringsReader("c")
+			b.closeWait = 200 * time.Millisecond
+			m := NewMultiReader(1, a, b, c)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+			err := m.CloseContext(ctx)
+			if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "sources [1 2]") {
+				return false
+			}
+			// Источник за зависшим тоже закрывается - в фоне, который дожидается WaitIdle
+			if err := m.WaitIdle(context.Background()); err != nil {
+				return false
+			}
+			return a.closed && b.closed && c.closed
+		},
+	},
+	{
+		name: "WithDiskCache: после закрытия дисковый уровень не создаёт новый файл и не отдаёт блоки",
+		run: func() bool {
+			dir, err := os.MkdirTemp("", "multireader-disk-closed")
+			if err != nil {
+				return false
+			}
+			defer os.RemoveAll(dir)
+
+			d := newDiskTier(dir, 4*bufferSize)
+			block := bytes.Repeat([]byte{'x'}, bufferSize)
+			if stored, _ := d.put(0, block, time.Now()); !stored {
+				return false
+			}
+			if d.close() != nil {
+				return false
+			}
+			// Вытеснение, запоздавшее после Close, не должно оставить файл
+			if stored, _ := d.put(1, block, time.Now()); stored {
+				return false
+			}
+			if _, _, ok := d.get(0); ok {
+				return false
+			}
+			files, err := os.ReadDir(dir)
+			return err == nil && len(files) == 0 && d.close() == nil
+		},
+	},
+	{
+		name: "Мелкий Read из окна: Shutdown дожидается его хуков, как и обычного Read",
+		run: func() bool {
+			var calls atomic.Int32
+			inHook, release := make(chan struct{}), make(chan struct{})
+			hooks := &Hooks{OnRead: func(int64, []byte, error) {
+				if calls.Add(1) == 2 { // Второй Read идёт быстрым путём - окно уже заполнено
+					close(inHook)
+					<-release
+				}
+			}}
+			m := NewMultiReaderWithOptions(2, []SizedReadSeekCloser{NewStringReader("abcdef")}, WithHooks(hooks))
+			buf := make([]byte, 1)
+			if _, err := m.Read(buf); err != nil {
+				return false
+			}
+			go func() { _, _ = m.Read(buf) }()
+			<-inHook
+
+			shut := make(chan error, 1)
+			go func() { shut <- m.Shutdown(context.Background()) }()
+			select {
+			case <-shut:
+				return false
+			case <-time.After(20 * time.Millisecond):
+			}
+			close(release)
+			return <-shut == nil
+		},
+	},
+	{
+		name: "WithEagerClose и OpenMmapSource: блоки пройденного источника, ждущие в очереди, переживают его закрытие",
+		run: func() bool {
+			dir, err := os.MkdirTemp("", "multireader-eager-mmap")
+			if err != nil {
+				return false
+			}
+			defer os.RemoveAll(dir)
+
+			var want []byte
+			readers := make([]SizedReadSeekCloser, 3)
+			for i := range readers {
+				data := bytes.Repeat([]byte{byte('a' + i)}, 3<<20)
+				want = append(want, data...)
+				path := filepath.Join(dir, fmt.Sprintf("part-%d", i))
+				if err := os.WriteFile(path, data, 0o600); err != nil {
+					return false
+				}
+				if readers[i], err = OpenMmapSource(path); err != nil {
+					return false
+				}
+			}
+			m := NewMultiReaderWithOptions(64, readers, WithEagerClose())
+			defer m.Close()
+
+			got, err := io.ReadAll(m)
+			return err == nil && bytes.Equal(got, want)
+		},
+	},
+	{
+		name: "Blocks и изменяющий OnRead: хук не портит кэш блоков и не пишет в отображение файла",
+		run: func() bool {
+			mask := &Hooks{OnRead: func(_ int64, data []byte, _ error) {
+				for i := range data {
+					data[i] = 'X'
+				}
+			}}
+			drain := func(m *MultiReader) bool {
+				for b, err := range m.Blocks(context.Background()) {
+					if err != nil || strings.Trim(string(b.Data), "X") != "" {
+						return false
+					}
+				}
+				return true
+			}
+
+			cached := NewMultiReaderWithOptions(2, []SizedReadSeekCloser{NewStringReader("0123456789")},
+				WithHooks(mask), WithBlockCache(bufferSize))
+			defer cached.Close()
+			got := make([]byte, 10)
+			if !drain(cached) {
+				return false
+			}
+			if n, err := cached.ReadAt(got, 0); n != 10 || (err != nil && err != io.EOF) || string(got) != "0123456789" {
+				return false
+			}
+
+			path := filepath.Join(os.TempDir(), fmt.Sprintf("multireader-blocks-hook-%d", time.Now().UnixNano()))
+			defer os.Remove(path)
+			if err := os.WriteFile(path, []byte("0123456789"), 0o600); err != nil {
+				return false
+			}
+			src, err := OpenMmapSource(path)
+			if err != nil {
+				return false
+			}
+			mapped := NewMultiReaderWithOptions(2, []SizedReadSeekCloser{src}, WithHooks(mask))
+			defer mapped.Close()
+			return drain(mapped)
+		},
+	},
+	{
+		name: "Blocks с WithPrefetch(false): блоки читаются напрямую из источников",
+		run: func() bool {
+			want := bytes.Repeat([]byte("0123456789"), bufferSize/4)
+			m := NewMultiReaderWithOptions(2, []SizedReadSeekCloser{NewBytesReader(want[:bufferSize/2]), NewBytesReader(want[bufferSize/2:])},
+				WithPrefetch(false))
+			defer m.Close()
+
+			var got []byte
+			for b, err := range m.Blocks(context.Background()) {
+				if err != nil || b.Offset != int64(len(got)) {
+					return false
+				}
+				got = append(got, b.Data...)
+			}
+			return bytes.Equal(got, want)
+		},
+	},
+	{
+		name: "WithCacheTTL: истёкшие блоки кэша в памяти и на диске перечитываются из источников",
+		run: func() bool {
+			check := func(cacheOpt Option) bool {
+				data := []byte("old-data")
+				m := NewMultiReaderWithOptions(2, []SizedReadSeekCloser{NewBytesReader(data)}, cacheOpt, WithCacheTTL(100*time.Millisecond))
+				defer m.Close()
+
+				readAt := func() string {
+					buf := make([]byte, len(data))
+					n, _ := m.ReadAt(buf, 0)
+					return string(buf[:n])
+				}
+				if readAt() != "old-data" {
+					return false
+				}
+				copy(data, "new-data") // Содержимое источника законно сменилось
+				if readAt() != "old-data" {
+					return false
+				}
+				time.Sleep(250 * time.Millisecond) // Фоновая очистка выбрасывает блок без обращения к нему
+				if m.Stats().CacheExpired != 1 {
+					return false
+				}
+				return readAt() == "new-data"
+			}
+			return check(WithBlockCache(bufferSize)) && check(WithDiskCache("", 4*bufferSize))
+		},
+	},
+	{
+		name: "WithDiskCacheCompression: сброшенные на диск блоки сжаты и читаются без изменений",
+		run: func() bool {
+			// Сжимаемые блоки (текст) и несжимаемые (случайные байты) вперемешку
+			text := bytes.Repeat([]byte("level=info msg=\"request served\" status=200\n"), 2*bufferSize/40)[:2*bufferSize]
+			noise := make([]byte, 2*bufferSize)
+			_, _ = rand.New(rand.NewSource(1)).Read(noise)
+			want := append(append([]byte{}, text...), noise...)
+
+			m := NewMultiReaderWithOptions(2, []SizedReadSeekCloser{NewBytesReader(text), NewBytesReader(noise)},
+				WithBlockCache(bufferSize), WithDiskCache("", 8*bufferSize), WithDiskCacheCompression())
+			defer m.Close()
+			if got, err := io.ReadAll(m); err != nil || !bytes.Equal(got, want) {
+				return false
+			}
+			st := m.Stats()
+			if st.SpilledBytes != 3*bufferSize || st.SpillSaved < bufferSize {
+				return false
+			}
+
+			// Блоки поднимаются с диска и разжимаются
+			got := make([]byte, len(want))
+			if n, err := m.ReadAt(got, 0); n != len(want) || (err != nil && err != io.EOF) || !bytes.Equal(got, want) {
+				return false
+			}
+			return m.Stats().CacheMisses == st.CacheMisses
+		},
+	},
+	{
+		name: "Downloader: сбой части возвращается вместо отмены, а Download дожидается остальных частей",
+		run: func() bool {
+			errGone := errors.New("gone")
+			var active atomic.Int32
+			d := &Downloader{
+				Open: func(ctx context.Context, off, length int64) (io.ReadCloser, error) {
+					if off == 0 {
+						return nil, errGone
+					}
+					active.Add(1) // Остальные части висят до отмены
+					defer active.Add(-1)
+					<-ctx.Done()
+					return nil, ctx.Err()
+				},
+				Size:        40,
+				PartSize:    10,
+				Concurrency: 2,
+			}
+			for range 20 {
+				if err := d.DownloadTo(context.Background(), &sliceWriterAt{buf: make([]byte, 40)}); !errors.Is(err, errGone) {
+					return false
+				}
+				if _, err := d.Download(context.Background(), io.Discard); !errors.Is(err, errGone) || active.Load() != 0 {
+					return false
+				}
+			}
+
+			// Отмена извне - это ctx.Err(), а не ошибки частей
+			ctx, cancel := context.WithCancel(context.Background())
+			d.Open = func(ctx context.Context, off, length int64) (io.ReadCloser, error) {
+				cancel()
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}
+			return errors.Is(d.DownloadTo(ctx, &sliceWriterAt{buf: make([]byte, 40)}), context.Canceled)
+		},
+	},
+	{
+		name: "ReplicaSource: реплика, оборвавшаяся раньше размера сегмента, заменяется следующей",
+		run: func() bool {
+			truncated := newMockStringsReader("abc")
+			truncated.size = 6 // Заявлено больше, чем отдаёт
+			seg, err := ReplicaSource(nil, 0, truncated, NewStringReader("abcdef"))
+			if err != nil {
+				return false
+			}
+			m := NewMultiReader(2, seg, NewStringReader("gh"))
+			defer m.Close()
+
+			got, err := io.ReadAll(m)
+			return err == nil && string(got) == "abcdefgh"
+		},
+	},
+	{
+		name: "ReadByte из окна: Shutdown дожидается его хуков",
+		run: func() bool {
+			var calls atomic.Int32
+			inHook, release := make(chan struct{}), make(chan struct{})
+			hooks := &Hooks{OnRead: func(int64, []byte, error) {
+				if calls.Add(1) == 2 { // Второй ReadByte идёт быстрым путём - окно уже заполнено
+					close(inHook)
+					<-release
+				}
+			}}
+			m := NewMultiReaderWithOptions(2, []SizedReadSeekCloser{NewStringReader("abcdef")}, WithHooks(hooks))
+			if _, err := m.ReadByte(); err != nil {
+				return false
+			}
+			go func() { _, _ = m.ReadByte() }()
+			<-inHook
+
+			shut := make(chan error, 1)
+			go func() { shut <- m.Shutdown(context.Background()) }()
+			select {
+			case <-shut:
+				return false
+			case <-time.After(20 * time.Millisecond):
+			}
+			close(release)
+			return <-shut == nil
+		},
+	},
+	{
+		name: "ServeMultiReader: медленный клиент не держит источники, выросший файл отдаётся по объявленному размеру",
+		run: func() bool {
+			dir, err := os.MkdirTemp("", "multireader-serve")
+			if err != nil {
+				return false
+			}
+			defer os.RemoveAll(dir)
+			path := filepath.Join(dir, "part")
+			if err = os.WriteFile(path, []byte("file"), 0o600); err != nil {
+				return false
+			}
+			file, err := OpenFileSource(path)
+			if err != nil {
+				return false
+			}
+			m := NewMultiReader(2, NewStringReader("head-"), file)
+			defer m.Close()
+
+			// Файл дописан после создания мультиридера - в ответ попадает только объявленная часть
+			f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+			if err != nil {
+				return false
+			}
+			_, _ = f.WriteString("-grown")
+			_ = f.Close()
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ServeMultiReader(w, r, m)
+			}))
+			defer srv.Close()
+			resp, err := http.Get(srv.URL)
+			if err != nil {
+				return false
+			}
+			body, err := io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+			if err != nil || string(body) != "head-file" {
+				return false
+			}
+
+			// Клиент застрял на записи первой порции - ReadAt тем временем не ждёт его
+			entered, release := make(chan struct{}), make(chan struct{})
+			var once sync.Once
+			stuck := writerFunc(func(p []byte) (int, error) {
+				once.Do(func() { close(entered) })
+				<-release
+				return len(p), nil
+			})
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				_, _ = m.writeSegmentsTo(stuck)
+			}()
+			<-entered
+			readDone := make(chan bool, 1)
+			go func() {
+				got := make([]byte, 4)
+				n, err := m.ReadAt(got, 5)
+				readDone <- n == 4 && (err == nil || err == io.EOF) && string(got) == "file"
+			}()
+			var ok bool
+			select {
+			case ok = <-readDone:
+			case <-time.After(time.Second):
+			}
+			close(release)
+			<-done
+			return ok
+		},
+	},
+	{
+		name: "SourcePanicError: паника в Close источника становится ошибкой Close, остальные источники закрываются",
+		run: func() bool {
+			a, c := newMockStringsReader("a"), newMockStringsReader("c")
+			m := NewMultiReaderWithOptions(1, []SizedReadSeekCloser{a, &panickingCloser{NewStringReader("b")}, c},
+				WithCloseConcurrency(3))
+
+			var panicErr *SourcePanicError
+			err := m.Close()
+			return errors.As(err, &panicErr) && panicErr.Index == 1 && a.closed && c.closed
+		},
+	},
 }
@@ -3,12 +3,14 @@ package main
 import (
 	"errors"
 	"io"
+	"time"
 )
 
-// TestCase описывает один самостоятельный тест: имя и функцию проверки.
+// TestCase описывает один самостоятельный тест: имя, функцию проверки и таймаут.
 type TestCase struct {
-	name string
-	run  func() bool
+	name    string
+	run     func() bool
+	timeout time.Duration // таймаут кейса (0 - concurrentTestTimeout)
 }
 
 var testCases = []TestCase{
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Range - диапазон [Off, Off+Len) в объединённом потоке.
+type Range struct {
+	Off int64 // абсолютная позиция начала диапазона
+	Len int64 // длина диапазона
+}
+
+// RangeError описывает сбой чтения диапазона: с какой позиции и в каком источнике данные не удалось получить.
+type RangeError struct {
+	Range  Range // запрошенный диапазон
+	Offset int64 // абсолютная позиция, на которой чтение прервалось
+	Source int   // индекс источника, содержащего Offset (-1, если позиция вне потока)
+	Err    error // исходная ошибка
+}
+
+func (e *RangeError) Error() string {
+	return fmt.Sprintf("range [%d, %d): read failed at offset %d (source %d): %v",
+		e.Range.Off, e.Range.Off+e.Range.Len, e.Offset, e.Source, e.Err)
+}
+
+func (e *RangeError) Unwrap() error {
+	return e.Err
+}
+
+// RangeResult - результат чтения одного диапазона в режиме best-effort.
+type RangeResult struct {
+	Range Range  // запрошенный диапазон
+	Data  []byte // прочитанные данные; при ошибке - успешно прочитанный префикс
+	Err   error  // *RangeError, если диапазон прочитан не полностью
+}
+
+// ReadRanges читает набор диапазонов через ReadAt, не сдвигая курсор. Останавливается на первой ошибке.
+func (m *MultiReader) ReadRanges(ranges []Range) ([][]byte, error) {
+	out := make([][]byte, 0, len(ranges))
+	for _, r := range ranges {
+		data, err := m.readRange(r)
+		if err != nil {
+			return out, err
+		}
+		out = append(out, data)
+	}
+
+	return out, nil
+}
+
+// ReadRangesBestEffort читает все диапазоны, не останавливаясь на сбоях: ошибки сообщаются по каждому диапазону отдельно,
+// а исправные диапазоны возвращаются полностью. Позволяет собрать отчёт о повреждениях за один проход.
+func (m *MultiReader) ReadRangesBestEffort(ranges []Range) []RangeResult {
+	out := make([]RangeResult, len(ranges))
+	for i, r := range ranges {
+		data, err := m.readRange(r)
+		out[i] = RangeResult{Range: r, Data: data, Err: err}
+	}
+
+	return out
+}
+
+// ReadRange читает байты [start, end) через ReadAt, не сдвигая курсор и не трогая окно префетча.
+// Сбой чтения или выход за границы потока возвращается как *RangeError. Удобно для обработчиков HTTP Range.
+func (m *MultiReader) ReadRange(start, end int64) ([]byte, error) {
+	return m.readRange(Range{Off: start, Len: end - start})
+}
+
+// WriteRangeTo - потоковый вариант ReadRange: пишет байты [start, end) в w порциями не больше блока префетча,
+// не держа весь диапазон в памяти. Возвращает число записанных байт; сбой чтения - *RangeError, ошибка w - как есть.
+func (m *MultiReader) WriteRangeTo(w io.Writer, start, end int64) (int64, error) {
+	r := Range{Off: start, Len: end - start}
+	if err := m.checkRange(r); err != nil {
+		return 0, err
+	}
+
+	buf := make([]byte, min(r.Len, bufferSize))
+	var written int64
+	for written < r.Len {
+		p := buf[:min(int64(len(buf)), r.Len-written)]
+		n, err := m.ReadAt(p, r.Off+written)
+		if n == len(p) { // ReadAt вправе вернуть EOF вместе с последней полной порцией
+			err = nil
+		}
+		if n > 0 {
+			k, werr := w.Write(p[:n])
+			written += int64(k)
+			if werr == nil && k < n {
+				werr = io.ErrShortWrite
+			}
+			if werr != nil {
+				return written, werr
+			}
+		}
+		if err != nil {
+			return written, m.rangeError(r, r.Off+written, err)
+		}
+	}
+
+	return written, nil
+}
+
+// readRange читает один диапазон целиком, оборачивая сбой в *RangeError.
+func (m *MultiReader) readRange(r Range) ([]byte, error) {
+	if err := m.checkRange(r); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, r.Len)
+	n, err := m.ReadAt(buf, r.Off)
+	if err != nil && !(errors.Is(err, io.EOF) && int64(n) == r.Len) {
+		return buf[:n], m.rangeError(r, r.Off+int64(n), err)
+	}
+
+	return buf, nil
+}
+
+// checkRange проверяет, что диапазон лежит внутри потока.
+func (m *MultiReader) checkRange(r Range) error {
+	if size := m.size.Load(); r.Off < 0 || r.Len < 0 || r.Off+r.Len > size {
+		return &RangeError{Range: r, Offset: r.Off, Source: -1, Err: fmt.Errorf("range is out of [0, %d]", size)}
+	}
+	return nil
+}
+
+// rangeError оборачивает сбой чтения диапазона r на позиции failedAt.
+func (m *MultiReader) rangeError(r Range, failedAt int64, err error) *RangeError {
+	source := -1
+	if failedAt < m.size.Load() {
+		source = m.readerIndex(failedAt)
+	}
+	return &RangeError{Range: r, Offset: failedAt, Source: source, Err: err}
+}
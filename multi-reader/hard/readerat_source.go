@@ -0,0 +1,34 @@
+package main
+
+import "io"
+
+// NewReaderAtSource создаёт источник размера size поверх io.ReaderAt. Префетчер и ReadAt читают его позиционно,
+// без Seek, поэтому один *os.File можно безопасно разделять между несколькими мультиридерами.
+// Close ничего не делает: ra принадлежит вызывающему.
+func NewReaderAtSource(ra io.ReaderAt, size int64) SizedReadSeekCloser {
+	return sectionSource{io.NewSectionReader(ra, 0, size)}
+}
+
+// positionalReaderOf возвращает io.ReaderAt источника, если его можно читать по локальному смещению вместо Seek+Read:
+// участки ReaderAt и файловые источники (*os.File через OSFile).
+func positionalReaderOf(r SizedReadSeekCloser) (io.ReaderAt, bool) {
+	switch src := r.(type) {
+	case sectionSource:
+		return src.SectionReader, true
+	case *mmapSource:
+		return src, true
+	case osFileProvider: // pread по файлу: курсор файла не используется и не сбивается
+		return src.OSFile(), true
+	default:
+		return nil, false
+	}
+}
+
+// readAtFull читает len(p) байт с позиции off. io.EOF вместе с полностью заполненным p ошибкой не считается.
+func readAtFull(ra io.ReaderAt, p []byte, off int64) (int, error) {
+	n, err := ra.ReadAt(p, off)
+	if n == len(p) {
+		err = nil
+	}
+	return n, err
+}
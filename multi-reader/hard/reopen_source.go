@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// reopenSource - источник поверх потока без Seek: перемещение вперёд - пропуском байт, назад - переоткрытием потока.
+type reopenSource struct {
+	open   func() (io.ReadCloser, error) // открытие потока с начала
+	size   int64                         // объявленный размер
+	rc     io.ReadCloser                 // открытый поток (nil - не открыт)
+	rcPos  int64                         // позиция внутри открытого потока
+	pos    int64                         // логическая позиция чтения
+	closed bool                          // флаг закрытия
+}
+
+// Проверка, что reopenSource удовлетворяет интерфейсам SizedReadSeekCloser и ContextReader
+var (
+	_ SizedReadSeekCloser = (*reopenSource)(nil)
+	_ ContextReader       = (*reopenSource)(nil)
+)
+
+func newReopenSource(size int64, open func() (io.ReadCloser, error)) *reopenSource {
+	return &reopenSource{
+		open: open,
+		size: size,
+	}
+}
+
+// Read подводит поток к логической позиции и читает из него.
+func (s *reopenSource) Read(p []byte) (int, error) {
+	return s.ReadContext(context.Background(), p)
+}
+
+// ReadContext - Read, прерываемый отменой ctx: зависшее чтение потока обрывается его закрытием,
+// а следующее чтение откроет поток заново.
+func (s *reopenSource) ReadContext(ctx context.Context, p []byte) (int, error) {
+	if s.closed {
+		return 0, io.ErrClosedPipe
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if s.pos >= s.size {
+		return 0, io.EOF
+	}
+
+	if err := s.position(); err != nil {
+		return 0, err
+	}
+
+	n, err := readCancelable(ctx, s.rc, p[:min(int64(len(p)), s.size-s.pos)])
+	s.pos += int64(n)
+	s.rcPos += int64(n)
+	if err != nil && ctx.Err() != nil { // Поток закрыт отменой
+		s.rc = nil
+		return n, err
+	}
+	if errors.Is(err, io.EOF) {
+		if s.pos < s.size { // Поток короче объявленного размера
+			return n, io.ErrUnexpectedEOF
+		}
+		err = nil // EOF вернём следующим вызовом
+	}
+
+	return n, err
+}
+
+// Seek перемещает логическую позицию. Поток подводится к ней при следующем чтении.
+func (s *reopenSource) Seek(offset int64, whence int) (int64, error) {
+	if s.closed {
+		return 0, io.ErrClosedPipe
+	}
+
+	var base int64
+	switch whence {
+	case io.SeekStart:
+		base = 0
+	case io.SeekCurrent:
+		base = s.pos
+	case io.SeekEnd:
+		base = s.size
+	default:
+		return 0, fmt.Errorf("invalid whence: %d", whence)
+	}
+
+	seekPos := base + offset
+	if seekPos < 0 || seekPos > s.size {
+		return 0, fmt.Errorf("seek position (%d) should be >= 0 and <= size (%d)", seekPos, s.size)
+	}
+	s.pos = seekPos
+
+	return seekPos, nil
+}
+
+// Close закрывает открытый поток. Повторный вызов возвращает nil.
+func (s *reopenSource) Close() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	return s.release()
+}
+
+// Size возвращает объявленный размер.
+func (s *reopenSource) Size() int64 {
+	return s.size
+}
+
+// position открывает поток заново, если позиция позади него, и пропускает байты до логической позиции.
+func (s *reopenSource) position() error {
+	if s.rc == nil || s.pos < s.rcPos {
+		if err := s.release(); err != nil {
+			return err
+		}
+		rc, err := s.open()
+		if err != nil {
+			return err
+		}
+		s.rc, s.rcPos = rc, 0
+	}
+
+	if skip := s.pos - s.rcPos; skip > 0 {
+		n, err := io.CopyN(io.Discard, s.rc, skip)
+		s.rcPos += n
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// release закрывает открытый поток, если он есть.
+func (s *reopenSource) release() error {
+	if s.rc == nil {
+		return nil
+	}
+	err := s.rc.Close()
+	s.rc = nil
+
+	return err
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// UnknownSize возвращается из Size, когда размер потока не определён (например, бесконечный повтор).
+// Источник такого размера нельзя склеивать в MultiReader: префиксные суммы размеров станут неверными.
+// NewMultiReaderE отвергает его с ErrInvalidSource; NewMultiReader не проверяет размеры.
+const UnknownSize int64 = -1
+
+// ErrUnknownSize возвращается при Seek относительно конца потока неизвестного размера.
+var ErrUnknownSize = errors.New("stream size is unknown")
+
+// RepeatReader отдаёт содержимое источника times раз подряд (или бесконечно при times <= 0).
+type RepeatReader struct {
+	src     SizedReadSeekCloser // повторяемый источник
+	srcSize int64               // размер одного повтора
+	times   int64               // количество повторов; 0 - бесконечно
+	pos     int64               // абсолютная позиция в повторяющемся потоке
+	srcPos  int64               // текущая позиция внутри источника
+}
+
+// Проверка, что RepeatReader удовлетворяет интерфейсу SizedReadSeekCloser
+var _ SizedReadSeekCloser = (*RepeatReader)(nil)
+
+// NewRepeatReader создаёт поток, повторяющий src times раз. При times <= 0 поток бесконечен и Size возвращает
+// UnknownSize - такой поток читается сам по себе, но не годится в источник MultiReader (см. UnknownSize).
+func NewRepeatReader(src SizedReadSeekCloser, times int) *RepeatReader {
+	return &RepeatReader{
+		src:     src,
+		srcSize: src.Size(),
+		times:   int64(max(times, 0)),
+	}
+}
+
+// Read читает данные, переходя в начало источника после каждого повтора.
+func (r *RepeatReader) Read(p []byte) (n int, err error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	size := r.Size()
+	if r.srcSize == 0 || (size != UnknownSize && r.pos >= size) {
+		return 0, io.EOF
+	}
+
+	local := r.pos % r.srcSize
+	if local != r.srcPos { // Начался новый повтор или был Seek - выставляем позицию источника
+		if _, err = r.src.Seek(local, io.SeekStart); err != nil {
+			return 0, err
+		}
+		r.srcPos = local
+	}
+
+	n, err = r.src.Read(p[:min(int64(len(p)), r.srcSize-local)])
+	r.pos += int64(n)
+	r.srcPos += int64(n)
+	if errors.Is(err, io.EOF) { // Конец одного повтора - это ещё не конец потока
+		err = nil
+		if n == 0 {
+			return 0, io.ErrUnexpectedEOF
+		}
+	}
+
+	return n, err
+}
+
+// Seek перемещает курсор. Для бесконечного потока Seek относительно конца недоступен.
+func (r *RepeatReader) Seek(offset int64, whence int) (int64, error) {
+	size := r.Size()
+
+	var base int64
+	switch whence {
+	case io.SeekStart:
+		base = 0
+	case io.SeekCurrent:
+		base = r.pos
+	case io.SeekEnd:
+		if size == UnknownSize {
+			return 0, ErrUnknownSize
+		}
+		base = size
+	default:
+		return 0, fmt.Errorf("invalid whence: %d", whence)
+	}
+
+	seekPos := base + offset
+	if seekPos < 0 || (size != UnknownSize && seekPos > size) {
+		return 0, fmt.Errorf("seek position (%d) should be >= 0 and <= totalSize (%d)", seekPos, size)
+	}
+	r.pos = seekPos
+
+	return seekPos, nil
+}
+
+// Close закрывает источник.
+func (r *RepeatReader) Close() error {
+	return r.src.Close()
+}
+
+// Size возвращает times × размер источника или UnknownSize для бесконечного повтора.
+func (r *RepeatReader) Size() int64 {
+	if r.times == 0 {
+		if r.srcSize == 0 {
+			return 0
+		}
+		return UnknownSize
+	}
+	return r.times * r.srcSize
+}
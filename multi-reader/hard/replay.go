@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"sync"
+)
+
+// ErrSourceChanged - sentinel для errors.Is: данные источников изменились с момента создания контрольной точки.
+var ErrSourceChanged = errors.New("sources changed since checkpoint")
+
+// Checkpoint - контрольная точка передачи: дайджесты уже отданных потребителю диапазонов.
+// Возобновлённая передача сверяет по ней префикс с источниками, прежде чем продолжить с Offset.
+type Checkpoint struct {
+	Offset    int64    `json:"offset"`     // конец подтверждённого префикса (кратен RangeSize)
+	RangeSize int64    `json:"range_size"` // размер диапазона, по которому считается дайджест
+	Digests   [][]byte `json:"digests"`    // SHA-256 каждого диапазона префикса [0, Offset)
+}
+
+// ReplayMismatchError описывает первый диапазон, содержимое которого не совпало с контрольной точкой.
+type ReplayMismatchError struct {
+	Range  int   // индекс диапазона в Checkpoint.Digests
+	Offset int64 // абсолютная позиция начала диапазона
+}
+
+func (e *ReplayMismatchError) Error() string {
+	return fmt.Sprintf("range %d at offset %d does not match checkpoint digest", e.Range, e.Offset)
+}
+
+func (e *ReplayMismatchError) Is(target error) bool {
+	return target == ErrSourceChanged
+}
+
+// replayRecorder считает дайджесты непрерывного префикса, отданного потребителю через Read.
+type replayRecorder struct {
+	mu        sync.Mutex
+	rangeSize int64     // размер диапазона
+	h         hash.Hash // дайджест текущего незавершённого диапазона
+	filled    int64     // сколько байт текущего диапазона уже учтено
+	offset    int64     // конец учтённого префикса
+	digests   [][]byte  // дайджесты завершённых диапазонов
+}
+
+func newReplayRecorder(rangeSize int64) *replayRecorder {
+	return &replayRecorder{rangeSize: rangeSize, h: sha256.New()}
+}
+
+// observe учитывает данные data, отданные с позиции pos. Повторно прочитанные байты игнорируются,
+// данные после разрыва (Seek вперёд) не продлевают префикс.
+func (r *replayRecorder) observe(pos int64, data []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if pos > r.offset || pos+int64(len(data)) <= r.offset {
+		return
+	}
+	data = data[r.offset-pos:]
+	for len(data) > 0 {
+		k := min(int64(len(data)), r.rangeSize-r.filled)
+		r.h.Write(data[:k])
+		data = data[k:]
+		r.filled += k
+		r.offset += k
+		if r.filled == r.rangeSize {
+			r.digests = append(r.digests, r.h.Sum(nil))
+			r.h.Reset()
+			r.filled = 0
+		}
+	}
+}
+
+// checkpoint возвращает контрольную точку по завершённым диапазонам.
+func (r *replayRecorder) checkpoint() Checkpoint {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	digests := make([][]byte, len(r.digests))
+	copy(digests, r.digests)
+	return Checkpoint{
+		Offset:    int64(len(digests)) * r.rangeSize,
+		RangeSize: r.rangeSize,
+		Digests:   digests,
+	}
+}
+
+// restore продолжает запись с подтверждённой контрольной точки.
+func (r *replayRecorder) restore(cp Checkpoint) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.rangeSize = cp.RangeSize
+	r.digests = append([][]byte(nil), cp.Digests...)
+	r.offset = cp.Offset
+	r.filled = 0
+	r.h.Reset()
+}
+
+// Checkpoint возвращает контрольную точку отданного префикса. Требует WithReplayProtection.
+func (m *MultiReader) Checkpoint() (Checkpoint, error) {
+	if m.replay == nil {
+		return Checkpoint{}, errors.New("replay protection is not enabled")
+	}
+	return m.replay.checkpoint(), nil
+}
+
+// VerifyCheckpoint перечитывает префикс [0, cp.Offset) из источников и сверяет дайджесты с контрольной точкой.
+// При расхождении возвращает *ReplayMismatchError (errors.Is(err, ErrSourceChanged)).
+func (m *MultiReader) VerifyCheckpoint(cp Checkpoint) error {
+	if cp.RangeSize <= 0 || cp.Offset != int64(len(cp.Digests))*cp.RangeSize {
+		return fmt.Errorf("malformed checkpoint: offset %d, range size %d, %d digests", cp.Offset, cp.RangeSize, len(cp.Digests))
+	}
+	if size := m.size.Load(); cp.Offset > size {
+		return &ReplayMismatchError{Range: int(size / cp.RangeSize), Offset: size / cp.RangeSize * cp.RangeSize}
+	}
+
+	buf := make([]byte, cp.RangeSize)
+	for i, want := range cp.Digests {
+		off := int64(i) * cp.RangeSize
+		if _, err := m.ReadAt(buf, off); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("verify range %d at offset %d: %w", i, off, err)
+		}
+		got := sha256.Sum256(buf)
+		if !bytes.Equal(got[:], want) {
+			return &ReplayMismatchError{Range: i, Offset: off}
+		}
+	}
+
+	return nil
+}
+
+// ResumeFromCheckpoint сверяет префикс с контрольной точкой и переводит курсор на cp.Offset,
+// продолжая запись дайджестов с этого места.
+func (m *MultiReader) ResumeFromCheckpoint(cp Checkpoint) error {
+	if err := m.VerifyCheckpoint(cp); err != nil {
+		return err
+	}
+	if _, err := m.Seek(cp.Offset, io.SeekStart); err != nil {
+		return err
+	}
+	if m.replay != nil {
+		m.replay.restore(cp)
+	}
+
+	return nil
+}
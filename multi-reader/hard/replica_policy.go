@@ -0,0 +1,117 @@
+package main
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ReplicaPolicy определяет порядок обращения к равнозначным репликам одного сегмента и учится на результатах чтений.
+type ReplicaPolicy interface {
+	// Order возвращает индексы реплик сегмента segment в порядке предпочтения.
+	Order(segment, replicas int) []int
+	// Observe сообщает политике результат чтения из реплики: длительность и ошибку.
+	Observe(segment, replica int, latency time.Duration, err error)
+}
+
+// localityPolicy сохраняет заданный порядок реплик: вызывающий перечисляет локальные реплики первыми.
+type localityPolicy struct{}
+
+// LocalityPolicy возвращает политику «сначала локальные»: реплики используются в порядке регистрации.
+func LocalityPolicy() ReplicaPolicy {
+	return localityPolicy{}
+}
+
+func (localityPolicy) Order(_, replicas int) []int {
+	order := make([]int, replicas)
+	for i := range order {
+		order[i] = i
+	}
+	return order
+}
+
+func (localityPolicy) Observe(int, int, time.Duration, error) {}
+
+// randomPolicy перемешивает реплики для равномерного распределения нагрузки.
+type randomPolicy struct {
+	mu  sync.Mutex // rand.Rand не потокобезопасен
+	rnd *rand.Rand
+}
+
+// RandomPolicy возвращает политику, выбирающую реплики в случайном порядке.
+func RandomPolicy(seed int64) ReplicaPolicy {
+	return &randomPolicy{rnd: rand.New(rand.NewSource(seed))}
+}
+
+func (p *randomPolicy) Order(_, replicas int) []int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.rnd.Perm(replicas)
+}
+
+func (p *randomPolicy) Observe(int, int, time.Duration, error) {}
+
+// replicaKey - ключ статистики реплики внутри сегмента.
+type replicaKey struct {
+	segment int
+	replica int
+}
+
+// latencyPolicy упорядочивает реплики по экспоненциально сглаженной задержке (EWMA), отдельно для каждого сегмента.
+type latencyPolicy struct {
+	mu      sync.Mutex
+	alpha   float64                // вес нового наблюдения в EWMA
+	penalty time.Duration          // задержка, засчитываемая при ошибке чтения
+	ewma    map[replicaKey]float64 // сглаженная задержка в наносекундах
+}
+
+// LatencyPolicy возвращает политику, которая по ходу чтения узнаёт самую быструю реплику каждого сегмента.
+// alpha - вес нового наблюдения (0 < alpha <= 1), penalty - задержка, засчитываемая за ошибку.
+// Ещё не опрошенные реплики идут первыми, чтобы каждая получила хотя бы одно измерение.
+func LatencyPolicy(alpha float64, penalty time.Duration) ReplicaPolicy {
+	if alpha <= 0 || alpha > 1 {
+		alpha = 0.3
+	}
+	return &latencyPolicy{
+		alpha:   alpha,
+		penalty: penalty,
+		ewma:    make(map[replicaKey]float64),
+	}
+}
+
+func (p *latencyPolicy) Order(segment, replicas int) []int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	order := make([]int, replicas)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		li, okI := p.ewma[replicaKey{segment, order[i]}]
+		lj, okJ := p.ewma[replicaKey{segment, order[j]}]
+		if okI != okJ { // Неопрошенные реплики - вперёд
+			return !okI
+		}
+		return li < lj
+	})
+	return order
+}
+
+func (p *latencyPolicy) Observe(segment, replica int, latency time.Duration, err error) {
+	if err != nil {
+		latency = max(latency, p.penalty)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key := replicaKey{segment, replica}
+	prev, ok := p.ewma[key]
+	if !ok {
+		p.ewma[key] = float64(latency)
+		return
+	}
+	p.ewma[key] = p.alpha*float64(latency) + (1-p.alpha)*prev
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// replicaSource - логический сегмент с несколькими равнозначными репликами. При сбое активной реплики
+// чтение прозрачно продолжается из следующей (по порядку ReplicaPolicy) с того же локального смещения.
+type replicaSource struct {
+	policy   ReplicaPolicy         // порядок выбора реплик
+	segment  int                   // номер сегмента для статистики политики
+	replicas []SizedReadSeekCloser // реплики одинакового размера
+	size     int64                 // размер сегмента
+	pos      int64                 // логическая позиция чтения
+	cur      int                   // активная реплика (-1 - не выбрана)
+	curPos   int64                 // позиция внутри активной реплики
+	closed   bool                  // флаг закрытия
+}
+
+// Проверка, что replicaSource удовлетворяет интерфейсам SizedReadSeekCloser и ContextReader
+var (
+	_ SizedReadSeekCloser = (*replicaSource)(nil)
+	_ ContextReader       = (*replicaSource)(nil)
+)
+
+// ReplicaSource объединяет реплики одного сегмента в отказоустойчивый источник. Все реплики должны иметь одинаковый размер.
+// segment - номер сегмента, под которым политика ведёт статистику реплик.
+func ReplicaSource(policy ReplicaPolicy, segment int, replicas ...SizedReadSeekCloser) (SizedReadSeekCloser, error) {
+	if len(replicas) == 0 {
+		return nil, fmt.Errorf("segment %d: no replicas", segment)
+	}
+	size := replicas[0].Size()
+	for i, r := range replicas[1:] {
+		if r.Size() != size {
+			return nil, fmt.Errorf("segment %d: replica %d size %d differs from %d", segment, i+1, r.Size(), size)
+		}
+	}
+	if policy == nil {
+		policy = LocalityPolicy()
+	}
+
+	return &replicaSource{
+		policy:   policy,
+		segment:  segment,
+		replicas: replicas,
+		size:     size,
+		cur:      -1,
+	}, nil
+}
+
+// NewMultiReaderWithReplicas создаёт мультиридер, в котором каждый сегмент представлен набором реплик.
+// Политика общая для всех сегментов и учится на результатах чтений по ходу потока.
+func NewMultiReaderWithReplicas(buffersNum int, policy ReplicaPolicy, segments ...[]SizedReadSeekCloser) (*MultiReader, error) {
+	if policy == nil {
+		policy = LocalityPolicy()
+	}
+	readers := make([]SizedReadSeekCloser, len(segments))
+	for i, replicas := range segments {
+		src, err := ReplicaSource(policy, i, replicas...)
+		if err != nil {
+			return nil, err
+		}
+		readers[i] = src
+	}
+
+	return NewMultiReader(buffersNum, readers...), nil
+}
+
+// Read читает из активной реплики, переключаясь на следующую при ошибке.
+func (s *replicaSource) Read(p []byte) (int, error) {
+	return s.ReadContext(context.Background(), p)
+}
+
+// ReadContext - Read, передающий ctx репликам, реализующим ContextReader. Отменённое чтение засчитывается
+// реплике как сбой, и следующие реплики в этом вызове уже не опрашиваются: повтор решает вызывающий.
+func (s *replicaSource) ReadContext(ctx context.Context, p []byte) (int, error) {
+	if s.closed {
+		return 0, io.ErrClosedPipe
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if s.pos >= s.size {
+		return 0, io.EOF
+	}
+
+	var errs []error
+	for _, i := range s.candidates() {
+		n, err := s.readFrom(ctx, i, p)
+		if n > 0 || err == nil || errors.Is(err, io.EOF) {
+			s.cur = i
+			s.pos += int64(n)
+			s.curPos = s.pos
+			if err != nil && !errors.Is(err, io.EOF) { // Данные отдаём сейчас, ошибку реплики отработаем в следующем вызове
+				s.cur = -1
+				err = nil
+			}
+			return n, err
+		}
+		errs = append(errs, fmt.Errorf("replica %d: %w", i, err))
+		if s.cur == i {
+			s.cur = -1
+		}
+		if ctx.Err() != nil {
+			return 0, fmt.Errorf("segment %d: %w", s.segment, errors.Join(errs...))
+		}
+	}
+
+	return 0, fmt.Errorf("segment %d: all replicas failed: %w", s.segment, errors.Join(errs...))
+}
+
+// readFrom выставляет позицию в i-й реплике и читает из неё, сообщая политике задержку и результат.
+func (s *replicaSource) readFrom(ctx context.Context, i int, p []byte) (n int, err error) {
+	start := time.Now()
+	r := s.replicas[i]
+	if i != s.cur || s.curPos != s.pos {
+		if _, err := r.Seek(s.pos, io.SeekStart); err != nil {
+			s.policy.Observe(s.segment, i, time.Since(start), err)
+			return 0, err
+		}
+	}
+	p = p[:min(int64(len(p)), s.size-s.pos)]
+	if cr, ok := r.(ContextReader); ok {
+		n, err = cr.ReadContext(ctx, p)
+	} else {
+		n, err = r.Read(p)
+	}
+	if errors.Is(err, io.EOF) && s.pos+int64(n) < s.size { // Реплика оборвалась раньше конца сегмента - это сбой
+		err = io.ErrUnexpectedEOF
+	}
+	var observed error
+	if err != nil && !errors.Is(err, io.EOF) {
+		observed = err
+	}
+	s.policy.Observe(s.segment, i, time.Since(start), observed)
+
+	return n, err
+}
+
+// candidates возвращает порядок опроса реплик: активная - первой, остальные - по политике.
+func (s *replicaSource) candidates() []int {
+	order := s.policy.Order(s.segment, len(s.replicas))
+	if s.cur < 0 {
+		return order
+	}
+	out := make([]int, 0, len(order))
+	out = append(out, s.cur)
+	for _, i := range order {
+		if i != s.cur {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+// Seek перемещает логическую позицию. Позиция реплики выставляется при следующем чтении.
+func (s *replicaSource) Seek(offset int64, whence int) (int64, error) {
+	if s.closed {
+		return 0, io.ErrClosedPipe
+	}
+
+	var base int64
+	switch whence {
+	case io.SeekStart:
+		base = 0
+	case io.SeekCurrent:
+		base = s.pos
+	case io.SeekEnd:
+		base = s.size
+	default:
+		return 0, fmt.Errorf("invalid whence: %d", whence)
+	}
+
+	seekPos := base + offset
+	if seekPos < 0 || seekPos > s.size {
+		return 0, fmt.Errorf("seek position (%d) should be >= 0 and <= size (%d)", seekPos, s.size)
+	}
+	s.pos = seekPos
+
+	return seekPos, nil
+}
+
+// Close закрывает все реплики, агрегируя ошибки.
+func (s *replicaSource) Close() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	var multiErr error
+	for _, r := range s.replicas {
+		if err := r.Close(); err != nil {
+			multiErr = errors.Join(multiErr, err)
+		}
+	}
+
+	return multiErr
+}
+
+// Size возвращает размер сегмента.
+func (s *replicaSource) Size() int64 {
+	return s.size
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// caseResult - результат одного тест-кейса для машиночитаемого отчёта.
+type caseResult struct {
+	Name     string  `json:"name"`
+	Status   string  `json:"status"` // pass, fail, panic или timeout
+	Duration float64 `json:"duration_sec"`
+	Message  string  `json:"message,omitempty"`
+}
+
+// runCase выполняет кейс, перехватывая панику, и не завершает процесс при провале.
+// По таймауту кейса пишет стеки всех горутин в stacks и возвращает статус timeout, не дожидаясь зависшего кейса.
+func runCase(tc TestCase, stacks io.Writer) caseResult {
+	start := time.Now()
+	done := make(chan caseResult, 1)
+	go func() {
+		res := caseResult{Name: tc.name}
+		defer func() {
+			if r := recover(); r != nil {
+				res.Status, res.Message = "panic", fmt.Sprint(r)
+			}
+			done <- res
+		}()
+
+		if tc.run() {
+			res.Status = "pass"
+		} else {
+			res.Status = "fail"
+		}
+	}()
+
+	timer := time.NewTimer(tc.caseTimeout())
+	defer timer.Stop()
+	var res caseResult
+	select {
+	case res = <-done:
+	case <-timer.C:
+		dumpGoroutines(stacks)
+		res = caseResult{Name: tc.name, Status: "timeout", Message: tc.caseTimeout().String()}
+	}
+	res.Duration = time.Since(start).Seconds()
+
+	return res
+}
+
+// runReport прогоняет все кейсы, не останавливаясь на первом провале, и пишет отчёт в w в формате format:
+// text - строки «Тест кейс ... - успех/провал», json - по объекту caseResult на строку (JSON Lines),
+// junit - JUnit XML. Возвращает число непрошедших кейсов.
+func runReport(tests []TestCase, format string, w io.Writer) (int, error) {
+	results := make([]caseResult, 0, len(tests))
+	failed := 0
+	enc := json.NewEncoder(w)
+	for _, tc := range tests {
+		res := runCase(tc, os.Stderr)
+		if res.Status != "pass" {
+			failed++
+		}
+		switch format {
+		case "text":
+			status := map[string]string{
+				"pass":    "успех",
+				"fail":    "провал",
+				"panic":   "Паника: " + res.Message,
+				"timeout": "таймаут (" + res.Message + ")",
+			}[res.Status]
+			if _, err := fmt.Fprintf(w, "Тест кейс %q - %s\n", res.Name, status); err != nil {
+				return failed, err
+			}
+		case "json":
+			if err := enc.Encode(res); err != nil {
+				return failed, err
+			}
+		case "junit":
+			results = append(results, res)
+		default:
+			return failed, fmt.Errorf("unknown report format: %q", format)
+		}
+	}
+
+	if format == "junit" {
+		return failed, writeJUnit(w, results, failed)
+	}
+	return failed, nil
+}
+
+// junitSuite и junitCase - минимальная схема JUnit XML, которую понимают CI-системы.
+type junitSuite struct {
+	XMLName  xml.Name    `xml:"testsuite"`
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Time     float64     `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name    string        `xml:"name,attr"`
+	Time    float64       `xml:"time,attr"`
+	Failure *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+}
+
+// writeJUnit пишет результаты в формате JUnit XML.
+func writeJUnit(w io.Writer, results []caseResult, failed int) error {
+	suite := junitSuite{Name: "multi-reader", Tests: len(results), Failures: failed}
+	for _, res := range results {
+		c := junitCase{Name: res.Name, Time: res.Duration}
+		if res.Status != "pass" {
+			c.Failure = &junitFailure{Message: res.Status + " " + res.Message}
+		}
+		suite.Time += res.Duration
+		suite.Cases = append(suite.Cases, c)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suite); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
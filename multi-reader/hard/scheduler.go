@@ -0,0 +1,30 @@
+package main
+
+import "context"
+
+// schedPoint - точка взаимодействия префетчера с потребителем, в которой планировщик может его придержать.
+type schedPoint int
+
+const (
+	schedBeforePublish schedPoint = iota // блок прочитан из источника и вот-вот будет опубликован в pfBufCh
+	schedBeforeEOF                       // префетчер дошёл до конца потока и вот-вот отправит EOF
+)
+
+// scheduler абстрагирует запуск горутины префетча и точки синхронизации внутри неё.
+// В рабочем режиме это обычные горутины, в тестах - детерминированный исполнитель,
+// позволяющий воспроизводить конкретные чередования (Seek во время публикации блока, Close во время EOF).
+type scheduler interface {
+	// Go запускает f в отдельной горутине.
+	Go(f func())
+	// Yield вызывается префетчером в точке p. Возвращает управление по решению планировщика или при отмене ctx.
+	Yield(ctx context.Context, p schedPoint)
+}
+
+// goScheduler - планировщик по умолчанию: обычная горутина и никаких задержек.
+type goScheduler struct{}
+
+func (goScheduler) Go(f func()) {
+	go f()
+}
+
+func (goScheduler) Yield(context.Context, schedPoint) {}
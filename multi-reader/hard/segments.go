@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// indexChunk - сколько источников покрывает одна запись грубого индекса.
+const indexChunk = 1024
+
+// buildCoarseIndex возвращает грубый индекс - каждую indexChunk-ю префиксную сумму. Для миллионов источников
+// поиск сначала идёт по нему (он помещается в кэш процессора), затем внутри одного фрагмента prefixSizes.
+// При небольшом числе источников индекс не нужен и возвращается nil.
+func buildCoarseIndex(prefixSizes []int64) []int64 {
+	readers := len(prefixSizes) - 1
+	if readers <= indexChunk {
+		return nil
+	}
+	coarse := make([]int64, 0, (readers+indexChunk-1)/indexChunk)
+	for i := 0; i < readers; i += indexChunk {
+		coarse = append(coarse, prefixSizes[i])
+	}
+	return coarse
+}
+
+// CoalesceSources объединяет подряд идущие источники размером меньше minSize в составные сегменты не меньше
+// minSize, чтобы индекс мультиридера над миллионами мелких источников был в разы короче. Источники не меньше
+// minSize остаются как есть. Составной сегмент закрывает свои источники в Close.
+func CoalesceSources(minSize int64, readers ...SizedReadSeekCloser) []SizedReadSeekCloser {
+	var (
+		out     []SizedReadSeekCloser
+		pending []SizedReadSeekCloser
+		size    int64
+	)
+	flush := func() {
+		switch len(pending) {
+		case 0:
+		case 1:
+			out = append(out, pending[0])
+		default:
+			out = append(out, newCompositeSource(pending))
+		}
+		pending, size = nil, 0
+	}
+
+	for _, r := range readers {
+		if r.Size() >= minSize {
+			flush()
+			out = append(out, r)
+			continue
+		}
+		pending = append(pending, r)
+		size += r.Size()
+		if size >= minSize {
+			flush()
+		}
+	}
+	flush()
+
+	return out
+}
+
+// NewMultiReaderCoalesced создаёт мультиридер над множеством мелких источников, предварительно объединив их
+// в составные сегменты не меньше minSize (см. CoalesceSources).
+func NewMultiReaderCoalesced(buffersNum int, minSize int64, readers ...SizedReadSeekCloser) *MultiReader {
+	return NewMultiReader(buffersNum, CoalesceSources(minSize, readers...)...)
+}
+
+// compositeSource - несколько источников, склеенных в один сегмент со своими префиксными суммами.
+type compositeSource struct {
+	parts       []SizedReadSeekCloser
+	prefixSizes []int64 // начала частей внутри сегмента
+	size        int64
+	pos         int64 // логическая позиция чтения
+	cur         int   // часть, позиция которой совпадает с pos (-1 - нужно выставить Seek)
+	closed      bool
+}
+
+// Проверка, что compositeSource удовлетворяет интерфейсам SizedReadSeekCloser и ContextReader
+var (
+	_ SizedReadSeekCloser = (*compositeSource)(nil)
+	_ ContextReader       = (*compositeSource)(nil)
+)
+
+func newCompositeSource(parts []SizedReadSeekCloser) *compositeSource {
+	prefixSizes := make([]int64, len(parts)+1)
+	for i, p := range parts {
+		prefixSizes[i+1] = prefixSizes[i] + p.Size()
+	}
+
+	return &compositeSource{
+		parts:       parts,
+		prefixSizes: prefixSizes,
+		size:        prefixSizes[len(parts)],
+		cur:         -1,
+	}
+}
+
+// Read читает из части, содержащей текущую позицию, не выходя за её границу.
+func (c *compositeSource) Read(p []byte) (int, error) {
+	return c.ReadContext(context.Background(), p)
+}
+
+// ReadContext - Read, передающий ctx части, если она реализует ContextReader.
+func (c *compositeSource) ReadContext(ctx context.Context, p []byte) (int, error) {
+	if c.closed {
+		return 0, io.ErrClosedPipe
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if c.pos >= c.size {
+		return 0, io.EOF
+	}
+
+	i := sort.Search(len(c.parts), func(i int) bool { return c.prefixSizes[i+1] > c.pos })
+	if i != c.cur {
+		if _, err := c.parts[i].Seek(c.pos-c.prefixSizes[i], io.SeekStart); err != nil {
+			return 0, err
+		}
+		c.cur = i
+	}
+
+	n, err := readContext(ctx, c.parts[i], p[:min(int64(len(p)), c.prefixSizes[i+1]-c.pos)])
+	c.pos += int64(n)
+	if c.pos == c.prefixSizes[i+1] { // Часть дочитана - следующая начнётся с Seek
+		c.cur = -1
+	}
+	if errors.Is(err, io.EOF) {
+		if n == 0 {
+			return 0, io.ErrUnexpectedEOF // Часть короче объявленного размера
+		}
+		err = nil
+	}
+
+	return n, err
+}
+
+func (c *compositeSource) Seek(offset int64, whence int) (int64, error) {
+	if c.closed {
+		return 0, io.ErrClosedPipe
+	}
+
+	var base int64
+	switch whence {
+	case io.SeekStart:
+		base = 0
+	case io.SeekCurrent:
+		base = c.pos
+	case io.SeekEnd:
+		base = c.size
+	default:
+		return 0, fmt.Errorf("invalid whence: %d", whence)
+	}
+
+	seekPos := base + offset
+	if seekPos < 0 || seekPos > c.size {
+		return 0, fmt.Errorf("seek position (%d) should be >= 0 and <= size (%d)", seekPos, c.size)
+	}
+	if seekPos != c.pos {
+		c.cur = -1
+	}
+	c.pos = seekPos
+
+	return seekPos, nil
+}
+
+// Close закрывает все части, агрегируя ошибки.
+func (c *compositeSource) Close() error {
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+
+	var multiErr error
+	for _, p := range c.parts {
+		if err := p.Close(); err != nil {
+			multiErr = errors.Join(multiErr, err)
+		}
+	}
+
+	return multiErr
+}
+
+func (c *compositeSource) Size() int64 {
+	return c.size
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+// osFileProvider - источник, построенный поверх локального файла.
+type osFileProvider interface {
+	OSFile() *os.File
+}
+
+// ServeMultiReader отдаёт весь поток m в HTTP-ответ. Копирование идёт по сегментам, минуя окно префетча:
+// файловые сегменты передаются через io.ReaderFrom ответа (в net/http это sendfile), источники с io.WriterTo
+// пишут в ответ сами, остальные копируются позиционным чтением. Курсор m не сдвигается.
+func ServeMultiReader(w http.ResponseWriter, r *http.Request, m *MultiReader) {
+	w.Header().Set("Content-Length", strconv.FormatInt(m.Size(), 10))
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/octet-stream")
+	}
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	_, _ = m.writeSegmentsTo(w) // Заголовки уже отправлены - сообщить об ошибке клиенту нельзя, ответ просто обрывается
+}
+
+// sniffLen - сколько первых байт потока учитывает http.DetectContentType.
+const sniffLen = 512
+
+// SniffContentType определяет MIME-тип потока по его первым 512 байтам через http.DetectContentType - например,
+// для заголовка Content-Type перед ServeMultiReader. Байты читаются через ReadAt: курсор и окно префетча не сдвигаются.
+func (m *MultiReader) SniffContentType() (string, error) {
+	head, err := m.ReadRange(0, min(sniffLen, m.Size()))
+	if err != nil {
+		return "", err
+	}
+	return http.DetectContentType(head), nil
+}
+
+// writeSegmentsTo последовательно пишет все сегменты в w, выбирая самый дешёвый способ копирования для каждого.
+func (m *MultiReader) writeSegmentsTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	closed := m.closed
+	m.mu.Unlock()
+	if closed {
+		return 0, io.ErrClosedPipe
+	}
+
+	var written int64
+	for i := range m.readers {
+		k, err := m.writeSegmentTo(w, i)
+		written += k
+		if err != nil {
+			return written, err
+		}
+	}
+
+	return written, nil
+}
+
+// writeSegmentTo пишет i-й сегмент целиком в w. Запись в w идёт без m.srcMu, чтобы медленный клиент не останавливал
+// префетчер и ReadAt: файловый сегмент копируется из собственного дескриптора файла (в net/http это sendfile),
+// остальные - порциями по bufferSize, каждая из которых читается под m.srcMu.
+func (m *MultiReader) writeSegmentTo(w io.Writer, i int) (int64, error) {
+	prefixSizes := m.prefixes()
+	segStart, size := prefixSizes[i], prefixSizes[i+1]-prefixSizes[i]
+	if size == 0 {
+		return 0, nil
+	}
+
+	if f := m.openSegmentFile(i); f != nil {
+		defer f.Close()
+		start := time.Now()
+		n, err := io.Copy(w, io.LimitReader(f, size)) // ReadFrom ответа распознаёт *os.File за LimitedReader
+		m.noteSourceRead(i, int(n), start)
+		if err == nil && n < size { // Файл оказался короче объявленного размера
+			err = io.ErrUnexpectedEOF
+		}
+		return n, err
+	}
+
+	buf := make([]byte, min(size, bufferSize))
+	var written int64
+	for written < size {
+		chunk := buf[:min(int64(len(buf)), size-written)]
+		m.srcMu.Lock()
+		n, err := m.readAtSourcesLocked(chunk, segStart+written)
+		m.srcMu.Unlock()
+		k, werr := w.Write(chunk[:n])
+		written += int64(k)
+		if werr != nil {
+			return written, werr
+		}
+		if err != nil {
+			return written, err
+		}
+	}
+
+	return written, nil
+}
+
+// openSegmentFile открывает собственный дескриптор файла i-го сегмента. nil - сегмент не файловый
+// или файл не открылся: тогда сегмент копируется через источник.
+func (m *MultiReader) openSegmentFile(i int) *os.File {
+	fp, ok := m.readers[i].(osFileProvider)
+	if !ok {
+		return nil
+	}
+	m.srcMu.Lock()
+	defer m.srcMu.Unlock()
+	if m.ensureSourceOpenLocked(i) != nil {
+		return nil
+	}
+	f, err := os.Open(fp.OSFile().Name())
+	if err != nil {
+		return nil
+	}
+	return f
+}
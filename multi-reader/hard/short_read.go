@@ -0,0 +1,45 @@
+package main
+
+import "context"
+
+// shortReadSource ограничивает каждый Read источника k байтами.
+type shortReadSource struct {
+	src SizedReadSeekCloser
+	k   int
+}
+
+// Проверка, что shortReadSource удовлетворяет интерфейсам SizedReadSeekCloser и ContextReader
+var (
+	_ SizedReadSeekCloser = (*shortReadSource)(nil)
+	_ ContextReader       = (*shortReadSource)(nil)
+)
+
+// ShortReadSource оборачивает src так, что каждый Read отдаёт не больше k байт (как iotest.OneByteReader при k = 1).
+// Позиционное чтение и прочие возможности src обёртка намеренно скрывает: мультиридер читает её только через Read.
+// Нужен тестам, проверяющим обработку коротких чтений, в том числе на границах источников.
+func ShortReadSource(src SizedReadSeekCloser, k int) SizedReadSeekCloser {
+	return &shortReadSource{src: src, k: max(k, 1)}
+}
+
+func (s *shortReadSource) Read(p []byte) (int, error) {
+	return s.ReadContext(context.Background(), p)
+}
+
+func (s *shortReadSource) ReadContext(ctx context.Context, p []byte) (int, error) {
+	if len(p) > s.k {
+		p = p[:s.k]
+	}
+	return readContext(ctx, s.src, p)
+}
+
+func (s *shortReadSource) Seek(offset int64, whence int) (int64, error) {
+	return s.src.Seek(offset, whence)
+}
+
+func (s *shortReadSource) Close() error {
+	return s.src.Close()
+}
+
+func (s *shortReadSource) Size() int64 {
+	return s.src.Size()
+}
@@ -0,0 +1,56 @@
+package main
+
+import "context"
+
+// Shutdown - мягкая альтернатива Close: перестаёт принимать новые Read, даёт завершиться уже начатым
+// (не дольше, чем позволяет ctx), после чего закрывает мультиридер и источники.
+// Если ctx истёк раньше, незавершённые Read прерываются как при Close, а в ошибке возвращается ctx.Err().
+func (m *MultiReader) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	m.draining = true
+	m.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		m.inflight.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		return m.Close()
+	case <-ctx.Done():
+		if err := m.Close(); err != nil {
+			return err
+		}
+		<-drained // Close разбудил зависшие Read - дожидаемся их выхода
+		return ctx.Err()
+	}
+}
+
+// WaitIdle блокируется, пока не завершится горутина текущего префетчера и фоновое закрытие источников,
+// брошенных Close по таймауту (не дольше, чем позволяет ctx). Префетчер останавливается сам по достижении
+// конца потока, при ошибке или в Close; Seek за пределы окна дожидается старого префетчера сам.
+// Нужен тестам, проверяющим отсутствие утечек горутин. Read или Seek, вызванные во время ожидания,
+// могут запустить новый префетчер - его WaitIdle не ждёт.
+func (m *MultiReader) WaitIdle(ctx context.Context) error {
+	m.mu.Lock()
+	pfDone := m.pfDone
+	m.mu.Unlock()
+
+	idle := make(chan struct{})
+	go func() {
+		if pfDone != nil {
+			<-pfDone
+		}
+		m.background.Wait()
+		close(idle)
+	}()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// SourcePanicError - паника внутри источника, перехваченная мультиридером. Сторонние ридеры иногда паникуют,
+// а вызовы из горутины префетча уронили бы весь процесс - вместо этого паника доставляется как ошибка чтения.
+type SourcePanicError struct {
+	Index int    // номер источника в склейке
+	Value any    // значение, переданное в panic
+	Stack []byte // стек горутины в момент паники
+}
+
+func (e *SourcePanicError) Error() string {
+	return fmt.Sprintf("source %d panicked: %v", e.Index, e.Value)
+}
+
+// Unwrap возвращает значение паники, если это ошибка (например, runtime.Error).
+func (e *SourcePanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// guardSource вызывает f - обращение к i-му источнику - и превращает панику в *SourcePanicError.
+func guardSource(i int, f func() error) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = &SourcePanicError{Index: i, Value: p, Stack: debug.Stack()}
+		}
+	}()
+	return f()
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// SourceStats - счётчики обращений к одному источнику склейки.
+type SourceStats struct {
+	BytesRead int64         // байт прочитано из источника
+	ReadCalls int64         // обращений на чтение (Read, ReadAt или выдача блока без копирования)
+	SeekCalls int64         // вызовов Seek
+	ReadTime  time.Duration // суммарная длительность чтений
+}
+
+// sourceCounters - счётчики одного источника, обновляемые без m.mu.
+type sourceCounters struct {
+	bytes atomic.Int64
+	reads atomic.Int64
+	seeks atomic.Int64
+	nanos atomic.Int64
+}
+
+// noteSourceRead учитывает чтение n байт из i-го источника, начатое в start.
+func (m *MultiReader) noteSourceRead(i, n int, start time.Time) {
+	c := &m.srcStats[i]
+	c.bytes.Add(int64(n))
+	c.reads.Add(1)
+	c.nanos.Add(int64(time.Since(start)))
+}
+
+// noteSourceSeek учитывает вызов Seek в i-м источнике.
+func (m *MultiReader) noteSourceSeek(i int) {
+	m.srcStats[i].seeks.Add(1)
+}
+
+// sourceStats возвращает снимок счётчиков всех источников.
+func (m *MultiReader) sourceStats() []SourceStats {
+	st := make([]SourceStats, len(m.srcStats))
+	for i := range m.srcStats {
+		c := &m.srcStats[i]
+		st[i] = SourceStats{
+			BytesRead: c.bytes.Load(),
+			ReadCalls: c.reads.Load(),
+			SeekCalls: c.seeks.Load(),
+			ReadTime:  time.Duration(c.nanos.Load()),
+		}
+	}
+	return st
+}
@@ -0,0 +1,26 @@
+package main
+
+import "io"
+
+// Split делит поток на n непересекающихся подряд идущих участков почти равного размера, покрывающих его целиком.
+// Каждый участок - самостоятельный мультиридер со своим префетчером поверх m.ReadAt, поэтому участки можно читать
+// параллельно (хеширование, загрузка частей). Закрытие участка не закрывает m и его источники; после m.Close
+// чтение участков возвращает io.ErrClosedPipe. При n <= 0 возвращает nil.
+func (m *MultiReader) Split(n int) []SizedReadSeekCloser {
+	if n <= 0 {
+		return nil
+	}
+
+	size := m.size.Load()
+	parts := make([]SizedReadSeekCloser, n)
+	for i := range parts {
+		from := size * int64(i) / int64(n)
+		to := size * int64(i+1) / int64(n)
+		parts[i] = NewMultiReaderWithOptions(m.buffersNum,
+			[]SizedReadSeekCloser{sectionSource{io.NewSectionReader(m, from, to-from)}},
+			WithoutClosingSources(),
+		)
+	}
+
+	return parts
+}
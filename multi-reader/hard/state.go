@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// SourceState - идентичность источника в сохранённом состоянии.
+type SourceState struct {
+	Name string `json:"name,omitempty"` // имя источника (Name() string: файлы, ленивые источники с LazySpec.Name)
+	Size int64  `json:"size"`           // размер источника
+}
+
+// State - сериализуемое состояние чтения: позиция курсора и состав источников. Позволяет другому процессу
+// продолжить чтение ровно с того места, где остановился предыдущий (см. NewMultiReaderFromState).
+type State struct {
+	Offset    int64         `json:"offset"`     // абсолютная позиция курсора
+	TotalSize int64         `json:"total_size"` // суммарный размер источников
+	Sources   []SourceState `json:"sources"`    // источники в порядке склейки
+}
+
+// StateOpener открывает i-й источник сохранённого состояния.
+type StateOpener func(i int, src SourceState) (SizedReadSeekCloser, error)
+
+// SaveState возвращает текущее состояние чтения. Имя источника берётся из его метода Name() string, если он есть.
+func (m *MultiReader) SaveState() State {
+	m.mu.Lock()
+	offset := m.absPos
+	m.mu.Unlock()
+
+	sources := make([]SourceState, len(m.readers))
+	for i, r := range m.readers {
+		sources[i].Size = r.Size()
+		sources[i].Name = sourceName(r)
+	}
+
+	return State{Offset: offset, TotalSize: m.size.Load(), Sources: sources}
+}
+
+// NewMultiReaderFromState открывает источники состояния st через open, сверяет их размеры с сохранёнными
+// и ставит курсор на st.Offset. Расхождение размеров возвращает ошибку, для которой errors.Is(err, ErrSourceChanged).
+func NewMultiReaderFromState(buffersNum int, st State, open StateOpener, opts ...Option) (*MultiReader, error) {
+	if st.Offset < 0 || st.Offset > st.TotalSize {
+		return nil, fmt.Errorf("malformed state: offset %d, total size %d", st.Offset, st.TotalSize)
+	}
+
+	readers := make([]SizedReadSeekCloser, 0, len(st.Sources))
+	closeOpened := func(err error) error {
+		for _, r := range readers {
+			err = errors.Join(err, r.Close())
+		}
+		return err
+	}
+	var total int64
+	for i, src := range st.Sources {
+		r, err := open(i, src)
+		if err != nil {
+			return nil, closeOpened(fmt.Errorf("open source %d: %w", i, err))
+		}
+		readers = append(readers, r)
+		if r.Size() != src.Size {
+			return nil, closeOpened(fmt.Errorf("source %d: size %d differs from saved %d: %w", i, r.Size(), src.Size, ErrSourceChanged))
+		}
+		total += src.Size
+	}
+	if total != st.TotalSize {
+		return nil, closeOpened(fmt.Errorf("malformed state: sources sum to %d, total size %d", total, st.TotalSize))
+	}
+
+	m := NewMultiReaderWithOptions(buffersNum, readers, opts...)
+	if _, err := m.Seek(st.Offset, io.SeekStart); err != nil {
+		return nil, errors.Join(err, m.Close())
+	}
+
+	return m, nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import "sync/atomic"
+
+// Stats - снимок заполненности окна и счётчиков конвейера префетча. Помогает подобрать buffersNum
+// по замерам: частые ProducerStalls означают, что потребитель не успевает и окно избыточно,
+// частые ConsumerStalls - что префетчер не успевает и окно стоит увеличить.
+type Stats struct {
+	BlocksBuffered   int           // блоков в очереди префетчера
+	BytesBuffered    int64         // байт в очереди префетчера (сжатые блоки - по сжатому размеру) и в непрочитанной части окна
+	ProducerStalls   int64         // сколько раз префетчер ждал места в окне
+	ConsumerStalls   int64         // сколько раз чтение ждало блок от префетчера
+	PrefetchRestarts int64         // сколько раз префетчер запускался заново (после Seek за пределы окна)
+	CacheHits        int64         // попадания в кэш блоков (WithBlockCache)
+	CacheMisses      int64         // промахи кэша блоков
+	CacheEvictions   int64         // блоков кэша, вытесненных из памяти сверх бюджета
+	CacheExpired     int64         // блоков кэша, выброшенных по истечении времени жизни (WithCacheTTL)
+	SpilledBytes     int64         // байт блоков кэша, сброшенных на дисковый уровень (WithDiskCache), до сжатия
+	SpillSaved       int64         // байт, не записанных на диск благодаря сжатию (WithDiskCacheCompression)
+	WindowMemory     int64         // байт памяти под окно (ёмкость его массива) и очередь префетчера
+	CacheMemory      int64         // байт блоков кэша в памяти (дисковый уровень не учитывается)
+	StuckReads       int64         // срабатывания сторожа зависших чтений (WithWatchdog)
+	PressureStalls   int64         // сколько раз префетчер придержал блок из-за близости к GOMEMLIMIT (WithMemoryLimitAware)
+	CompressionSaved int64         // байт, сэкономленных сжатием блоков очереди (WithWindowCompression)
+	Sources          []SourceStats // счётчики по источникам в порядке склейки
+}
+
+// pipelineStats - счётчики конвейера, обновляемые без m.mu.
+type pipelineStats struct {
+	producerStalls atomic.Int64
+	consumerStalls atomic.Int64
+	starts         atomic.Int64 // запуски префетчера
+	stuckReads     atomic.Int64 // срабатывания сторожа
+	pressureStalls atomic.Int64 // блоки, придержанные из-за давления памяти
+}
+
+// Stats возвращает текущее состояние окна и накопленные счётчики.
+func (m *MultiReader) Stats() Stats {
+	m.mu.Lock()
+	st := Stats{
+		BlocksBuffered: len(m.pfBufCh),
+		BytesBuffered:  int64(len(m.windowBuf)) + max(m.pfQueued.Load(), 0), // Блок могут забрать раньше, чем его учтёт префетчер
+		WindowMemory:   m.windowMem + max(m.pfQueued.Load(), 0),
+	}
+	m.mu.Unlock()
+
+	st.ProducerStalls = m.stats.producerStalls.Load()
+	st.ConsumerStalls = m.stats.consumerStalls.Load()
+	st.PrefetchRestarts = max(m.stats.starts.Load()-1, 0)
+	st.StuckReads = m.stats.stuckReads.Load()
+	st.PressureStalls = m.stats.pressureStalls.Load()
+	st.Sources = m.sourceStats()
+	if m.compress != nil {
+		st.CompressionSaved = m.compress.saved.Load()
+	}
+	if c := m.cache; c != nil {
+		c.mu.Lock()
+		st.CacheHits, st.CacheMisses = c.hits, c.misses
+		st.CacheEvictions, st.CacheMemory = c.evicted, c.used
+		st.CacheExpired = c.expired
+		if c.disk != nil {
+			st.SpilledBytes, st.SpillSaved = c.disk.spilled, c.disk.spilled-c.disk.written
+		}
+		c.mu.Unlock()
+	}
+
+	return st
+}
@@ -0,0 +1,52 @@
+package main
+
+import "context"
+
+// stepScheduler - детерминированный планировщик для тестов: останавливает префетчер в выбранных точках
+// и отпускает его только по команде теста (или при отмене контекста префетча).
+type stepScheduler struct {
+	pause   map[schedPoint]bool // точки, в которых префетчер останавливается
+	reached chan schedPoint     // префетчер сообщает, в какой точке остановился
+	resume  chan struct{}       // тест разрешает продолжить
+}
+
+func newStepScheduler(points ...schedPoint) *stepScheduler {
+	pause := make(map[schedPoint]bool, len(points))
+	for _, p := range points {
+		pause[p] = true
+	}
+	return &stepScheduler{
+		pause:   pause,
+		reached: make(chan schedPoint),
+		resume:  make(chan struct{}),
+	}
+}
+
+func (s *stepScheduler) Go(f func()) {
+	go f()
+}
+
+func (s *stepScheduler) Yield(ctx context.Context, p schedPoint) {
+	if !s.pause[p] {
+		return
+	}
+	select {
+	case s.reached <- p:
+	case <-ctx.Done():
+		return
+	}
+	select {
+	case <-s.resume:
+	case <-ctx.Done():
+	}
+}
+
+// await дожидается остановки префетчера в точке p.
+func (s *stepScheduler) await(p schedPoint) bool {
+	return <-s.reached == p
+}
+
+// step отпускает префетчер, остановленный в текущей точке.
+func (s *stepScheduler) step() {
+	s.resume <- struct{}{}
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+// StressConfig задаёт один стресс-прогон: склейку из случайных источников и случайную последовательность операций.
+type StressConfig struct {
+	Seed      int64 // сид генератора: прогон с тем же сидом повторяет те же источники, опции и операции
+	Ops       int   // операций Read/Seek курсора
+	Workers   int   // горутин, параллельно читающих через ReadAt
+	Sources   int   // максимум источников в склейке
+	MaxSource int   // максимальный размер источника; размеры чтений пропорциональны ему
+}
+
+// RunStress строит по cfg.Seed склейку со случайными опциями и гоняет случайные Read/Seek курсора, параллельные ReadAt
+// и Close в случайный момент. Результаты Read/Seek сверяются с эталоном - последовательной реализацией из easy,
+// ReadAt - с содержимым склейки. Возвращает первое расхождение с сидом и номером операции.
+// Гонки в префетчере ловит race-детектор: запускайте под `go run -race . stress` или `go test -race`.
+func RunStress(cfg StressConfig) error {
+	rnd := rand.New(rand.NewSource(cfg.Seed))
+	contents := make([][]byte, 1+rnd.Intn(max(cfg.Sources, 1)))
+	var all []byte
+	for i := range contents {
+		size := 0
+		if rnd.Intn(4) != 0 { // Каждый четвёртый источник пустой
+			size = rnd.Intn(max(cfg.MaxSource, 1) + 1)
+		}
+		contents[i] = make([]byte, size)
+		FillSynthetic(cfg.Seed+int64(i), 0, contents[i])
+		all = append(all, contents[i]...)
+	}
+
+	readers := make([]SizedReadSeekCloser, len(contents))
+	refReaders := make([]SizedReadSeekCloser, len(contents))
+	for i, c := range contents {
+		readers[i] = NewBytesReader(c)
+		if rnd.Intn(2) == 0 {
+			readers[i] = ShortReadSource(readers[i], 1+rnd.Intn(max(cfg.MaxSource, 1)))
+		}
+		refReaders[i] = NewBytesReader(c)
+	}
+	var opts []Option
+	if rnd.Intn(3) == 0 {
+		opts = append(opts, WithBlockCache(int64(1+rnd.Intn(4))*bufferSize))
+	}
+	if rnd.Intn(3) == 0 {
+		opts = append(opts, WithReadConcurrency(1+rnd.Intn(4)))
+	}
+	if rnd.Intn(3) == 0 {
+		opts = append(opts, WithCoalescedReads())
+	}
+	if rnd.Intn(4) == 0 {
+		opts = append(opts, WithPrefetch(false))
+	}
+	m := NewMultiReaderWithOptions(1+rnd.Intn(4), readers, opts...)
+	defer m.Close()
+	ref := newReferenceReader(refReaders...)
+
+	fail := func(op int, format string, args ...any) error {
+		return fmt.Errorf("stress seed %d, op %d: %s", cfg.Seed, op, fmt.Sprintf(format, args...))
+	}
+
+	// Читатели ReadAt работают до конца прогона, в том числе одновременно с Close
+	stop := make(chan struct{})
+	errs := make(chan error, cfg.Workers)
+	var wg sync.WaitGroup
+	for w := range cfg.Workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			wrnd := rand.New(rand.NewSource(cfg.Seed ^ int64(w+1)<<32))
+			for op := 0; ; op++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				off := wrnd.Int63n(int64(len(all)) + 1)
+				p := make([]byte, wrnd.Intn(2*cfg.MaxSource+1))
+				n, err := m.ReadAt(p, off)
+				if errors.Is(err, io.ErrClosedPipe) {
+					return
+				}
+				want := all[min(off, int64(len(all))):min(off+int64(len(p)), int64(len(all)))]
+				if n != len(want) || !bytes.Equal(p[:n], want) || (err == nil && n < len(p) && len(p) > 0) {
+					errs <- fail(op, "worker %d: ReadAt(len %d, off %d) = %d, %v", w, len(p), off, n, err)
+					return
+				}
+			}
+		}()
+	}
+	defer func() {
+		close(stop)
+		wg.Wait()
+	}()
+
+	closeAt := -1
+	if rnd.Intn(2) == 0 {
+		closeAt = rnd.Intn(max(cfg.Ops, 1))
+	}
+	for op := range cfg.Ops {
+		select {
+		case err := <-errs:
+			return err
+		default:
+		}
+		if op == closeAt {
+			closed := make(chan error, 1)
+			go func() { closed <- m.Close() }() // Close из другой горутины, пока читатели ReadAt работают
+			if err := <-closed; err != nil {
+				return fail(op, "Close: %v", err)
+			}
+			_ = ref.Close()
+		}
+
+		switch rnd.Intn(3) {
+		case 0, 1:
+			size := rnd.Intn(3*cfg.MaxSource + 1)
+			got, want := make([]byte, size), make([]byte, size)
+			n, err := m.Read(got)
+			refN, refErr := ref.Read(want)
+			if n != refN || readClass(n, err) != readClass(refN, refErr) || !bytes.Equal(got[:n], want[:refN]) {
+				return fail(op, "Read(%d) = %d, %v; reference %d, %v", size, n, err, refN, refErr)
+			}
+		default:
+			whence := rnd.Intn(4) // 3 - недопустимый whence
+			offset := rnd.Int63n(int64(len(all))+2) - rnd.Int63n(int64(len(all))+2)
+			pos, err := m.Seek(offset, whence)
+			refPos, refErr := ref.Seek(offset, whence)
+			if errorClass(err) != errorClass(refErr) || (err == nil && pos != refPos) {
+				return fail(op, "Seek(%d, %d) = %d, %v; reference %d, %v", offset, whence, pos, err, refPos, refErr)
+			}
+		}
+	}
+
+	select {
+	case err := <-errs:
+		return err
+	default:
+	}
+	return nil
+}
+
+// errorClass сводит ошибку к классу для сверки с эталоном: тексты ошибок реализаций различаются.
+func errorClass(err error) string {
+	switch {
+	case err == nil:
+		return "nil"
+	case errors.Is(err, io.EOF):
+		return "EOF"
+	case errors.Is(err, io.ErrClosedPipe):
+		return "closed"
+	default:
+		return "error"
+	}
+}
+
+// readClass - errorClass для результата Read: io.Reader может вернуть EOF вместе с последними байтами
+// или следующим вызовом, поэтому n > 0 с EOF равнозначно n > 0 без ошибки.
+func readClass(n int, err error) string {
+	if n > 0 && errors.Is(err, io.EOF) {
+		return "nil"
+	}
+	return errorClass(err)
+}
+
+// runStress - режим стресс-тестов: `go run -race . stress -seed 1 -iterations 100`.
+func runStress(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("stress", flag.ContinueOnError)
+	fs.SetOutput(out)
+	seed := fs.Int64("seed", 1, "сид первого прогона (следующие - seed+1, seed+2, ...)")
+	iterations := fs.Int("iterations", 100, "число прогонов")
+	ops := fs.Int("ops", 500, "операций курсора за прогон")
+	maxSource := fs.Int("max-source", 2*bufferSize, "максимальный размер источника")
+	workers := fs.Int("workers", 4, "параллельных читателей ReadAt")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	for i := range *iterations {
+		cfg := StressConfig{Seed: *seed + int64(i), Ops: *ops, Workers: *workers, Sources: 6, MaxSource: *maxSource}
+		if err := RunStress(cfg); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(out, "stress: %d iterations ok\n", *iterations)
+	return err
+}
+
+// referenceReader - эталон для стресс-тестов: последовательная реализация MultiReader из easy без префетча и блокировок.
+type referenceReader struct {
+	readers     []SizedReadSeekCloser
+	totalSize   int64
+	prefixSizes []int64 // prefixSizes[i] - абсолютная стартовая позиция i-го ридера
+	absPos      int64
+	needSeek    bool
+	closed      bool
+}
+
+func newReferenceReader(readers ...SizedReadSeekCloser) *referenceReader {
+	prefixSizes := make([]int64, len(readers)+1)
+	var total int64
+	for i, r := range readers {
+		prefixSizes[i] = total
+		total += r.Size()
+	}
+	prefixSizes[len(readers)] = total
+
+	return &referenceReader{readers: readers, totalSize: total, prefixSizes: prefixSizes, needSeek: true}
+}
+
+func (m *referenceReader) Read(p []byte) (n int, err error) {
+	if m.closed {
+		return 0, io.ErrClosedPipe
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if m.absPos == m.totalSize {
+		return 0, io.EOF
+	}
+
+	for n < len(p) && m.absPos < m.totalSize {
+		i := sort.Search(len(m.readers), func(i int) bool {
+			return m.prefixSizes[i+1] > m.absPos
+		})
+		if m.needSeek {
+			if _, err := m.readers[i].Seek(m.absPos-m.prefixSizes[i], io.SeekStart); err != nil {
+				return n, err
+			}
+			m.needSeek = false
+		}
+
+		k, readErr := m.readers[i].Read(p[n:])
+		n += k
+		m.absPos += int64(k)
+		if m.absPos == m.prefixSizes[i+1] { // Следующий ридер мог быть прочитан раньше - выставим его позицию
+			m.needSeek = true
+		}
+		switch {
+		case readErr == nil && k == 0:
+			return n, nil
+		case errors.Is(readErr, io.EOF):
+			m.absPos = m.prefixSizes[i+1]
+			m.needSeek = true
+		case readErr != nil:
+			return n, readErr
+		}
+	}
+
+	return n, nil
+}
+
+func (m *referenceReader) Seek(offset int64, whence int) (int64, error) {
+	if m.closed {
+		return 0, io.ErrClosedPipe
+	}
+
+	var base int64
+	switch whence {
+	case io.SeekStart:
+		base = 0
+	case io.SeekCurrent:
+		base = m.absPos
+	case io.SeekEnd:
+		base = m.totalSize
+	default:
+		return 0, fmt.Errorf("invalid whence: %d", whence)
+	}
+
+	seekPos := base + offset
+	if seekPos < 0 || seekPos > m.totalSize {
+		return 0, fmt.Errorf("seek position (%d) should be >= 0 and <= size (%d)", seekPos, m.totalSize)
+	}
+	m.absPos = seekPos
+	m.needSeek = true
+
+	return seekPos, nil
+}
+
+func (m *referenceReader) Close() error {
+	m.closed = true
+	return nil
+}
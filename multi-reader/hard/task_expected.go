@@ -5,8 +5,11 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
 	"sort"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // SizedReadSeekCloser - интерфейс ридера с возможностью seek и знанием своего размера.
@@ -23,25 +26,86 @@ const (
 // MultiReader объединяет несколько SizedReadSeekCloser в единый конкатенированный поток и поддерживает асинхронный префетч
 type MultiReader struct {
 	readers     []SizedReadSeekCloser // исходные ридеры
-	totalSize   int64                 // суммарный размер всех источников
-	prefixSizes []int64               // абсолютные стартовые позиции ридеров (префиксные суммы)
+	coarseIndex []int64               // каждая indexChunk-я префиксная сумма (nil при небольшом числе ридеров)
 	absPos      int64                 // абсолютная позиция курсора чтения (пользователя)
 	windowBuf   []byte                // текущее окно данных
 	windowStart int64                 // абсолютная позиция начала окна
+	windowMem   int64                 // ёмкость массива окна в байтах - память, которую окно держит (для Stats)
 	buffersNum  int                   // количество буферов
 	pfBufCh     chan []byte           // буферизированный канал блоков, наполняется префетчером
 	pfErrCh     chan error            // канал для ошибки/EOF от префетчера (ёмкость 1)
 	pfCancel    context.CancelFunc    // отмена контекста префетчера
 	pfDone      chan struct{}         // сигнал завершения горутины префетчера
 	pfStarted   bool                  // флаг запуска префетчера
-	mu          sync.Mutex            // мьютекс для блокировок
+	pfErr       error                 // итоговая ошибка/EOF префетчера, уже забранная из pfErrCh
+	pfQueued    *atomic.Int64         // байт в очереди текущего префетчера; новый счётчик после каждого сброса префетча
+	mu          sync.Mutex            // мьютекс окна, курсора и состояния префетча; сам префетчер его не берёт - блоки идут через pfBufCh
+	srcMu       sync.Mutex            // мьютекс доступа к исходным ридерам (префетчер и ReadAt)
+	srcGen      uint64                // счётчик позиционных чтений; префетчер сверяется с ним, чтобы понять, что позиция источника сбита
 	closed      bool                  // флаг закрытия мультиридера
+	closeCh     chan struct{}         // закрывается в Close, будит ожидающие блок Read; проверка закрытия без m.mu
+	sched       scheduler             // запуск горутины префетча и точки синхронизации (подменяется в тестах)
+
+	// Раскладка потока читается без блокировок (ReadAt, представления, хуки), а меняется только в Refresh:
+	// размер - атомарно, префиксные суммы - публикацией новой копии среза.
+	size   atomic.Int64            // суммарный размер всех источников
+	prefix atomic.Pointer[[]int64] // абсолютные стартовые позиции ридеров (префиксные суммы)
+
+	coldStartTimeout time.Duration    // лимит ожидания первого блока после запуска префетча (0 - без лимита)
+	pfWarm           bool             // флаг - от текущего префетчера уже получен хотя бы один блок
+	eagerClose       bool             // флаг - закрывать источник сразу, как только префетчер прошёл его целиком
+	srcClosed        []bool           // srcClosed[i] - i-й источник закрыт досрочно (под srcMu)
+	eagerCloseErr    error            // ошибки досрочного закрытия, возвращаются из Close (под srcMu)
+	consistencyToken string           // токен согласованности, переданный источникам
+	borrowedSources  bool             // флаг - источники принадлежат вызывающему и не закрываются в Close
+	closeWorkers     int              // сколько источников закрывается одновременно (<= 1 - последовательно)
+	closeOrder       CloseOrder       // порядок закрытия источников
+	onSourceClose    func(int, error) // хук, вызываемый после закрытия каждого источника
+	closeGrace       time.Duration    // сколько Close ждёт остановки префетчера (0 - без лимита)
+	draining         bool             // флаг - идёт Shutdown, новые Read не принимаются
+	inflight         sync.WaitGroup   // выполняющиеся в данный момент Read
+	replay           *replayRecorder  // запись дайджестов отданного префикса (nil - выключена)
+	digest           *streamDigest    // хеш всех отданных байт (nil - выключен)
+	cacheTTL         time.Duration    // время жизни блоков кэша (0 - без ограничения)
+	diskCompress     bool             // флаг - сжимать блоки, сбрасываемые на дисковый уровень кэша
+	hooks            *Hooks           // пользовательские перехватчики (nil - не заданы)
+	hookPos          *hookCursor      // последние отданные байты для событий границ источников (при hooks != nil)
+	parallelReads    int              // сколько позиционных чтений префетчер держит в полёте (<= 1 - по одному)
+	coalesceReads    bool             // флаг - склеивать чтения мелких источников в один блок
+	readAtAny        bool             // флаг - читать позиционно любой источник, реализующий io.ReaderAt
+	fadvise          bool             // флаг - передавать ядру подсказки posix_fadvise для файловых источников
+	unread           []byte           // последняя единица, прочитанная ReadByte/ReadRune (для Unread*)
+	unreadPos        int64            // позиция курсора сразу после неё; иначе Unread* недопустим
+	unreadRune       bool             // флаг - единица прочитана ReadRune
+	tee              *teeWriter       // копия отданных байт (nil - выключена)
+	cache            *blockCache      // LRU-кэш блоков по абсолютному смещению (nil - выключен)
+	pinMu            sync.Mutex       // защищает pins
+	pins             []*pinnedRegion  // закреплённые области, переживающие Seek
+	stats            pipelineStats    // счётчики конвейера для Stats
+	srcStats         []sourceCounters // счётчики по источникам для Stats
+	background       sync.WaitGroup   // фоновое закрытие источников после брошенного префетчера
+	noPrefetch       bool             // флаг - префетч выключен, Read читает из источников синхронно
+	eofStyle         EOFStyle         // как Read сообщает о конце потока (WithEOFStyle)
+	maxEmptyReads    int              // сколько чтений (0, nil) подряд допускается до io.ErrNoProgress
+	probeSeek        bool             // флаг - NewMultiReaderE проверяет Seek каждого источника
+	follow           bool             // флаг - режим слежения за растущим последним источником (WithFollow)
+	followPoll       time.Duration    // интервал опроса размера в режиме слежения (0 - сразу ErrWouldBlock)
+	trace            *traceRecorder   // запись трассы операций (nil - выключена)
+	readDeadline     time.Time        // срок чтений из SetReadDeadline (нулевой - без срока)
+	readTimeout      time.Duration    // лимит ожидания одного Read (WithReadTimeout, 0 - без лимита)
+	deadlineCh       chan struct{}    // закрывается при смене срока, будит ожидающие блок чтения
+	watchdog         *Watchdog        // сторож зависших чтений (nil - выключен)
+	compress         *windowPacker    // сжатие блоков в очереди префетчера (nil - выключено)
+	memPressure      *memoryPressure  // сжатие окна при близости к GOMEMLIMIT (nil - выключено)
+	pfSpace          chan struct{}    // сигнал префетчеру, что потребитель забрал блок (nil - никто не ждёт)
 }
 
 // Проверка, что MultiReader удовлетворяет интерфейсу SizedReadSeekCloser
 var _ SizedReadSeekCloser = (*MultiReader)(nil)
 
-// NewMultiReader создаёт конкатенированный ридер с поддержкой асинхронного префетча
+// NewMultiReader создаёт конкатенированный ридер с поддержкой асинхронного префетча.
+// Размеры источников не проверяются: отрицательный размер (UnknownSize) испортит префиксные суммы -
+// источники, в которых нет уверенности, проверяет NewMultiReaderE.
 func NewMultiReader(buffersNum int, readers ...SizedReadSeekCloser) *MultiReader {
 	if buffersNum <= 0 {
 		buffersNum = defaultBuffersNum
@@ -54,34 +118,68 @@ func NewMultiReader(buffersNum int, readers ...SizedReadSeekCloser) *MultiReader
 		total += r.Size()
 	}
 	prefixSizes[len(readers)] = total
+	openReaders.Add(1)
 
-	return &MultiReader{
+	m := &MultiReader{
 		readers:     readers,
-		totalSize:   total,
-		prefixSizes: prefixSizes,
+		coarseIndex: buildCoarseIndex(prefixSizes),
 		buffersNum:  buffersNum,
+		pfQueued:    new(atomic.Int64),
+		closeCh:     make(chan struct{}),
+		sched:       goScheduler{},
+		srcStats:    make([]sourceCounters, len(readers)),
+
+		maxEmptyReads: defaultMaxEmptyReads,
 	}
+	m.size.Store(total)
+	m.prefix.Store(&prefixSizes)
+
+	return m
 }
 
-// Read читает данные из внутреннего окна, пополняемого префетчером.
+// Read читает данные из внутреннего окна, пополняемого префетчером. В режиме WithFollow конец потока не окончателен.
 func (m *MultiReader) Read(p []byte) (n int, err error) {
+	if m.trace != nil {
+		defer func() { m.trace.read(len(p), p[:n], err) }()
+	}
+	if m.follow {
+		return m.readFollow(p)
+	}
+	return m.read(p)
+}
+
+// read - Read без режима слежения: конец потока окончателен.
+func (m *MultiReader) read(p []byte) (n int, err error) {
 	if len(p) == 0 {
 		return 0, nil
 	}
-
-	m.mu.Lock()
-	if m.closed {
-		m.mu.Unlock()
-		return 0, io.ErrClosedPipe
+	if len(p) <= smallReadMax {
+		if pos, ok := m.readSmall(p); ok {
+			err := m.delivered(pos, p, nil)
+			m.inflight.Done()
+			return len(p), err
+		}
 	}
-	if m.absPos == m.totalSize {
-		m.mu.Unlock()
-		return 0, io.EOF
+
+	start := time.Now()
+	startPos, err := m.beginRead()
+	if err != nil {
+		return 0, err
 	}
-	if !m.pfStarted {
-		m.startPrefetchLocked(m.absPos)
+	defer m.inflight.Done()
+	defer func() {
+		if derr := m.delivered(startPos, p[:n], err); derr != nil && err == nil {
+			err = derr
+		}
+	}()
+	if m.eofStyle != EOFDefault {
+		defer func() {
+			m.mu.Lock()
+			atEnd := m.absPos == m.size.Load()
+			m.mu.Unlock()
+			err = m.eofStyle.apply(n, err, atEnd)
+		}()
 	}
-	m.mu.Unlock()
 
 	for n < len(p) {
 		// Пытаемся прочитать из окна без ожидания каналов
@@ -94,34 +192,178 @@ func (m *MultiReader) Read(p []byte) (n int, err error) {
 			continue
 		}
 
-		// Окно пусто - ждём новый блок от префетчера
-		buf, okPf := <-m.pfBufCh
-		if !okPf {
-			// Канал данных закрыт - считываем итоговую ошибку/EOF
-			select {
-			case err = <-m.pfErrCh:
-			default:
-				err = io.EOF
+		// Окно пусто - без префетча читаем из источников сразу в p
+		if m.noPrefetch {
+			k, err := m.readDirect(p[n:])
+			n += k
+			if err != nil {
+				return n, err
 			}
+			continue
+		}
+
+		// Окно пусто - ждём новый блок от префетчера
+		if err := m.fillWindow(start); err != nil {
 			return n, err
 		}
-		m.mu.Lock()
-		m.windowBuf = append(m.windowBuf, buf...)
-		m.mu.Unlock()
 	}
 
 	return n, nil
 }
 
+// beginRead проверяет состояние перед чтением, запускает префетч и регистрирует чтение в inflight.
+// Возвращает позицию курсора; при успехе вызывающий обязан вызвать m.inflight.Done().
+func (m *MultiReader) beginRead() (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed || m.draining {
+		return 0, io.ErrClosedPipe
+	}
+	if m.absPos == m.size.Load() {
+		return 0, io.EOF
+	}
+	if !m.pfStarted && !m.noPrefetch {
+		m.startPrefetchLocked(m.absPos)
+	}
+	m.inflight.Add(1)
+
+	return m.absPos, nil
+}
+
+// fillWindow ждёт следующий блок от префетчера и дописывает его в окно. По окончании потока возвращает итоговую ошибку/EOF.
+// start - момент начала чтения, от которого отсчитывается WithReadTimeout.
+func (m *MultiReader) fillWindow(start time.Time) error {
+	if m.noPrefetch {
+		return m.fillWindowDirect()
+	}
+
+	buf, okPf, err := m.waitBlock(start)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !okPf { // Канал данных закрыт
+		return m.prefetchResultLocked()
+	}
+	if m.closed { // Блок может ссылаться на память закрываемого источника (mmap) - не трогаем его
+		return io.ErrClosedPipe
+	}
+	m.appendWindowLocked(buf)
+	m.pfWarm = true
+
+	return nil
+}
+
+// readDirect читает в p с позиции курсора напрямую из источников (или через кэш блоков) и сдвигает курсор.
+// Используется вместо окна при выключенном префетче; m.mu удерживается всё чтение, поэтому
+// конкурентные Read и Seek сериализуются так же, как в easy-варианте.
+func (m *MultiReader) readDirect(p []byte) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return 0, io.ErrClosedPipe
+	}
+	if m.absPos == m.size.Load() {
+		return 0, io.EOF
+	}
+	p = p[:min(int64(len(p)), m.size.Load()-m.absPos)]
+
+	var n int
+	var err error
+	if m.cache != nil {
+		n, err = m.readAtCached(p, m.absPos)
+	} else {
+		m.srcMu.Lock()
+		n, err = m.readAtSourcesLocked(p, m.absPos)
+		m.srcMu.Unlock()
+	}
+	m.absPos += int64(n)
+	m.windowStart = m.absPos
+
+	return n, err
+}
+
+// fillWindowDirect дописывает в окно блок, прочитанный синхронно из источников. Нужен Peek, ReadByte и другим
+// читателям окна при выключенном префетче.
+func (m *MultiReader) fillWindowDirect() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return io.ErrClosedPipe
+	}
+	pos := m.windowStart + int64(len(m.windowBuf))
+	if pos >= m.size.Load() {
+		return io.EOF
+	}
+	block := make([]byte, min(bufferSize, m.size.Load()-pos))
+
+	var n int
+	var err error
+	if m.cache != nil {
+		n, err = m.readAtCached(block, pos)
+	} else {
+		m.srcMu.Lock()
+		n, err = m.readAtSourcesLocked(block, pos)
+		m.srcMu.Unlock()
+	}
+	m.appendWindowLocked(block[:n])
+
+	return err
+}
+
+// delivered уведомляет подписчиков (хуки, запись дайджестов и т.п.) о байтах data, отданных потребителю с позиции pos.
+// Возвращает ошибку записи в tee-писатель: вызывающий отдаёт её потребителю, если своей ошибки нет.
+func (m *MultiReader) delivered(pos int64, data []byte, err error) error {
+	if m.hooks != nil {
+		m.hooks.afterRead(m, pos, data, err) // Хук может изменить данные - дайджесты считаем уже по ним
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	bytesStreamed.Add(int64(len(data)))
+	if m.replay != nil {
+		m.replay.observe(pos, data)
+	}
+	if m.digest != nil {
+		m.digest.write(data)
+	}
+	m.adviseConsumed(pos, int64(len(data)))
+	if m.tee != nil {
+		return m.tee.write(data)
+	}
+
+	return nil
+}
+
 // Seek перемещает курсор
 func (m *MultiReader) Seek(offset int64, whence int) (int64, error) {
 	m.mu.Lock()
-	defer m.mu.Unlock()
+	from := m.absPos
+	seekPos, err := m.seekLocked(offset, whence)
+	m.mu.Unlock()
+
+	if err == nil && m.hooks != nil && m.hooks.OnSeek != nil {
+		m.hooks.OnSeek(from, seekPos)
+	}
+	if m.trace != nil {
+		m.trace.seek(offset, whence, seekPos, err)
+	}
+
+	return seekPos, err
+}
 
+// seekLocked перемещает курсор; вызывается под m.mu.
+func (m *MultiReader) seekLocked(offset int64, whence int) (int64, error) {
 	if m.closed {
 		return 0, io.ErrClosedPipe
 	}
 
+	totalSize := m.size.Load()
 	var base int64
 	switch whence {
 	case io.SeekStart:
@@ -129,22 +371,24 @@ func (m *MultiReader) Seek(offset int64, whence int) (int64, error) {
 	case io.SeekCurrent:
 		base = m.absPos
 	case io.SeekEnd:
-		base = m.totalSize
+		base = totalSize
 	default:
 		return 0, fmt.Errorf("invalid whence: %d", whence)
 	}
 
 	seekPos := base + offset
-	if seekPos < 0 || seekPos > m.totalSize {
-		return 0, fmt.Errorf("seek position (%d) should be >= 0 and <= totalSize (%d)", seekPos, m.totalSize)
+	if seekPos < 0 || seekPos > totalSize {
+		return 0, fmt.Errorf("seek position (%d) should be >= 0 and <= totalSize (%d)", seekPos, totalSize)
 	}
 
 	delta := seekPos - m.windowStart
 	switch {
 	case 0 <= delta && delta < int64(len(m.windowBuf)): // Быстрый путь: позиция внутри текущего окна - только сдвигаем смещение
 		m.windowBuf = m.windowBuf[delta:]
+	case delta > 0 && m.pfStarted && m.takeQueuedLocked(seekPos): // Позиция в блоках, уже лежащих в очереди, - префетчер продолжает работу
+		m.windowBuf = m.windowBuf[seekPos-m.windowStart:]
 	default: // Вне окна: сбрасываем окно и перезапускаем префетч при следующем чтении
-		m.windowBuf = nil
+		m.windowBuf, m.windowMem = nil, 0
 		if m.pfStarted {
 			m.resetPrefetchLocked()
 		}
@@ -156,14 +400,52 @@ func (m *MultiReader) Seek(offset int64, whence int) (int64, error) {
 	return seekPos, nil
 }
 
+// takeQueuedLocked переносит в окно блоки, уже опубликованные префетчером, пока окно не дойдёт до seekPos.
+// Блоки целиком до seekPos выбрасываются. Возвращает false, не трогая очередь, если seekPos за её пределами:
+// тогда Seek сбрасывает префетч как обычно. Требует удержания m.mu
+func (m *MultiReader) takeQueuedLocked(seekPos int64) bool {
+	if seekPos >= m.windowStart+int64(len(m.windowBuf))+m.pfQueued.Load() { // Счётчик растёт после отправки блока - нижняя оценка очереди
+		return false
+	}
+	for m.windowStart+int64(len(m.windowBuf)) <= seekPos {
+		select {
+		case buf, ok := <-m.pfBufCh:
+			if !ok {
+				return false
+			}
+			buf, err := m.takeBlock(m.pfQueued, buf)
+			if err != nil {
+				return false
+			}
+			if end := m.windowStart + int64(len(m.windowBuf)) + int64(len(buf)); end <= seekPos {
+				m.windowBuf, m.windowMem, m.windowStart = nil, 0, end
+				continue
+			}
+			m.appendWindowLocked(buf)
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
 // Close завершает префетч и закрывает все источники, агрегируя ошибки.
 func (m *MultiReader) Close() error {
+	return m.CloseContext(context.Background())
+}
+
+// CloseContext работает как Close, но не ждёт дольше, чем позволяет ctx: зависшее закрытие источника
+// (или остановка префетчера) доводится в фоне (его дожидается WaitIdle), а возвращается ошибка с ctx.Err().
+func (m *MultiReader) CloseContext(ctx context.Context) error {
 	m.mu.Lock()
 	if m.closed {
 		m.mu.Unlock()
 		return nil
 	}
 	m.closed = true
+	openReaders.Add(-1)
+	close(m.closeCh)
 	if m.pfCancel != nil {
 		m.pfCancel()
 	}
@@ -171,17 +453,36 @@ func (m *MultiReader) Close() error {
 	m.mu.Unlock()
 
 	if pfDone != nil {
-		<-pfDone
+		var grace <-chan time.Time
+		if m.closeGrace > 0 {
+			timer := time.NewTimer(m.closeGrace)
+			defer timer.Stop()
+			grace = timer.C
+		}
+		select {
+		case <-pfDone:
+		case <-grace: // Префетчер завис в чтении источника - бросаем его, источники закроются после его выхода
+			m.background.Add(1)
+			go m.closeAfterPrefetch(pfDone)
+			return fmt.Errorf("error when closing: %w", ErrCloseTimeout)
+		case <-ctx.Done():
+			m.background.Add(1)
+			go m.closeAfterPrefetch(pfDone)
+			return fmt.Errorf("error when closing: %w", ctx.Err())
+		}
 	}
 
-	var multiErr error
-	for _, r := range m.readers {
-		err := r.Close()
-		if err != nil {
-			multiErr = errors.Join(multiErr, err)
+	cacheErr := errors.Join(m.cache.close(), m.trace.err())
+	if m.borrowedSources { // Источники принадлежат вызывающему - не закрываем их
+		if cacheErr != nil {
+			return fmt.Errorf("error when closing: %w", cacheErr)
 		}
+		return nil
 	}
 
+	m.srcMu.Lock()
+	eagerErr := m.eagerCloseErr
+	multiErr := errors.Join(eagerErr, cacheErr, m.closeSourcesAndUnlock(ctx))
 	if multiErr != nil {
 		return fmt.Errorf("error when closing: %w", multiErr)
 	}
@@ -189,9 +490,108 @@ func (m *MultiReader) Close() error {
 	return nil
 }
 
-// Size возвращает суммарный размер всех ридеров.
+// Size возвращает суммарный размер всех ридеров (с учётом роста, обнаруженного Refresh).
 func (m *MultiReader) Size() int64 {
-	return m.totalSize
+	return m.size.Load()
+}
+
+// ReadAt читает len(p) байт с абсолютной позиции off, не сдвигая курсор и не затрагивая окно префетча.
+func (m *MultiReader) ReadAt(p []byte, off int64) (n int, err error) {
+	defer func() {
+		bytesStreamed.Add(int64(n))
+		if m.trace != nil {
+			m.trace.readAt(off, len(p), p[:n], err)
+		}
+	}()
+	if off < 0 {
+		return 0, fmt.Errorf("invalid offset: %d", off)
+	}
+
+	select {
+	case <-m.closeCh: // Закрытие проверяем без m.mu - ReadAt не конкурирует с потребителем окна
+		return 0, io.ErrClosedPipe
+	default:
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if off >= m.size.Load() {
+		return 0, io.EOF
+	}
+
+	if pinned := m.pinnedAt(off); len(pinned) >= len(p) { // Вся область закреплена
+		return copy(p, pinned), nil
+	}
+	if m.cache != nil {
+		return m.readAtCached(p, off)
+	}
+
+	m.srcMu.Lock()
+	defer m.srcMu.Unlock()
+	return m.readAtSourcesLocked(p, off)
+}
+
+// readAtSourcesLocked читает len(p) байт с абсолютной позиции off напрямую из источников. Требует удержания m.srcMu
+func (m *MultiReader) readAtSourcesLocked(p []byte, off int64) (n int, err error) {
+	m.srcGen++ // Позиции источников сбиваются - префетчер должен сделать Seek перед следующим чтением
+	prefixSizes, totalSize := m.prefixes(), m.size.Load()
+
+	for n < len(p) && off < totalSize {
+		i := m.readerIndex(off)
+		chunk := p[n:min(int64(len(p)), int64(n)+prefixSizes[i+1]-off)]
+		if err = m.ensureSourceOpenLocked(i); err != nil {
+			return n, err
+		}
+		var k int
+		err = guardSource(i, func() (err error) {
+			if ra, ok := positionalReaderOf(m.readers[i]); ok {
+				start := time.Now()
+				k, err = readAtFull(ra, chunk, off-prefixSizes[i])
+				m.noteSourceRead(i, k, start)
+				return err
+			}
+			m.noteSourceSeek(i)
+			if _, err := m.readers[i].Seek(off-prefixSizes[i], io.SeekStart); err != nil {
+				return err
+			}
+			start := time.Now()
+			k, err = readFullProgress(m.readers[i], chunk, m.maxEmptyReads)
+			m.noteSourceRead(i, k, start)
+			return err
+		})
+		n += k
+		off += int64(k)
+		switch {
+		case errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF): // Источник оказался короче объявленного размера
+			return n, io.ErrUnexpectedEOF
+		case err != nil:
+			return n, err
+		}
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+
+	return n, nil
+}
+
+// readerIndex возвращает индекс ридера, содержащего абсолютную позицию pos (pos < totalSize).
+func (m *MultiReader) readerIndex(pos int64) int {
+	prefixSizes := m.prefixes()
+	if m.coarseIndex == nil {
+		return sort.Search(len(m.readers), func(i int) bool { return prefixSizes[i+1] > pos })
+	}
+
+	// Сначала фрагмент по грубому индексу, затем ридер внутри фрагмента
+	chunk := sort.Search(len(m.coarseIndex), func(i int) bool { return m.coarseIndex[i] > pos }) - 1
+	from := chunk * indexChunk
+	to := min(from+indexChunk, len(m.readers))
+	return from + sort.Search(to-from, func(i int) bool { return prefixSizes[from+i+1] > pos })
+}
+
+// prefixes возвращает префиксные суммы размеров источников. Срез не изменяется: Refresh публикует новую копию.
+func (m *MultiReader) prefixes() []int64 {
+	return *m.prefix.Load()
 }
 
 // startPrefetchLocked запускает горутину префетчера, читающую блоки в каналы.
@@ -200,12 +600,14 @@ func (m *MultiReader) startPrefetchLocked(startPos int64) {
 		return
 	}
 	ctx, cancel := context.WithCancel(context.Background())
-	m.pfBufCh = make(chan []byte, m.buffersNum)
+	m.pfBufCh = make(chan []byte, m.queueSlots())
 	m.pfErrCh = make(chan error, 1)
 	m.pfCancel = cancel
 	m.pfDone = make(chan struct{})
 	m.pfStarted = true
-	go m.prefetchLoop(ctx, startPos)
+	m.pfWarm = false
+	m.stats.starts.Add(1)
+	m.sched.Go(func() { m.prefetchLoop(ctx, startPos) })
 }
 
 // prefetchLoop - горутина префетча. Наполняет pfBufCh блоками, по завершении шлёт ошибку в pfErrCh.
@@ -213,63 +615,201 @@ func (m *MultiReader) prefetchLoop(ctx context.Context, startPos int64) {
 	pfBufCh := m.pfBufCh // Локальные копии каналов для безопасного закрытия без гонок
 	pfDone := m.pfDone
 	pfErrCh := m.pfErrCh
+	livePrefetchers.Add(1)
 	defer func() {
+		livePrefetchers.Add(-1)
 		close(pfDone)
 		close(pfBufCh)
 		close(pfErrCh)
 	}()
 
+	prefixSizes, totalSize := m.prefixes(), m.size.Load() // Refresh меняет раскладку только после остановки префетчера
 	curPos := startPos
 	curReaderIdx := -1
+	lastReaderIdx := -1
 	needSeek := true
+	emptyReads := 0   // чтения (0, nil) подряд из текущего источника
+	stuckRetries := 0 // повторы текущего блока после отмены сторожем
+	var seenGen uint64
 
 	for {
 		// Общий EOF: больше данных не будет, уведомляем и завершаемся
-		if curPos >= m.totalSize {
+		if curPos >= totalSize {
+			m.sched.Yield(ctx, schedBeforeEOF)
 			sendErr(pfErrCh, io.EOF)
 			return
 		}
 
+		// Закреплённые области отдаются из памяти; источник после них нужно заново позиционировать
+		if data := m.pinnedAt(curPos); data != nil {
+			data = data[:min(len(data), bufferSize)]
+			if err := m.publishBlock(ctx, pfBufCh, data); err != nil {
+				sendErr(pfErrCh, err)
+				return
+			}
+			curPos += int64(len(data))
+			curReaderIdx = -1
+			continue
+		}
+
+		// С кэшем блоки берутся из него (или читаются в него целиком), а не напрямую из источников
+		if m.cache != nil {
+			n, err := m.prefetchCached(ctx, pfBufCh, curPos)
+			curPos += n
+			if err != nil {
+				sendErr(pfErrCh, err)
+				return
+			}
+			continue
+		}
+
 		// Выбор активного ридера и установка needSeek
-		if curReaderIdx < 0 || !(m.prefixSizes[curReaderIdx] <= curPos && curPos < m.prefixSizes[curReaderIdx+1]) {
-			curReaderIdx = sort.Search(len(m.readers), func(i int) bool { return m.prefixSizes[i+1] > curPos })
+		if curReaderIdx < 0 || !(prefixSizes[curReaderIdx] <= curPos && curPos < prefixSizes[curReaderIdx+1]) {
+			curReaderIdx = m.readerIndex(curPos)
 			needSeek = true
+			if m.eagerClose && !m.borrowedSources && 0 <= lastReaderIdx && lastReaderIdx < curReaderIdx { // Префетчер ушёл за источник - освобождаем его
+				m.srcMu.Lock()
+				m.closeSourceLocked(lastReaderIdx)
+				m.srcMu.Unlock()
+			}
+			lastReaderIdx = curReaderIdx
 		}
 		reader := m.readers[curReaderIdx]
+		ra, positional := m.positionalSource(reader) // Позиционный источник читается по смещению, Seek не нужен
+
+		m.srcMu.Lock()
+		if m.srcGen != seenGen { // Между нашими чтениями источники двигал ReadAt
+			seenGen = m.srcGen
+			needSeek = true
+		}
 
 		// Выполнение Seek и сброс needSeek
 		if needSeek {
-			localOffset := curPos - m.prefixSizes[curReaderIdx]
-			_, err := reader.Seek(localOffset, io.SeekStart)
+			localOffset := curPos - prefixSizes[curReaderIdx]
+			err := guardSource(curReaderIdx, func() error {
+				if err := m.ensureSourceOpenLocked(curReaderIdx); err != nil || positional {
+					return err
+				}
+				m.noteSourceSeek(curReaderIdx)
+				_, err := reader.Seek(localOffset, io.SeekStart)
+				return err
+			})
+			if err == nil {
+				m.adviseSequential(reader)
+			}
 			if err != nil {
+				m.srcMu.Unlock()
 				sendErr(pfErrCh, err)
 				return
 			}
 			needSeek = false
 		}
 
+		// Параллельное чтение остатка позиционного источника несколькими запросами сразу
+		if _, viewer := reader.(blockViewer); positional && !viewer && m.parallelReads > 1 && curPos < prefixSizes[curReaderIdx+1] {
+			m.srcMu.Unlock()
+			n, err := m.prefetchParallel(ctx, pfBufCh, ra, curReaderIdx, curPos, m.pinLimit(curPos, prefixSizes[curReaderIdx+1]))
+			curPos += n
+			switch {
+			case errors.Is(err, io.EOF): // Источник короче объявленного размера - как и при обычном чтении, переходим к следующему
+				curPos = prefixSizes[curReaderIdx+1]
+				curReaderIdx = -1
+			case err != nil:
+				sendErr(pfErrCh, err)
+				return
+			}
+			continue
+		}
+
 		// Выполнение Read
 		nextReader := func() {
-			curPos = m.prefixSizes[curReaderIdx+1]
+			curPos = prefixSizes[curReaderIdx+1]
 			curReaderIdx = -1
 			needSeek = true
 		}
-		remainInReader := int(m.prefixSizes[curReaderIdx+1] - curPos)
+		remainInReader := int(prefixSizes[curReaderIdx+1] - curPos)
 		if remainInReader == 0 { // Достигли границы ридеров
+			m.srcMu.Unlock()
 			nextReader()
 			continue
 		}
-		toRead := min(remainInReader, bufferSize)
-		buf := make([]byte, toRead)
-		n, err := reader.Read(buf)
+
+		// Хвост мелкого источника дополняется следующими источниками до полного блока
+		if m.coalesceReads && remainInReader < bufferSize && curReaderIdx+1 < len(m.readers) {
+			buf := make([]byte, m.pinLimit(curPos, min(curPos+bufferSize, totalSize))-curPos)
+			n, err := m.readAtSourcesLocked(buf, curPos)
+			seenGen = m.srcGen
+			m.srcMu.Unlock()
+			if n > 0 {
+				if err := m.publishBlock(ctx, pfBufCh, buf[:n]); err != nil {
+					sendErr(pfErrCh, err)
+					return
+				}
+				curPos += int64(n)
+			}
+			if err != nil {
+				sendErr(pfErrCh, err)
+				return
+			}
+			curReaderIdx = -1
+			continue
+		}
+
+		toRead := int(m.pinLimit(curPos, curPos+int64(min(remainInReader, bufferSize))) - curPos)
+		var (
+			buf []byte
+			n   int
+			err error
+		)
+		localOffset := curPos - prefixSizes[curReaderIdx]
+		m.adviseWillNeed(reader, localOffset)
+		start := time.Now()
+		readCtx, unwatch := m.watchRead(ctx, curReaderIdx, curPos, stuckRetries)
+		err = guardSource(curReaderIdx, func() (err error) {
+			// Блок отдаётся срезом памяти источника, без копирования. С WithEagerClose источник закроется, пока его
+			// блоки ещё ждут в очереди, - тогда память источника не отдаём и читаем в свой буфер
+			if v, ok := reader.(blockViewer); ok && !m.eagerClose {
+				buf, err = v.viewAt(localOffset, toRead)
+				n = len(buf)
+				return err
+			}
+			buf = make([]byte, toRead)
+			switch cr, ok := reader.(ContextReader); {
+			case positional:
+				n, err = readAtFull(ra, buf, localOffset)
+			case ok: // Источник умеет прерывать чтение по отмене префетча или сторожем
+				n, err = cr.ReadContext(readCtx, buf)
+			default:
+				n, err = reader.Read(buf)
+			}
+			return err
+		})
+		m.noteSourceRead(curReaderIdx, n, start)
+		m.srcMu.Unlock()
+		if unwatch() && err != nil { // Зависшее чтение отменено сторожем - повторяем с новой позиции
+			stuckRetries++
+			needSeek = true // Позиция источника после прерванного чтения неизвестна
+			if n == 0 {
+				continue
+			}
+			err = nil
+		} else if n > 0 {
+			stuckRetries = 0
+		}
+		if n == 0 && err == nil { // Источник не продвинулся - не крутимся бесконечно, выделяя блоки
+			if emptyReads++; emptyReads >= m.maxEmptyReads {
+				sendErr(pfErrCh, io.ErrNoProgress)
+				return
+			}
+			continue
+		}
+		emptyReads = 0
 		if n > 0 {
-			select {
-			case <-ctx.Done():
-				sendErr(pfErrCh, ctx.Err())
+			if err := m.publishBlock(ctx, pfBufCh, buf[:n]); err != nil { // Ждем, пока окно освободится
+				sendErr(pfErrCh, err)
 				return
-			case pfBufCh <- buf[:n]: // Ждем, пока окно освободиться, чтобы записать следующий блок
-				curPos += int64(n) // Обновляем глобальную позицию на фактически прочитанные байты
 			}
+			curPos += int64(n) // Обновляем глобальную позицию на фактически прочитанные байты
 		}
 		if err != nil {
 			if errors.Is(err, io.EOF) { // Достигли конца этого ридера
@@ -282,6 +822,83 @@ func (m *MultiReader) prefetchLoop(ctx context.Context, startPos int64) {
 	}
 }
 
+// waitBlock ждёт следующий блок от префетчера. Для первого блока после запуска префетча действует coldStartTimeout,
+// для любого - срок чтения, начатого в start (SetReadDeadline, WithReadTimeout).
+func (m *MultiReader) waitBlock(start time.Time) ([]byte, bool, error) {
+	m.mu.Lock()
+	pfBufCh, queued := m.pfBufCh, m.pfQueued
+	timeout := m.coldStartTimeout
+	if m.pfWarm {
+		timeout = 0
+	}
+	pos := m.absPos
+	m.mu.Unlock()
+
+	var coldStart <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		coldStart = timer.C
+	}
+
+	select {
+	case buf, ok := <-pfBufCh:
+		if !ok {
+			return nil, false, nil
+		}
+		buf, err := m.takeBlock(queued, buf)
+		return buf, true, err
+	default:
+		m.stats.consumerStalls.Add(1) // Блока ещё нет - ждём префетчер
+	}
+	var deadlineTimer *time.Timer
+	defer func() {
+		if deadlineTimer != nil {
+			deadlineTimer.Stop()
+		}
+	}()
+	for {
+		m.mu.Lock()
+		deadline, deadlineChanged := m.readDeadlineLocked(start), m.deadlineCh
+		m.mu.Unlock()
+		var expired <-chan time.Time
+		if !deadline.IsZero() {
+			if deadlineTimer == nil {
+				deadlineTimer = time.NewTimer(time.Until(deadline))
+			} else {
+				deadlineTimer.Reset(time.Until(deadline))
+			}
+			expired = deadlineTimer.C
+		}
+
+		select {
+		case buf, ok := <-pfBufCh:
+			if !ok {
+				return nil, false, nil
+			}
+			buf, err := m.takeBlock(queued, buf)
+			return buf, true, err
+		case <-coldStart:
+			return nil, false, &ColdStartTimeoutError{Segment: m.readerIndex(pos), Timeout: timeout}
+		case <-expired:
+			return nil, false, os.ErrDeadlineExceeded
+		case <-deadlineChanged: // Срок сменили - пересчитываем
+		case <-m.closeCh: // Close не ждёт, пока зависший префетчер закроет канал
+			return nil, false, io.ErrClosedPipe
+		}
+	}
+}
+
+// appendWindowLocked дописывает data в окно. Если append выделяет новый массив, старый освобождается,
+// и память окна - ёмкость нового. Требует удержания m.mu
+func (m *MultiReader) appendWindowLocked(data []byte) {
+	grow := cap(m.windowBuf)-len(m.windowBuf) < len(data)
+	m.windowBuf = append(m.windowBuf, data...)
+	if grow {
+		m.windowMem = int64(cap(m.windowBuf))
+	}
+}
+
 // readFromWindow копирует данные из окна в dst под локом. Возвращает (copied, true), если данные были.
 func (m *MultiReader) readFromWindow(dst []byte) (int, bool) {
 	m.mu.Lock()
@@ -302,6 +919,22 @@ func (m *MultiReader) readFromWindow(dst []byte) (int, bool) {
 	return toCopy, true
 }
 
+// prefetchResultLocked возвращает итоговую ошибку/EOF завершившегося префетчера. Она запоминается: окно после Peek
+// ещё может хранить данные, и следующий вызов должен получить тот же итог, а не закрытый канал. Требует удержания m.mu
+func (m *MultiReader) prefetchResultLocked() error {
+	select {
+	case err, ok := <-m.pfErrCh:
+		if ok {
+			m.pfErr = err
+		}
+	default:
+	}
+	if m.pfErr == nil {
+		return io.EOF
+	}
+	return m.pfErr
+}
+
 // resetPrefetchLocked останавливает текущий префетч и сбрасывает его поля. Требует удержания m.mu
 func (m *MultiReader) resetPrefetchLocked() {
 	if m.pfCancel != nil {
@@ -310,13 +943,49 @@ func (m *MultiReader) resetPrefetchLocked() {
 	if m.pfDone != nil { // Дождаться завершения старого префетчера, чтобы исключить параллельный доступ
 		<-m.pfDone
 	}
+	m.pfQueued = new(atomic.Int64) // Блоки старого префетчера выброшены вместе с каналом; читатель, ещё ждущий их, спишет их со старого счётчика
 	m.pfStarted = false
+	m.pfErr = nil
 	m.pfBufCh = nil
 	m.pfErrCh = nil
 	m.pfDone = nil
 	m.pfCancel = nil
 }
 
+// publishBlock передаёт блок префетчера в out, дожидаясь места в окне. Отмена ctx, пришедшая во время точки
+// планировщика или ожидания, приоритетнее публикации.
+func (m *MultiReader) publishBlock(ctx context.Context, out chan<- []byte, block []byte) error {
+	m.sched.Yield(ctx, schedBeforePublish)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if m.compress != nil {
+		block = m.compress.pack(block)
+		if err := m.waitQueueBudget(ctx, int64(len(block))); err != nil {
+			return err
+		}
+	}
+	if m.memPressure != nil {
+		if err := m.waitMemoryPressure(ctx); err != nil {
+			return err
+		}
+	}
+	select {
+	case out <- block:
+		m.pfQueued.Add(int64(len(block)))
+		return nil
+	default:
+		m.stats.producerStalls.Add(1) // Окно заполнено - ждём потребителя
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case out <- block:
+		m.pfQueued.Add(int64(len(block)))
+		return nil
+	}
+}
+
 // sendErr отправляет ошибку в канал, если есть место
 func sendErr(errCh chan<- error, err error) {
 	select {
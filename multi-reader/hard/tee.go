@@ -0,0 +1,26 @@
+package main
+
+import (
+	"io"
+	"sync"
+)
+
+// teeWriter - писатель, получающий копию байт, отданных потребителю.
+type teeWriter struct {
+	mu sync.Mutex // сохраняет порядок записей при параллельных чтениях
+	w  io.Writer
+}
+
+func (t *teeWriter) write(data []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, err := t.w.Write(data)
+	return err
+}
+
+// NewTeeMultiReader создаёт мультиридер, который пишет в w всё, что отдаёт потребителю (кэширование на лету, аудит).
+// В w попадают именно отданные байты: при Seek пропущенные данные в него не пишутся, а перечитанные пишутся повторно,
+// так что точной копией потока w будет только при последовательном чтении. Ошибка записи в w возвращается из Read.
+func NewTeeMultiReader(buffersNum int, w io.Writer, readers ...SizedReadSeekCloser) *MultiReader {
+	return NewMultiReaderWithOptions(buffersNum, readers, WithTee(w))
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"sync/atomic"
+	"time"
+)
+
+// ctxMockReader — mockStringsReader с поддержкой ReadContext: ожидание readGate прерывается отменой контекста.
+type ctxMockReader struct {
+	*mockStringsReader
+}
+
+func (c ctxMockReader) ReadContext(ctx context.Context, p []byte) (int, error) {
+	if c.readGate != nil {
+		select {
+		case <-c.readGate:
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+	return c.Reader.Read(p)
+}
+
+// sliceWriterAt — io.WriterAt поверх заранее выделенного среза.
+type sliceWriterAt struct {
+	buf []byte
+}
+
+func (w *sliceWriterAt) WriteAt(p []byte, off int64) (int, error) {
+	return copy(w.buf[off:], p), nil
+}
+
+// noSeekFS - fs.FS, скрывающий Seek у открываемых файлов.
+type noSeekFS struct {
+	fsys fs.FS
+}
+
+func (n noSeekFS) Open(name string) (fs.File, error) {
+	f, err := n.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return struct{ fs.File }{f}, nil
+}
+
+// slowReaderAt - io.ReaderAt с задержкой, считающий максимальное число одновременных чтений.
+type slowReaderAt struct {
+	ra       io.ReaderAt
+	delay    time.Duration
+	inFlight atomic.Int32
+	maxSeen  atomic.Int32
+}
+
+func (s *slowReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	cur := s.inFlight.Add(1)
+	defer s.inFlight.Add(-1)
+	for {
+		prev := s.maxSeen.Load()
+		if cur <= prev || s.maxSeen.CompareAndSwap(prev, cur) {
+			break
+		}
+	}
+	time.Sleep(s.delay)
+	return s.ra.ReadAt(p, off)
+}
+
+// mockWriteSeeker — SizedWriteSeekCloser поверх среза фиксированного размера.
+type mockWriteSeeker struct {
+	buf      []byte
+	pos      int64
+	closed   bool
+	closeErr error
+}
+
+func newMockWriteSeeker(size int) *mockWriteSeeker {
+	return &mockWriteSeeker{buf: make([]byte, size)}
+}
+
+func (w *mockWriteSeeker) Write(p []byte) (int, error) {
+	n := copy(w.buf[w.pos:], p)
+	w.pos += int64(n)
+	if n < len(p) {
+		return n, io.ErrShortWrite
+	}
+	return n, nil
+}
+
+func (w *mockWriteSeeker) Seek(offset int64, whence int) (int64, error) {
+	if whence != io.SeekStart {
+		return 0, errors.New("mock supports only io.SeekStart")
+	}
+	w.pos = offset
+	return offset, nil
+}
+
+func (w *mockWriteSeeker) Close() error {
+	w.closed = true
+	return w.closeErr
+}
+
+func (w *mockWriteSeeker) Size() int64 {
+	return int64(len(w.buf))
+}
+
+// errWriter — io.Writer, всегда возвращающий err.
+type errWriter struct {
+	err error
+}
+
+func (w errWriter) Write([]byte) (int, error) {
+	return 0, w.err
+}
+
+// recordingT - ConformanceT, запоминающий сообщения о нарушениях.
+type recordingT struct {
+	errs []string
+}
+
+func (r *recordingT) Helper() {}
+
+func (r *recordingT) Errorf(format string, args ...any) {
+	r.errs = append(r.errs, fmt.Sprintf(format, args...))
+}
+
+// stallingReader отвечает (0, nil) на первые stalls вызовов Read (stalls < 0 - всегда), затем читает из источника.
+type stallingReader struct {
+	SizedReadSeekCloser
+	stalls int
+}
+
+func (s *stallingReader) Read(p []byte) (int, error) {
+	if s.stalls != 0 && len(p) > 0 {
+		s.stalls--
+		return 0, nil
+	}
+	return s.SizedReadSeekCloser.Read(p)
+}
+
+// panickingReader паникует в Read, как сломанный сторонний ридер.
+type panickingReader struct {
+	SizedReadSeekCloser
+}
+
+func (s *panickingReader) Read(p []byte) (int, error) {
+	var m map[string]int
+	m["boom"] = len(p) // Запись в nil-map - runtime.Error
+	return 0, nil
+}
+
+// writerFunc - io.Writer из функции.
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
+
+// panickingCloser паникует в Close, как сломанный сторонний источник.
+type panickingCloser struct {
+	SizedReadSeekCloser
+}
+
+func (s *panickingCloser) Close() error {
+	panic("close failed")
+}
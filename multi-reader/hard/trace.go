@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Формат трассы - по строке на операцию, поля через пробел:
+//
+//	R <len> <n> <crc32> <err>           Read в буфер длины len
+//	S <offset> <whence> <pos> <err>     Seek
+//	A <off> <len> <n> <crc32> <err>     ReadAt
+//
+// crc32 (IEEE, hex) считается по отданным байтам, err - класс ошибки: nil, EOF, closed или error.
+// EOF вместе с данными в Read записывается как nil: реализации вправе вернуть его и следующим вызовом.
+// Тексты ошибок в трассу не попадают: они различаются между реализациями и могут содержать данные клиента.
+
+// traceRecorder пишет операции мультиридера в трассу.
+type traceRecorder struct {
+	mu     sync.Mutex // сохраняет строки целыми при параллельных операциях
+	w      io.Writer
+	failed error // первая ошибка записи; после неё запись прекращается
+}
+
+func (t *traceRecorder) record(format string, args ...any) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.failed == nil {
+		_, t.failed = fmt.Fprintf(t.w, format+"\n", args...)
+	}
+}
+
+func (t *traceRecorder) read(size int, data []byte, err error) {
+	t.record("R %d %d %08x %s", size, len(data), crc32.ChecksumIEEE(data), readClass(len(data), err))
+}
+
+func (t *traceRecorder) seek(offset int64, whence int, pos int64, err error) {
+	t.record("S %d %d %d %s", offset, whence, pos, errorClass(err))
+}
+
+func (t *traceRecorder) readAt(off int64, size int, data []byte, err error) {
+	t.record("A %d %d %d %08x %s", off, size, len(data), crc32.ChecksumIEEE(data), errorClass(err))
+}
+
+// err возвращает ошибку записи трассы. Безопасен для nil.
+func (t *traceRecorder) err() error {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.failed != nil {
+		return fmt.Errorf("write trace: %w", t.failed)
+	}
+	return nil
+}
+
+// TraceMismatchError описывает первую операцию трассы, результат которой при воспроизведении разошёлся с записанным.
+type TraceMismatchError struct {
+	Line int    // номер строки трассы (с 1)
+	Op   string // строка трассы
+	Got  string // результат воспроизведения в формате трассы
+}
+
+func (e *TraceMismatchError) Error() string {
+	return fmt.Sprintf("trace line %d: recorded %q, replayed %q", e.Line, e.Op, e.Got)
+}
+
+// ReplayTrace заново выполняет операции трассы, записанной WithTrace, над r - любой реализацией io.ReadSeeker
+// (для операций ReadAt r должен реализовывать и io.ReaderAt) - и сверяет результаты: число байт, их crc32,
+// позицию после Seek и класс ошибки. Возвращает *TraceMismatchError на первом расхождении.
+// Read сверяется строго: реализация, законно отдающая данные другими порциями, тоже даст расхождение.
+func ReplayTrace(trace io.Reader, r io.ReadSeeker) error {
+	sc := bufio.NewScanner(trace)
+	for line := 1; sc.Scan(); line++ {
+		op := sc.Text()
+		if op == "" {
+			continue
+		}
+		got, err := replayTraceOp(op, r)
+		if err != nil {
+			return fmt.Errorf("trace line %d: %w", line, err)
+		}
+		if got != op {
+			return &TraceMismatchError{Line: line, Op: op, Got: got}
+		}
+	}
+
+	return sc.Err()
+}
+
+// replayTraceOp выполняет над r одну операцию трассы и возвращает её результат в формате трассы.
+func replayTraceOp(op string, r io.ReadSeeker) (string, error) {
+	var (
+		got strings.Builder
+		rec = &traceRecorder{w: &got}
+		n   int
+		err error
+	)
+	switch op[0] {
+	case 'R':
+		var size, wantN int
+		if _, err := fmt.Sscanf(op, "R %d %d", &size, &wantN); err != nil || size < 0 {
+			return "", fmt.Errorf("malformed op %q", op)
+		}
+		p := make([]byte, size)
+		n, err = r.Read(p)
+		rec.read(size, p[:n], err)
+	case 'S':
+		var (
+			offset int64
+			whence int
+		)
+		if _, err := fmt.Sscanf(op, "S %d %d", &offset, &whence); err != nil {
+			return "", fmt.Errorf("malformed op %q", op)
+		}
+		pos, err := r.Seek(offset, whence)
+		rec.seek(offset, whence, pos, err)
+	case 'A':
+		ra, ok := r.(io.ReaderAt)
+		if !ok {
+			return "", errors.New("ReadAt op, but reader does not implement io.ReaderAt")
+		}
+		var (
+			off  int64
+			size int
+		)
+		if _, err := fmt.Sscanf(op, "A %d %d", &off, &size); err != nil || size < 0 {
+			return "", fmt.Errorf("malformed op %q", op)
+		}
+		p := make([]byte, size)
+		n, err = ra.ReadAt(p, off)
+		rec.readAt(off, size, p[:n], err)
+	default:
+		return "", fmt.Errorf("unknown op %q", op)
+	}
+
+	return strings.TrimSuffix(got.String(), "\n"), nil
+}
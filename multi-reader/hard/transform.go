@@ -0,0 +1,133 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Transform превращает сырой источник в логический поток (распаковка, расшифровка и т.п.).
+// Результат должен поддерживать Seek по логическим смещениям. Если он реализует io.Closer, TransformSource
+// закроет его перед сырым источником; сам raw закрывать не нужно.
+type Transform func(raw SizedReadSeekCloser) (io.ReadSeeker, error)
+
+// transformedSource - источник, отдающий преобразованное содержимое сырого источника под объявленным логическим размером.
+type transformedSource struct {
+	raw       SizedReadSeekCloser // сырой источник
+	transform Transform           // преобразование, применяемое при первом чтении
+	size      int64               // логический (после преобразования) размер
+	out       io.ReadSeeker       // результат преобразования (nil - ещё не применено)
+	outPos    int64               // позиция внутри out
+	pos       int64               // логическая позиция чтения
+	closed    bool                // флаг закрытия
+}
+
+// Проверка, что transformedSource удовлетворяет интерфейсу SizedReadSeekCloser
+var _ SizedReadSeekCloser = (*transformedSource)(nil)
+
+// TransformSource навешивает на сырой источник преобразование t. size - логический размер после преобразования:
+// именно он участвует в префиксных суммах мультиридера, поэтому сырой источник не открывается до первого чтения.
+func TransformSource(raw SizedReadSeekCloser, size int64, t Transform) SizedReadSeekCloser {
+	return &transformedSource{
+		raw:       raw,
+		transform: t,
+		size:      size,
+	}
+}
+
+// Read применяет преобразование при необходимости и читает логический поток с текущей позиции.
+func (s *transformedSource) Read(p []byte) (int, error) {
+	if s.closed {
+		return 0, io.ErrClosedPipe
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if s.pos >= s.size {
+		return 0, io.EOF
+	}
+
+	if err := s.ensureTransformed(); err != nil {
+		return 0, err
+	}
+
+	n, err := s.out.Read(p[:min(int64(len(p)), s.size-s.pos)])
+	s.pos += int64(n)
+	s.outPos += int64(n)
+	if errors.Is(err, io.EOF) {
+		if s.pos < s.size { // Преобразованный поток короче объявленного размера
+			return n, fmt.Errorf("transformed source ended at %d, declared size %d: %w", s.pos, s.size, io.ErrUnexpectedEOF)
+		}
+		err = nil // EOF вернём следующим вызовом
+	}
+
+	return n, err
+}
+
+// Seek перемещает логическую позицию. Позиция в преобразованном потоке выставляется при следующем чтении.
+func (s *transformedSource) Seek(offset int64, whence int) (int64, error) {
+	if s.closed {
+		return 0, io.ErrClosedPipe
+	}
+
+	var base int64
+	switch whence {
+	case io.SeekStart:
+		base = 0
+	case io.SeekCurrent:
+		base = s.pos
+	case io.SeekEnd:
+		base = s.size
+	default:
+		return 0, fmt.Errorf("invalid whence: %d", whence)
+	}
+
+	seekPos := base + offset
+	if seekPos < 0 || seekPos > s.size {
+		return 0, fmt.Errorf("seek position (%d) should be >= 0 and <= size (%d)", seekPos, s.size)
+	}
+	s.pos = seekPos
+
+	return seekPos, nil
+}
+
+// Close закрывает результат преобразования (если он io.Closer) и сырой источник, агрегируя ошибки.
+func (s *transformedSource) Close() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	var multiErr error
+	if c, ok := s.out.(io.Closer); ok {
+		multiErr = c.Close()
+	}
+	s.out = nil
+
+	return errors.Join(multiErr, s.raw.Close())
+}
+
+// Size возвращает логический размер.
+func (s *transformedSource) Size() int64 {
+	return s.size
+}
+
+// ensureTransformed применяет преобразование (один раз) и выставляет в результате логическую позицию.
+func (s *transformedSource) ensureTransformed() error {
+	if s.out == nil {
+		out, err := s.transform(s.raw)
+		if err != nil {
+			return fmt.Errorf("transform source: %w", err)
+		}
+		s.out, s.outPos = out, 0
+	}
+
+	if s.outPos != s.pos {
+		if _, err := s.out.Seek(s.pos, io.SeekStart); err != nil {
+			return err
+		}
+		s.outPos = s.pos
+	}
+
+	return nil
+}
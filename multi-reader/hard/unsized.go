@@ -0,0 +1,151 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrSeekBackward возвращается при попытке Seek назад в потоке, поддерживающем только чтение вперёд.
+var ErrSeekBackward = errors.New("backward seek is not supported by forward-only stream")
+
+// seekerSource - адаптер io.ReadSeekCloser к SizedReadSeekCloser, определяющий размер через Seek.
+type seekerSource struct {
+	io.ReadSeekCloser
+	size      int64 // размер, определённый при первом обращении
+	sizeKnown bool  // флаг - размер уже определён
+}
+
+// Size возвращает размер источника, определяя его при первом вызове через Seek(0, io.SeekEnd).
+// Если определить размер не удалось, возвращает UnknownSize.
+func (s *seekerSource) Size() int64 {
+	if !s.sizeKnown {
+		size, err := s.probeSize()
+		if err != nil {
+			return UnknownSize
+		}
+		s.size, s.sizeKnown = size, true
+	}
+	return s.size
+}
+
+// probeSize переходит в конец источника, чтобы узнать размер, и возвращает позицию на место.
+func (s *seekerSource) probeSize() (int64, error) {
+	cur, err := s.ReadSeekCloser.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	end, err := s.ReadSeekCloser.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, err
+	}
+	if _, err = s.ReadSeekCloser.Seek(cur, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return end, nil
+}
+
+// NewMultiReaderFromSeekers создаёт мультиридер поверх источников без известного заранее размера:
+// размер каждого определяется через Seek(0, io.SeekEnd) с возвратом на исходную позицию.
+func NewMultiReaderFromSeekers(buffersNum int, readers ...io.ReadSeekCloser) (*MultiReader, error) {
+	sources := make([]SizedReadSeekCloser, len(readers))
+	for i, r := range readers {
+		src := &seekerSource{ReadSeekCloser: r}
+		size, err := src.probeSize()
+		if err != nil {
+			return nil, fmt.Errorf("source %d: determine size: %w", i, err)
+		}
+		src.size, src.sizeKnown = size, true
+		sources[i] = src
+	}
+
+	return NewMultiReader(buffersNum, sources...), nil
+}
+
+// ForwardReader объединяет потоковые io.Reader (например, пайпы) в режиме «только вперёд».
+// Размер неизвестен, Seek назад и относительно конца отклоняются, Seek вперёд пропускает данные.
+type ForwardReader struct {
+	readers []io.Reader // исходные потоки
+	r       io.Reader   // последовательное чтение всех потоков
+	pos     int64       // количество уже прочитанных или пропущенных байт
+	closed  bool        // флаг закрытия
+}
+
+// Проверка, что ForwardReader удовлетворяет интерфейсу SizedReadSeekCloser
+var _ SizedReadSeekCloser = (*ForwardReader)(nil)
+
+// NewForwardReader создаёт поток «только вперёд» поверх набора io.Reader.
+func NewForwardReader(readers ...io.Reader) *ForwardReader {
+	return &ForwardReader{
+		readers: readers,
+		r:       io.MultiReader(readers...),
+	}
+}
+
+// Read читает потоки последовательно.
+func (f *ForwardReader) Read(p []byte) (int, error) {
+	if f.closed {
+		return 0, io.ErrClosedPipe
+	}
+	n, err := f.r.Read(p)
+	f.pos += int64(n)
+	return n, err
+}
+
+// Seek поддерживает только перемещение вперёд (io.SeekStart и io.SeekCurrent), пропуская данные.
+func (f *ForwardReader) Seek(offset int64, whence int) (int64, error) {
+	if f.closed {
+		return 0, io.ErrClosedPipe
+	}
+
+	var seekPos int64
+	switch whence {
+	case io.SeekStart:
+		seekPos = offset
+	case io.SeekCurrent:
+		seekPos = f.pos + offset
+	case io.SeekEnd:
+		return 0, ErrUnknownSize
+	default:
+		return 0, fmt.Errorf("invalid whence: %d", whence)
+	}
+
+	if seekPos < f.pos {
+		return 0, ErrSeekBackward
+	}
+	skipped, err := io.CopyN(io.Discard, f.r, seekPos-f.pos)
+	f.pos += skipped
+	if err != nil {
+		return f.pos, err
+	}
+
+	return f.pos, nil
+}
+
+// Close закрывает потоки, реализующие io.Closer, агрегируя ошибки.
+func (f *ForwardReader) Close() error {
+	if f.closed {
+		return nil
+	}
+	f.closed = true
+
+	var multiErr error
+	for _, r := range f.readers {
+		if c, ok := r.(io.Closer); ok {
+			if err := c.Close(); err != nil {
+				multiErr = errors.Join(multiErr, err)
+			}
+		}
+	}
+
+	if multiErr != nil {
+		return fmt.Errorf("error when closing: %w", multiErr)
+	}
+
+	return nil
+}
+
+// Size всегда возвращает UnknownSize - размер потоков заранее неизвестен.
+func (f *ForwardReader) Size() int64 {
+	return UnknownSize
+}
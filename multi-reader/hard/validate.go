@@ -0,0 +1,66 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"runtime"
+)
+
+// ErrInvalidSource - источник нельзя включить в склейку: nil, отрицательный размер, переполнение суммарного
+// размера или (с WithSeekProbe) неработающий Seek.
+var ErrInvalidSource = errors.New("invalid source")
+
+// NewMultiReaderE работает как NewMultiReaderWithOptions, но сначала проверяет источники и возвращает ошибку
+// вместо паники или испорченных префиксных сумм при первом Read. Ошибка оборачивает ErrInvalidSource
+// и называет индекс источника.
+func NewMultiReaderE(buffersNum int, readers []SizedReadSeekCloser, opts ...Option) (*MultiReader, error) {
+	var total int64
+	for i, r := range readers {
+		size, err := sourceSize(r)
+		if err != nil {
+			return nil, fmt.Errorf("source %d: %w: %w", i, ErrInvalidSource, err)
+		}
+		if size < 0 {
+			return nil, fmt.Errorf("source %d: %w: negative size %d", i, ErrInvalidSource, size)
+		}
+		if total > math.MaxInt64-size {
+			return nil, fmt.Errorf("source %d: %w: total size overflows int64", i, ErrInvalidSource)
+		}
+		total += size
+	}
+
+	m := NewMultiReaderWithOptions(buffersNum, readers, opts...)
+	if m.probeSeek {
+		for i, r := range readers {
+			if _, err := r.Seek(0, io.SeekStart); err != nil {
+				runtime.SetFinalizer(m, nil) // Источники остаются у вызывающего - WithFinalizer не должен их закрыть
+				return nil, fmt.Errorf("source %d: %w: seek: %w", i, ErrInvalidSource, err)
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// WithSeekProbe заставляет NewMultiReaderE проверить Seek(0, io.SeekStart) каждого источника при создании,
+// чтобы неперематываемый источник (например, сетевой поток) был отвергнут сразу, а не при первом Seek.
+func WithSeekProbe() Option {
+	return func(m *MultiReader) {
+		m.probeSeek = true
+	}
+}
+
+// sourceSize возвращает размер источника, превращая nil и панику в Size (типизированный nil) в ошибку.
+func sourceSize(r SizedReadSeekCloser) (size int64, err error) {
+	if r == nil {
+		return 0, errors.New("nil reader")
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("size panicked: %v", p)
+		}
+	}()
+	return r.Size(), nil
+}
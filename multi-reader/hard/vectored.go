@@ -0,0 +1,64 @@
+package main
+
+import "time"
+
+// ReadV заполняет буферы bufs по порядку (как readv/net.Buffers) за один вызов и возвращает общее число байт.
+// Останавливается на первой ошибке или EOF; частично заполненный буфер может быть только последним затронутым.
+func (m *MultiReader) ReadV(bufs [][]byte) (int64, error) {
+	var total int64
+	for _, b := range bufs {
+		n, err := m.Read(b)
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+	}
+
+	return total, nil
+}
+
+// Next возвращает до n следующих байт прямо из окна префетча, без копирования в буфер вызывающего, и сдвигает курсор.
+// Если окно пусто, ждёт очередной блок. Срез нельзя изменять и рассчитывать на него после следующего вызова мультиридера.
+func (m *MultiReader) Next(n int) (data []byte, err error) {
+	if n <= 0 {
+		return nil, nil
+	}
+
+	start := time.Now()
+	startPos, err := m.beginRead()
+	if err != nil {
+		return nil, err
+	}
+	defer m.inflight.Done()
+	defer func() {
+		if derr := m.delivered(startPos, data, err); derr != nil && err == nil {
+			err = derr
+		}
+	}()
+
+	for {
+		if data := m.nextFromWindow(n); data != nil {
+			return data, nil
+		}
+		if err := m.fillWindow(start); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// nextFromWindow отрезает до n байт от начала окна и сдвигает курсор. Пустое окно - nil.
+func (m *MultiReader) nextFromWindow(n int) []byte {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.windowBuf) == 0 {
+		return nil
+	}
+	k := min(n, len(m.windowBuf))
+	data := m.windowBuf[:k:k] // Ограничиваем ёмкость, чтобы append вызывающего не затёр окно
+	m.windowBuf = m.windowBuf[k:]
+	m.windowStart += int64(k)
+	m.absPos += int64(k)
+
+	return data
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// Watchdog настраивает сторож зависших источников: чтение блока префетчером дольше Threshold считается зависанием.
+type Watchdog struct {
+	Threshold time.Duration   // сколько может длиться чтение одного блока
+	OnStuck   func(StuckRead) // вызывается из отдельной горутины при каждом зависании (nil - только счётчик Stats.StuckReads)
+	Retries   int             // сколько раз подряд отменять и повторять зависшее чтение ContextReader (0 - только сообщать)
+}
+
+// StuckRead описывает чтение, превысившее Watchdog.Threshold.
+type StuckRead struct {
+	Index   int           // номер источника в склейке
+	Name    string        // имя источника, если он его сообщает
+	Offset  int64         // абсолютная позиция начала блока
+	Elapsed time.Duration // сколько длится чтение на момент срабатывания
+	Attempt int           // номер повтора этого блока (0 - первое чтение)
+}
+
+// WithWatchdog включает сторож зависших источников. Зависшее чтение источника, реализующего ContextReader,
+// отменяется и повторяется с той же позиции (не более w.Retries раз подряд): источник с репликами (ReplicaSource)
+// при повторе переходит на другую реплику, если политика (LatencyPolicy) штрафует прерванную.
+// Позиционные чтения и источники без ContextReader прервать нельзя - о них сторож только сообщает.
+func WithWatchdog(w Watchdog) Option {
+	return func(m *MultiReader) {
+		if w.Threshold > 0 {
+			m.watchdog = &w
+		}
+	}
+}
+
+// watchRead ставит сторож на чтение блока с абсолютной позиции off из i-го источника. Возвращает контекст для
+// ReadContext и функцию снятия сторожа, которая сообщает, было ли чтение отменено сторожем.
+func (m *MultiReader) watchRead(ctx context.Context, i int, off int64, attempt int) (context.Context, func() bool) {
+	w := m.watchdog
+	if w == nil {
+		return ctx, func() bool { return false }
+	}
+
+	readCtx, cancel := context.WithCancel(ctx)
+	start := time.Now()
+	var cancelled atomic.Bool
+	timer := time.AfterFunc(w.Threshold, func() {
+		m.stats.stuckReads.Add(1)
+		if w.OnStuck != nil {
+			w.OnStuck(StuckRead{Index: i, Name: sourceName(m.readers[i]), Offset: off, Elapsed: time.Since(start), Attempt: attempt})
+		}
+		if attempt < w.Retries {
+			cancelled.Store(true)
+			cancel()
+		}
+	})
+
+	return readCtx, func() bool {
+		timer.Stop()
+		cancel()
+		return cancelled.Load() && ctx.Err() == nil
+	}
+}
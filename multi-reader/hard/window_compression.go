@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// windowCompressionSlots - во сколько раз больше блоков вмещает очередь префетчера при сжатии. Бюджет памяти
+// очереди прежний, buffersNum*bufferSize, но считается по размеру сжатых блоков.
+const windowCompressionSlots = 8
+
+// Вид блока в очереди при сжатии - первый байт блока.
+const (
+	packedRaw   byte = iota // сжатие почти ничего не дало - блок хранится как есть
+	packedFlate             // uvarint длины исходного блока и поток deflate
+)
+
+var errCorruptPackedBlock = errors.New("corrupt packed window block")
+
+// windowPacker сжимает блоки, ждущие в очереди префетчера, и разжимает их при потреблении.
+type windowPacker struct {
+	writers sync.Pool    // *flate.Writer
+	readers sync.Pool    // io.ReadCloser из flate.NewReader (реализует flate.Resetter)
+	saved   atomic.Int64 // байт, сэкономленных сжатием
+}
+
+func newWindowPacker() *windowPacker {
+	c := &windowPacker{}
+	c.writers.New = func() any {
+		w, _ := flate.NewWriter(nil, flate.BestSpeed) // Ошибка возможна только при недопустимой степени сжатия
+		return w
+	}
+	c.readers.New = func() any { return flate.NewReader(nil) }
+	return c
+}
+
+// WithWindowCompression сжимает блоки, которые префетчер прочитал заранее и которые ждут своей очереди, и разжимает
+// их при потреблении. Память очереди по-прежнему ограничена buffersNum блоками, но считается по сжатому размеру,
+// так что для хорошо сжимаемых данных (тексты, логи) префетчер уходит вперёд до windowCompressionSlots раз дальше -
+// полезно при склейке больших текстовых источников по медленной сети. Плохо сжимаемые блоки хранятся как есть.
+// Сжатие - deflate с самой быстрой степенью; оно стоит времени префетчера, поэтому для быстрых локальных
+// источников опция скорее замедлит чтение.
+func WithWindowCompression() Option {
+	return func(m *MultiReader) {
+		m.compress = newWindowPacker()
+		if m.pfSpace == nil {
+			m.pfSpace = make(chan struct{}, 1)
+		}
+	}
+}
+
+// pack сжимает блок для очереди. Блок, сжавшийся меньше чем на 1/8, хранится как есть: разжатие ему не окупится.
+func (c *windowPacker) pack(block []byte) []byte {
+	var buf bytes.Buffer
+	buf.Grow(len(block)/2 + binary.MaxVarintLen64 + 1)
+	buf.WriteByte(packedFlate)
+	buf.Write(binary.AppendUvarint(nil, uint64(len(block))))
+	w := c.writers.Get().(*flate.Writer)
+	w.Reset(&buf)
+	_, _ = w.Write(block) // Запись в bytes.Buffer не возвращает ошибок
+	_ = w.Close()
+	c.writers.Put(w)
+
+	if buf.Len() >= len(block)-len(block)/8 {
+		packed := make([]byte, 1+len(block))
+		packed[0] = packedRaw
+		copy(packed[1:], block)
+		return packed
+	}
+	c.saved.Add(int64(len(block) - buf.Len()))
+	return buf.Bytes()
+}
+
+// unpack восстанавливает блок, сжатый pack.
+func (c *windowPacker) unpack(packed []byte) ([]byte, error) {
+	if len(packed) == 0 {
+		return nil, errCorruptPackedBlock
+	}
+	switch packed[0] {
+	case packedRaw:
+		return packed[1:], nil
+	case packedFlate:
+	default:
+		return nil, errCorruptPackedBlock
+	}
+	size, k := binary.Uvarint(packed[1:])
+	if k <= 0 || size > bufferSize {
+		return nil, errCorruptPackedBlock
+	}
+
+	r := c.readers.Get().(io.ReadCloser)
+	defer c.readers.Put(r)
+	if err := r.(flate.Resetter).Reset(bytes.NewReader(packed[1+k:]), nil); err != nil {
+		return nil, fmt.Errorf("unpack window block: %w", err)
+	}
+	block := make([]byte, size)
+	if _, err := io.ReadFull(r, block); err != nil {
+		return nil, fmt.Errorf("unpack window block: %w", err)
+	}
+	return block, nil
+}
+
+// takeBlock списывает блок, забранный из очереди префетчера, со счётчика queued, будит префетчер, ждущий
+// места в очереди (pfSpace), и возвращает данные блока, разжимая их при WithWindowCompression.
+func (m *MultiReader) takeBlock(queued *atomic.Int64, buf []byte) ([]byte, error) {
+	queued.Add(-int64(len(buf)))
+	if m.pfSpace != nil {
+		select {
+		case m.pfSpace <- struct{}{}:
+		default:
+		}
+	}
+	if m.compress == nil {
+		return buf, nil
+	}
+	return m.compress.unpack(buf)
+}
+
+// waitQueueBudget ждёт, пока сжатые блоки в очереди вместе с очередным блоком размера size уложатся в бюджет
+// buffersNum*bufferSize. Блок в пустую очередь принимается при любом размере.
+func (m *MultiReader) waitQueueBudget(ctx context.Context, size int64) error {
+	budget := int64(m.buffersNum) * bufferSize
+	stalled := false
+	for {
+		if queued := m.pfQueued.Load(); queued <= 0 || queued+size <= budget {
+			return nil
+		}
+		if !stalled {
+			m.stats.producerStalls.Add(1) // Бюджет очереди исчерпан - ждём потребителя
+			stalled = true
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-m.pfSpace:
+		}
+	}
+}
+
+// queueSlots возвращает ёмкость канала блоков префетчера.
+func (m *MultiReader) queueSlots() int {
+	if m.compress != nil {
+		return m.buffersNum * windowCompressionSlots
+	}
+	return m.buffersNum
+}